@@ -0,0 +1,85 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is a stable, machine-readable identifier for the kind of an error.
+// Clients should check the Code of an error instead of matching its message.
+type Code string
+
+const (
+	// CodeBadRequest is a request that is malformed or invalid.
+	CodeBadRequest Code = "bad_request"
+	// CodeInvalidMultihash is a request containing a multihash that cannot be
+	// decoded.
+	CodeInvalidMultihash Code = "invalid_multihash"
+	// CodeUnauthorized is a request that is missing valid authentication.
+	CodeUnauthorized Code = "unauthorized"
+	// CodeForbidden is a request that is not permitted.
+	CodeForbidden Code = "forbidden"
+	// CodeProviderNotAllowed is a request from or about a provider that is
+	// not allowed by the indexer's policy.
+	CodeProviderNotAllowed Code = "provider_not_allowed"
+	// CodeNotFound is a request for something that does not exist.
+	CodeNotFound Code = "not_found"
+	// CodeTimeout is a request that did not complete in time.
+	CodeTimeout Code = "timeout"
+	// CodeRateLimited is a request that was rejected because too many
+	// requests were made. The error may specify when to retry.
+	CodeRateLimited Code = "rate_limited"
+	// CodeFrozen is a request to change data that was rejected because the
+	// indexer is frozen and is not accepting new data.
+	CodeFrozen Code = "frozen"
+	// CodeInternal is a failure within the server.
+	CodeInternal Code = "internal"
+	// CodeNotImplemented is a request for functionality that the server does
+	// not support.
+	CodeNotImplemented Code = "not_implemented"
+	// CodeUnavailable is a request that cannot be handled because the server
+	// is temporarily unavailable. The error may specify when to retry.
+	CodeUnavailable Code = "unavailable"
+)
+
+// CodeForStatus returns the Code that corresponds to an HTTP status. An empty
+// Code is returned if there is no corresponding Code.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return CodeTimeout
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusInternalServerError:
+		return CodeInternal
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	return ""
+}
+
+// IsCode returns true if err is, or wraps, an Error whose Code is any of the
+// given codes.
+func IsCode(err error, codes ...Code) bool {
+	var apierr *Error
+	if !errors.As(err, &apierr) {
+		return false
+	}
+	code := apierr.Code()
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
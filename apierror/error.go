@@ -4,20 +4,32 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Error is the type of error returned by a network client. It contains an HTTP
 // status code so that API clients can interpret the error emssage.
 type Error struct {
-	err    error
-	status int
+	err        error
+	status     int
+	code       Code
+	retryAfter time.Duration
+	details    map[string]string
 }
 
 type ErrorMessage struct {
 	Message string `json:",omitempty"`
 	Status  int    `json:",omitempty"`
+	// Code identifies the kind of error.
+	Code Code `json:",omitempty"`
+	// RetryAfter is the number of seconds to wait before retrying.
+	RetryAfter int `json:",omitempty"`
+	// Details contains additional information about the error.
+	Details map[string]string `json:",omitempty"`
 }
 
 var serverError []byte
@@ -42,7 +54,49 @@ func New(err error, status int) *Error {
 	}
 }
 
+// WithCode sets the code that identifies the kind of error, and returns the
+// Error. If no code is set, then the code is determined by the status.
+func (e *Error) WithCode(code Code) *Error {
+	e.code = code
+	return e
+}
+
+// WithRetryAfter sets the time to wait before retrying a request that failed
+// with this error, and returns the Error.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	e.retryAfter = d
+	return e
+}
+
+// WithDetail adds a detail to the error, and returns the Error.
+func (e *Error) WithDetail(key, value string) *Error {
+	if e.details == nil {
+		e.details = make(map[string]string)
+	}
+	e.details[key] = value
+	return e
+}
+
+// FromResponse creates an error from the status and body of an HTTP response.
+// If the body is RFC 7807 problem details or an encoded ErrorMessage, then the
+// error contains the message, code, retry after, and details from the body.
+// Otherwise, the body is used as the error message.
 func FromResponse(status int, body []byte) error {
+	if p, ok := decodeProblem(body); ok {
+		if status != 0 {
+			p.Status = status
+		}
+		return FromProblem(p)
+	}
+
+	var e ErrorMessage
+	if json.Unmarshal(body, &e) == nil && (e.Message != "" || e.Code != "") {
+		if status == 0 {
+			status = e.Status
+		}
+		return fromErrorMessage(e, status)
+	}
+
 	var err error
 	text := strings.TrimSpace(string(body))
 	if text != "" {
@@ -54,6 +108,45 @@ func FromResponse(status int, body []byte) error {
 	return New(err, status)
 }
 
+// FromHTTPResponse creates an error from an HTTP response that does not have
+// a success status, reading the response body. The error is created as by
+// FromResponse, and if the body does not specify a retry after, then the
+// response's Retry-After header is used.
+func FromHTTPResponse(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		err = New(fmt.Errorf("cannot read error response: %w", err), resp.StatusCode)
+	} else {
+		err = FromResponse(resp.StatusCode, body)
+	}
+	var apierr *Error
+	if errors.As(err, &apierr) && apierr.retryAfter == 0 {
+		apierr.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	return err
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date. Returns 0 if the value is not valid or
+// is not in the future.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
 func (e *Error) Error() string {
 	if e.err != nil {
 		return e.err.Error()
@@ -72,6 +165,26 @@ func (e *Error) Status() int {
 	return e.status
 }
 
+// Code returns the code that identifies the kind of error. If no code was
+// set, then the code that corresponds to the status is returned.
+func (e *Error) Code() Code {
+	if e.code != "" {
+		return e.code
+	}
+	return CodeForStatus(e.status)
+}
+
+// RetryAfter returns the time to wait before retrying a request that failed
+// with this error. Returns 0 if not specified.
+func (e *Error) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// Details returns additional information about the error.
+func (e *Error) Details() map[string]string {
+	return e.details
+}
+
 func (e *Error) Text() string {
 	parts := make([]string, 0, 5)
 	if e.status != 0 {
@@ -107,6 +220,9 @@ func EncodeError(err error) []byte {
 	var apierr *Error
 	if errors.As(err, &apierr) {
 		e.Status = apierr.Status()
+		e.Code = apierr.Code()
+		e.RetryAfter = retryAfterSeconds(apierr.retryAfter)
+		e.Details = apierr.details
 	}
 
 	data, err := json.Marshal(&e)
@@ -126,9 +242,19 @@ func DecodeError(data []byte) error {
 		return fmt.Errorf("cannot decode error message: %s", err)
 	}
 
-	err = errors.New(e.Message)
-	if e.Status == 0 {
-		return err
+	return fromErrorMessage(e, e.Status)
+}
+
+func fromErrorMessage(e ErrorMessage, status int) error {
+	if status == 0 && e.Code == "" {
+		return errors.New(e.Message)
+	}
+	var err error
+	if e.Message != "" {
+		err = errors.New(e.Message)
 	}
-	return New(err, e.Status)
+	apierr := New(err, status).WithCode(e.Code)
+	apierr.retryAfter = time.Duration(e.RetryAfter) * time.Second
+	apierr.details = e.Details
+	return apierr
 }
@@ -1,10 +1,16 @@
 package apierror_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ipni/go-libipni/apierror"
 	"github.com/stretchr/testify/require"
@@ -74,3 +80,164 @@ func TestUnwrap(t *testing.T) {
 	err := apierror.New(errEOF, 0)
 	require.ErrorIs(t, err, errEOF)
 }
+
+func TestCode(t *testing.T) {
+	err := apierror.New(errors.New("not here"), http.StatusNotFound)
+	require.Equal(t, apierror.CodeNotFound, err.Code())
+	require.True(t, apierror.IsCode(err, apierror.CodeNotFound))
+	require.True(t, apierror.IsCode(fmt.Errorf("wrapped: %w", err), apierror.CodeBadRequest, apierror.CodeNotFound))
+	require.False(t, apierror.IsCode(err, apierror.CodeRateLimited))
+	require.False(t, apierror.IsCode(errors.New("not found"), apierror.CodeNotFound))
+	require.False(t, apierror.IsCode(nil, apierror.CodeNotFound))
+
+	err = apierror.New(nil, http.StatusForbidden).WithCode(apierror.CodeProviderNotAllowed)
+	require.Equal(t, apierror.CodeProviderNotAllowed, err.Code())
+	require.False(t, apierror.IsCode(err, apierror.CodeForbidden))
+
+	require.Equal(t, apierror.Code(""), apierror.New(nil, http.StatusTeapot).Code())
+}
+
+func TestEncodeDecodeCode(t *testing.T) {
+	err := apierror.New(errors.New("slow down"), http.StatusTooManyRequests).
+		WithRetryAfter(1500*time.Millisecond).
+		WithDetail("limit", "10/s")
+	data := apierror.EncodeError(err)
+
+	derr := apierror.DecodeError(data)
+	require.Equal(t, "slow down", derr.Error())
+	ae, ok := derr.(*apierror.Error)
+	require.True(t, ok)
+	require.Equal(t, http.StatusTooManyRequests, ae.Status())
+	require.Equal(t, apierror.CodeRateLimited, ae.Code())
+	require.Equal(t, 2*time.Second, ae.RetryAfter())
+	require.Equal(t, map[string]string{"limit": "10/s"}, ae.Details())
+
+	// Code without status.
+	err = apierror.New(errors.New("frozen"), 0).WithCode(apierror.CodeFrozen)
+	derr = apierror.DecodeError(apierror.EncodeError(err))
+	require.True(t, apierror.IsCode(derr, apierror.CodeFrozen))
+	require.Zero(t, derr.(*apierror.Error).Status())
+
+	// FromResponse decodes an encoded ErrorMessage body.
+	err = apierror.New(errors.New("bad provider"), http.StatusForbidden).WithCode(apierror.CodeProviderNotAllowed)
+	derr = apierror.FromResponse(http.StatusForbidden, apierror.EncodeError(err))
+	require.Equal(t, "bad provider", derr.Error())
+	require.True(t, apierror.IsCode(derr, apierror.CodeProviderNotAllowed))
+
+	// FromResponse uses non-ErrorMessage JSON as the message.
+	derr = apierror.FromResponse(http.StatusBadRequest, []byte(`{"foo":"bar"}`))
+	require.Equal(t, `{"foo":"bar"}`, derr.Error())
+	require.True(t, apierror.IsCode(derr, apierror.CodeBadRequest))
+}
+
+func TestProblem(t *testing.T) {
+	err := apierror.New(errors.New("slow down"), http.StatusTooManyRequests).
+		WithRetryAfter(time.Minute).
+		WithDetail("limit", "10/s")
+	data := apierror.EncodeProblem(err, "/multihash/abc")
+
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &m))
+	require.Equal(t, "about:blank", m["type"])
+	require.Equal(t, http.StatusText(http.StatusTooManyRequests), m["title"])
+	require.Equal(t, float64(http.StatusTooManyRequests), m["status"])
+	require.Equal(t, "slow down", m["detail"])
+	require.Equal(t, "/multihash/abc", m["instance"])
+	require.Equal(t, "rate_limited", m["code"])
+	require.Equal(t, float64(60), m["retryAfter"])
+
+	var p apierror.Problem
+	require.NoError(t, json.Unmarshal(data, &p))
+	require.Equal(t, "/multihash/abc", p.Instance)
+	require.Contains(t, p.Extensions, "code")
+	require.NotContains(t, p.Extensions, "detail")
+
+	derr := apierror.FromResponse(http.StatusTooManyRequests, data)
+	require.Equal(t, "slow down", derr.Error())
+	ae, ok := derr.(*apierror.Error)
+	require.True(t, ok)
+	require.Equal(t, http.StatusTooManyRequests, ae.Status())
+	require.Equal(t, apierror.CodeRateLimited, ae.Code())
+	require.Equal(t, time.Minute, ae.RetryAfter())
+	require.Equal(t, map[string]string{"limit": "10/s"}, ae.Details())
+
+	// Problem from another server, with unknown extension members.
+	derr = apierror.FromResponse(http.StatusForbidden, []byte(`{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","balance":30}`))
+	require.Equal(t, "You do not have enough credit.", derr.Error())
+	require.True(t, apierror.IsCode(derr, apierror.CodeForbidden))
+
+	// Errors that are not an apierror.Error are internal server errors.
+	p = *apierror.ToProblem(errors.New("oops"), "")
+	require.Equal(t, http.StatusInternalServerError, p.Status)
+	require.Equal(t, "oops", p.Detail)
+	require.Nil(t, p.Extensions)
+}
+
+func TestWriteError(t *testing.T) {
+	err := apierror.New(errors.New("cannot find it"), http.StatusNotFound)
+
+	// Plain text when problem details are not accepted.
+	req := httptest.NewRequest(http.MethodGet, "/multihash/abc", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	apierror.WriteError(rec, req, err)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, "cannot find it", strings.TrimSpace(rec.Body.String()))
+	derr := apierror.FromResponse(rec.Code, rec.Body.Bytes())
+	require.Equal(t, "cannot find it", derr.Error())
+	require.True(t, apierror.IsCode(derr, apierror.CodeNotFound))
+
+	// Problem details when accepted.
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	rec = httptest.NewRecorder()
+	apierror.WriteError(rec, req, err.WithRetryAfter(time.Second))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, apierror.MediaTypeProblem, rec.Header().Get("Content-Type"))
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+	derr = apierror.FromResponse(rec.Code, rec.Body.Bytes())
+	require.Equal(t, "cannot find it", derr.Error())
+	require.True(t, apierror.IsCode(derr, apierror.CodeNotFound))
+
+	// Code is kept when problem details are not accepted.
+	err = apierror.New(errors.New("bad multihash"), http.StatusBadRequest).WithCode(apierror.CodeInvalidMultihash)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	apierror.WriteError(rec, req, err)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	derr = apierror.FromResponse(rec.Code, rec.Body.Bytes())
+	require.Equal(t, "bad multihash", derr.Error())
+	require.True(t, apierror.IsCode(derr, apierror.CodeInvalidMultihash))
+}
+
+func TestFromHTTPResponse(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+		Body:       io.NopCloser(strings.NewReader("slow down")),
+	}
+	err := apierror.FromHTTPResponse(resp)
+	var apierr *apierror.Error
+	require.ErrorAs(t, err, &apierr)
+	require.Equal(t, "slow down", apierr.Error())
+	require.Equal(t, http.StatusTooManyRequests, apierr.Status())
+	require.Equal(t, 30*time.Second, apierr.RetryAfter())
+
+	// HTTP date.
+	resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	resp.Body = io.NopCloser(strings.NewReader("slow down"))
+	require.ErrorAs(t, apierror.FromHTTPResponse(resp), &apierr)
+	require.Greater(t, apierr.RetryAfter(), 30*time.Second)
+	require.LessOrEqual(t, apierr.RetryAfter(), time.Minute)
+
+	// Retry after in the body is used instead of the header.
+	resp.Header.Set("Retry-After", "30")
+	resp.Body = io.NopCloser(bytes.NewReader(apierror.EncodeError(apierror.New(nil, http.StatusTooManyRequests).WithRetryAfter(5 * time.Second))))
+	require.ErrorAs(t, apierror.FromHTTPResponse(resp), &apierr)
+	require.Equal(t, 5*time.Second, apierr.RetryAfter())
+
+	// Invalid header is ignored.
+	resp.Header.Set("Retry-After", "soon")
+	resp.Body = io.NopCloser(strings.NewReader("slow down"))
+	require.ErrorAs(t, apierror.FromHTTPResponse(resp), &apierr)
+	require.Zero(t, apierr.RetryAfter())
+}
@@ -0,0 +1,247 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaTypeProblem is the media type of an RFC 7807 problem details object.
+const MediaTypeProblem = "application/problem+json"
+
+// problemTypeDefault is the problem type used when the problem has no
+// additional semantics beyond the HTTP status.
+const problemTypeDefault = "about:blank"
+
+// Names of the problem extension members that hold Error fields.
+const (
+	problemCode       = "code"
+	problemRetryAfter = "retryAfter"
+	problemDetails    = "details"
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type.
+	Type string
+	// Title is a short, human-readable summary of the problem type.
+	Title string
+	// Status is the HTTP status code.
+	Status int
+	// Detail is a human-readable explanation specific to this occurrence of
+	// the problem.
+	Detail string
+	// Instance is a URI reference that identifies the specific occurrence of
+	// the problem.
+	Instance string
+	// Extensions contains any additional members of the problem details
+	// object.
+	Extensions map[string]interface{}
+}
+
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+// MarshalJSON implements json.Marshaler. Extension members are written
+// alongside the standard members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Members other than the standard
+// members are stored in Extensions.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var std struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail"`
+		Instance string `json:"instance"`
+	}
+	if err := json.Unmarshal(data, &std); err != nil {
+		return err
+	}
+	var ext map[string]interface{}
+	if err := json.Unmarshal(data, &ext); err != nil {
+		return err
+	}
+	for _, name := range problemMembers {
+		delete(ext, name)
+	}
+	if len(ext) == 0 {
+		ext = nil
+	}
+	*p = Problem{
+		Type:       std.Type,
+		Title:      std.Title,
+		Status:     std.Status,
+		Detail:     std.Detail,
+		Instance:   std.Instance,
+		Extensions: ext,
+	}
+	return nil
+}
+
+// ToProblem converts an error into problem details. The code, retry after,
+// and details of an Error are included as extension members. If err is not an
+// Error, then the problem has status http.StatusInternalServerError.
+func ToProblem(err error, instance string) *Problem {
+	p := &Problem{
+		Type:     problemTypeDefault,
+		Status:   http.StatusInternalServerError,
+		Instance: instance,
+	}
+	var apierr *Error
+	if errors.As(err, &apierr) {
+		if apierr.status != 0 {
+			p.Status = apierr.status
+		}
+		if apierr.err != nil {
+			p.Detail = apierr.err.Error()
+		}
+		ext := make(map[string]interface{})
+		if code := apierr.Code(); code != "" {
+			ext[problemCode] = code
+		}
+		if apierr.retryAfter != 0 {
+			ext[problemRetryAfter] = retryAfterSeconds(apierr.retryAfter)
+		}
+		if len(apierr.details) != 0 {
+			ext[problemDetails] = apierr.details
+		}
+		if len(ext) != 0 {
+			p.Extensions = ext
+		}
+	} else if err != nil {
+		p.Detail = err.Error()
+	}
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// FromProblem converts problem details into an Error. The code, retry after,
+// and details extension members are read into the Error. Other extension
+// members are ignored.
+func FromProblem(p *Problem) *Error {
+	var err error
+	if p.Detail != "" {
+		err = errors.New(p.Detail)
+	} else if p.Title != "" && p.Title != http.StatusText(p.Status) {
+		err = errors.New(p.Title)
+	}
+	apierr := New(err, p.Status)
+	if code, ok := p.Extensions[problemCode].(string); ok {
+		apierr.code = Code(code)
+	}
+	if secs, ok := p.Extensions[problemRetryAfter].(float64); ok && secs > 0 {
+		apierr.retryAfter = time.Duration(secs) * time.Second
+	}
+	if details, ok := p.Extensions[problemDetails].(map[string]interface{}); ok {
+		for k, v := range details {
+			if s, ok := v.(string); ok {
+				apierr.WithDetail(k, s)
+			}
+		}
+	}
+	return apierr
+}
+
+// EncodeProblem encodes an error as RFC 7807 problem details.
+func EncodeProblem(err error, instance string) []byte {
+	data, merr := json.Marshal(ToProblem(err, instance))
+	if merr != nil {
+		return serverError
+	}
+	return data
+}
+
+// decodeProblem decodes problem details from data. Returns false if the data
+// does not contain any standard problem details members.
+func decodeProblem(data []byte) (*Problem, bool) {
+	var p Problem
+	if json.Unmarshal(data, &p) != nil {
+		return nil, false
+	}
+	if p.Type == "" && p.Title == "" && p.Detail == "" {
+		return nil, false
+	}
+	return &p, true
+}
+
+// AcceptsProblem returns true if the request's Accept headers include the
+// problem details media type.
+func AcceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, amt := range strings.Split(accept, ",") {
+			mt, _, err := mime.ParseMediaType(amt)
+			if err == nil && mt == MediaTypeProblem {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteError writes an error response. If the request accepts problem
+// details, then the error is written as RFC 7807 problem details with the
+// request path as the instance. Otherwise, an Error that has a code or details
+// is written as an encoded ErrorMessage, so that they are not lost, and any
+// other error message is written as plain text. The status of an Error is used
+// as the response status, and its retry after, if any, is written in the
+// Retry-After header.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	var apierr *Error
+	if errors.As(err, &apierr) {
+		if apierr.status != 0 {
+			status = apierr.status
+		}
+		if apierr.retryAfter != 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(apierr.retryAfter)))
+		}
+	}
+
+	if !AcceptsProblem(r) {
+		if apierr == nil || (apierr.code == "" && len(apierr.details) == 0) {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(status)
+		_, _ = w.Write(EncodeError(err))
+		return
+	}
+	w.Header().Set("Content-Type", MediaTypeProblem)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(EncodeProblem(err, r.URL.Path))
+}
+
+// retryAfterSeconds returns a retry after duration in whole seconds, rounded
+// up.
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
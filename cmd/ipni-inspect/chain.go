@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/ipni/go-libipni/metadata"
+)
+
+func runHead(ctx context.Context, args []string) error {
+	fs, out := newFlagSet("head")
+	var pf publisherFlags
+	pf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f, err := pf.newFetcher()
+	if err != nil {
+		return err
+	}
+	defer f.close()
+
+	ctx, cancel := context.WithTimeout(ctx, pf.timeout)
+	defer cancel()
+	head, err := f.head(ctx)
+	if err != nil {
+		return err
+	}
+
+	var headStr string
+	if head != cid.Undef {
+		headStr = head.String()
+	}
+	return out.write(struct{ Head string }{headStr}, func(w io.Writer) {
+		if headStr == "" {
+			fmt.Fprintln(w, "No advertisements published")
+			return
+		}
+		fmt.Fprintln(w, headStr)
+	})
+}
+
+func runAds(ctx context.Context, args []string) error {
+	fs, out := newFlagSet("ads")
+	var pf publisherFlags
+	pf.register(fs)
+	start := fs.String("cid", "", "Advertisement to start at. Default is the publisher's head")
+	count := fs.Int("n", 10, "Maximum number of advertisements to print, 0 for the whole chain")
+	lint := fs.Bool("lint", false, "Lint each advertisement")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f, err := pf.newFetcher()
+	if err != nil {
+		return err
+	}
+	defer f.close()
+
+	ctx, cancel := context.WithTimeout(ctx, pf.timeout)
+	defer cancel()
+	c, err := f.startCid(ctx, *start)
+	if err != nil {
+		return err
+	}
+
+	return walkAds(ctx, f, c, *count, func(ad *schema.Advertisement, adCid cid.Cid) error {
+		info := newAdInfo(ad, adCid, *lint)
+		return out.write(info, info.print)
+	})
+}
+
+func runVerify(ctx context.Context, args []string) error {
+	fs, out := newFlagSet("verify")
+	var pf publisherFlags
+	pf.register(fs)
+	start := fs.String("cid", "", "Advertisement to start at. Default is the publisher's head")
+	count := fs.Int("n", 1, "Maximum number of advertisements to verify, 0 for the whole chain")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f, err := pf.newFetcher()
+	if err != nil {
+		return err
+	}
+	defer f.close()
+
+	ctx, cancel := context.WithTimeout(ctx, pf.timeout)
+	defer cancel()
+	c, err := f.startCid(ctx, *start)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	err = walkAds(ctx, f, c, *count, func(ad *schema.Advertisement, adCid cid.Cid) error {
+		findings := schema.Lint(ad)
+		result := verifyResult{
+			Cid:      adCid.String(),
+			Valid:    !schema.HasErrors(findings),
+			Findings: toFindingInfos(findings),
+		}
+		if signer, err := ad.VerifySignature(); err == nil {
+			result.Signer = signer.String()
+		}
+		if !result.Valid {
+			failed++
+		}
+		return out.write(result, result.print)
+	})
+	if err != nil {
+		return err
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d advertisements have errors", failed)
+	}
+	return nil
+}
+
+func runEntries(ctx context.Context, args []string) error {
+	fs, out := newFlagSet("entries")
+	var pf publisherFlags
+	pf.register(fs)
+	start := fs.String("cid", "", "Advertisement whose entries are dumped. Default is the publisher's head")
+	maxChunks := fs.Int("chunks", 0, "Maximum number of entry chunks to fetch, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f, err := pf.newFetcher()
+	if err != nil {
+		return err
+	}
+	defer f.close()
+
+	ctx, cancel := context.WithTimeout(ctx, pf.timeout)
+	defer cancel()
+	c, err := f.startCid(ctx, *start)
+	if err != nil {
+		return err
+	}
+	ad, err := f.loadAd(ctx, c)
+	if err != nil {
+		return err
+	}
+	next, ok := ad.Entries.(cidlink.Link)
+	if !ok || next == schema.NoEntries {
+		return nil
+	}
+
+	for chunks := 0; *maxChunks == 0 || chunks < *maxChunks; chunks++ {
+		chunk, err := f.loadEntryChunk(ctx, next.Cid)
+		if err != nil {
+			if chunks == 0 {
+				return fmt.Errorf("%w (only entry chunk chains are supported)", err)
+			}
+			return err
+		}
+		info := chunkInfo{
+			Cid:     next.Cid.String(),
+			Entries: make([]string, len(chunk.Entries)),
+		}
+		for i, mh := range chunk.Entries {
+			info.Entries[i] = mh.B58String()
+		}
+		err = out.write(info, func(w io.Writer) {
+			for _, mh := range info.Entries {
+				fmt.Fprintln(w, mh)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if next, ok = chunk.Next.(cidlink.Link); !ok {
+			break
+		}
+	}
+	return nil
+}
+
+// walkAds calls fn for each advertisement in the chain, starting at c and
+// following PreviousID links, until count advertisements have been visited or
+// the start of the chain is reached.
+func walkAds(ctx context.Context, f *fetcher, c cid.Cid, count int, fn func(*schema.Advertisement, cid.Cid) error) error {
+	for i := 0; count == 0 || i < count; i++ {
+		ad, err := f.loadAd(ctx, c)
+		if err != nil {
+			return err
+		}
+		if err = fn(ad, c); err != nil {
+			return err
+		}
+		prev, ok := ad.PreviousID.(cidlink.Link)
+		if !ok {
+			break
+		}
+		c = prev.Cid
+	}
+	return nil
+}
+
+type chunkInfo struct {
+	Cid     string
+	Entries []string
+}
+
+type findingInfo struct {
+	Severity string
+	Field    string
+	Message  string
+}
+
+func toFindingInfos(findings []schema.Finding) []findingInfo {
+	if len(findings) == 0 {
+		return nil
+	}
+	infos := make([]findingInfo, len(findings))
+	for i, f := range findings {
+		infos[i] = findingInfo{
+			Severity: f.Severity.String(),
+			Field:    f.Field,
+			Message:  f.Message,
+		}
+	}
+	return infos
+}
+
+type verifyResult struct {
+	Cid      string
+	Valid    bool
+	Signer   string        `json:",omitempty"`
+	Findings []findingInfo `json:",omitempty"`
+}
+
+func (r verifyResult) print(w io.Writer) {
+	status := "OK"
+	if !r.Valid {
+		status = "FAIL"
+	}
+	fmt.Fprintf(w, "%s %s", r.Cid, status)
+	if r.Signer != "" {
+		fmt.Fprintf(w, " signed by %s", r.Signer)
+	}
+	fmt.Fprintln(w)
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "  %s: %s: %s\n", f.Severity, f.Field, f.Message)
+	}
+}
+
+type providerInfo struct {
+	ID            string
+	Addresses     []string        `json:",omitempty"`
+	Metadata      json.RawMessage `json:",omitempty"`
+	MetadataError string          `json:",omitempty"`
+}
+
+type extendedProviderInfo struct {
+	Override  bool
+	Providers []providerInfo
+}
+
+type adInfo struct {
+	Cid              string
+	PreviousID       string `json:",omitempty"`
+	Provider         string
+	Addresses        []string `json:",omitempty"`
+	ContextID        []byte   `json:",omitempty"`
+	Entries          string   `json:",omitempty"`
+	IsRm             bool
+	Metadata         json.RawMessage       `json:",omitempty"`
+	MetadataError    string                `json:",omitempty"`
+	Signer           string                `json:",omitempty"`
+	SignatureError   string                `json:",omitempty"`
+	ExtendedProvider *extendedProviderInfo `json:",omitempty"`
+	Findings         []findingInfo         `json:",omitempty"`
+}
+
+func newAdInfo(ad *schema.Advertisement, adCid cid.Cid, lint bool) *adInfo {
+	info := &adInfo{
+		Cid:        adCid.String(),
+		PreviousID: linkString(ad.PreviousID),
+		Provider:   ad.Provider,
+		Addresses:  ad.Addresses,
+		ContextID:  ad.ContextID,
+		Entries:    linkString(ad.Entries),
+		IsRm:       ad.IsRm,
+	}
+	if len(ad.Metadata) != 0 {
+		info.Metadata, info.MetadataError = metadataJSON(ad.Metadata)
+	}
+	if signer, err := ad.VerifySignature(); err != nil {
+		info.SignatureError = err.Error()
+	} else {
+		info.Signer = signer.String()
+	}
+	if ad.ExtendedProvider != nil {
+		info.ExtendedProvider = &extendedProviderInfo{
+			Override:  ad.ExtendedProvider.Override,
+			Providers: make([]providerInfo, len(ad.ExtendedProvider.Providers)),
+		}
+		for i, p := range ad.ExtendedProvider.Providers {
+			pinfo := providerInfo{
+				ID:        p.ID,
+				Addresses: p.Addresses,
+			}
+			if len(p.Metadata) != 0 {
+				pinfo.Metadata, pinfo.MetadataError = metadataJSON(p.Metadata)
+			}
+			info.ExtendedProvider.Providers[i] = pinfo
+		}
+	}
+	if lint {
+		info.Findings = toFindingInfos(schema.Lint(ad))
+	}
+	return info
+}
+
+func (a *adInfo) print(w io.Writer) {
+	fmt.Fprintf(w, "Advertisement: %s\n", a.Cid)
+	fmt.Fprintf(w, "  Provider:   %s\n", a.Provider)
+	for _, addr := range a.Addresses {
+		fmt.Fprintf(w, "  Address:    %s\n", addr)
+	}
+	if len(a.ContextID) != 0 {
+		fmt.Fprintf(w, "  ContextID:  %s\n", encodeBase64(a.ContextID))
+	}
+	fmt.Fprintf(w, "  Entries:    %s\n", a.Entries)
+	fmt.Fprintf(w, "  IsRm:       %t\n", a.IsRm)
+	printMetadata(w, "  ", a.Metadata, a.MetadataError)
+	if a.SignatureError != "" {
+		fmt.Fprintf(w, "  Signature:  invalid: %s\n", a.SignatureError)
+	} else {
+		fmt.Fprintf(w, "  Signed by:  %s\n", a.Signer)
+	}
+	if xp := a.ExtendedProvider; xp != nil {
+		fmt.Fprintf(w, "  ExtendedProvider: override=%t\n", xp.Override)
+		for _, p := range xp.Providers {
+			fmt.Fprintf(w, "    Provider:   %s\n", p.ID)
+			for _, addr := range p.Addresses {
+				fmt.Fprintf(w, "    Address:    %s\n", addr)
+			}
+			printMetadata(w, "    ", p.Metadata, p.MetadataError)
+		}
+	}
+	for _, f := range a.Findings {
+		fmt.Fprintf(w, "  Lint:       %s: %s: %s\n", f.Severity, f.Field, f.Message)
+	}
+	if a.PreviousID != "" {
+		fmt.Fprintf(w, "  PreviousID: %s\n", a.PreviousID)
+	}
+	fmt.Fprintln(w)
+}
+
+// metadataJSON leniently decodes binary metadata and returns its JSON
+// encoding, or a description of why it could not be decoded.
+func metadataJSON(data []byte) (json.RawMessage, string) {
+	md, _, err := metadata.Decode(metadata.Default, data, metadata.DecodeLenient)
+	if err != nil {
+		return nil, err.Error()
+	}
+	mdJSON, err := json.Marshal(md)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return mdJSON, ""
+}
+
+// printMetadata prints each protocol in JSON encoded metadata on its own line.
+func printMetadata(w io.Writer, indent string, mdJSON json.RawMessage, mdErr string) {
+	if mdErr != "" {
+		fmt.Fprintf(w, "%sMetadata:   invalid: %s\n", indent, mdErr)
+		return
+	}
+	var protocols []json.RawMessage
+	if err := json.Unmarshal(mdJSON, &protocols); err != nil {
+		return
+	}
+	for _, p := range protocols {
+		fmt.Fprintf(w, "%sMetadata:   %s\n", indent, p)
+	}
+}
+
+func linkString(lnk ipld.Link) string {
+	if lnk == nil {
+		return ""
+	}
+	return lnk.String()
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/storage/memstore"
+	"github.com/ipni/go-libipni/dagsync/httpsync"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/ipni/go-libipni/test"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+type testPublisher struct {
+	url     string
+	peerID  string
+	ads     []cidlink.Link
+	entries []cidlink.Link
+	mhs     [][]multihash.Multihash
+}
+
+// newTestPublisher serves a chain of advertisements, each with a single
+// entry chunk, from an httptest server.
+func newTestPublisher(t *testing.T, count int) *testPublisher {
+	store := &memstore.Store{}
+	lsys := cidlink.DefaultLinkSystem()
+	lsys.SetReadStorage(store)
+	lsys.SetWriteStorage(store)
+
+	peerID, privKey, _ := test.RandomIdentity()
+	pub, err := httpsync.NewPublisherWithoutServer("127.0.0.1:0", "", lsys, privKey)
+	require.NoError(t, err)
+	srv := httptest.NewServer(pub)
+	t.Cleanup(srv.Close)
+
+	md := metadata.Default.New(metadata.Bitswap{})
+	mdBytes, err := md.MarshalBinary()
+	require.NoError(t, err)
+
+	tp := &testPublisher{
+		url:    srv.URL,
+		peerID: peerID.String(),
+	}
+	var prev ipld.Link
+	for i := 0; i < count; i++ {
+		mhs := test.RandomMultihashes(3)
+		chunk := schema.EntryChunk{Entries: mhs}
+		node, err := chunk.ToNode()
+		require.NoError(t, err)
+		entriesLnk, err := lsys.Store(ipld.LinkContext{}, schema.Linkproto, node)
+		require.NoError(t, err)
+
+		ad := schema.Advertisement{
+			PreviousID: prev,
+			Provider:   peerID.String(),
+			Addresses:  test.RandomAddrs(1),
+			Entries:    entriesLnk,
+			ContextID:  []byte{byte(i)},
+			Metadata:   mdBytes,
+		}
+		require.NoError(t, ad.Sign(privKey))
+		node, err = ad.ToNode()
+		require.NoError(t, err)
+		adLnk, err := lsys.Store(ipld.LinkContext{}, schema.Linkproto, node)
+		require.NoError(t, err)
+		prev = adLnk
+
+		// Keep newest first, in the order the chain is walked.
+		tp.ads = append([]cidlink.Link{adLnk.(cidlink.Link)}, tp.ads...)
+		tp.entries = append([]cidlink.Link{entriesLnk.(cidlink.Link)}, tp.entries...)
+		tp.mhs = append([][]multihash.Multihash{mhs}, tp.mhs...)
+	}
+	pub.SetRoot(tp.ads[0].Cid)
+	return tp
+}
+
+func (tp *testPublisher) args(args ...string) []string {
+	return append([]string{"-json", "-addr", tp.url, "-peer", tp.peerID}, args...)
+}
+
+func TestRunHead(t *testing.T) {
+	tp := newTestPublisher(t, 1)
+	out, err := runCommand(t, runHead, tp.args()...)
+	require.NoError(t, err)
+	heads := decodeNDJSON[struct{ Head string }](t, out)
+	require.Len(t, heads, 1)
+	require.Equal(t, tp.ads[0].String(), heads[0].Head)
+}
+
+func TestRunAds(t *testing.T) {
+	tp := newTestPublisher(t, 3)
+
+	out, err := runCommand(t, runAds, tp.args("-n", "0", "-lint")...)
+	require.NoError(t, err)
+	infos := decodeNDJSON[adInfo](t, out)
+	require.Len(t, infos, 3)
+	for i, info := range infos {
+		require.Equal(t, tp.ads[i].String(), info.Cid)
+		require.Equal(t, tp.peerID, info.Provider)
+		require.Equal(t, tp.peerID, info.Signer)
+		require.Empty(t, info.SignatureError)
+		require.Equal(t, tp.entries[i].String(), info.Entries)
+		require.Empty(t, info.MetadataError)
+		require.JSONEq(t, `[{"protocol":"transport-bitswap"}]`, string(info.Metadata))
+		if i == len(infos)-1 {
+			require.Empty(t, info.PreviousID)
+		} else {
+			require.Equal(t, tp.ads[i+1].String(), info.PreviousID)
+		}
+	}
+
+	// Start part way down the chain and stop early.
+	out, err = runCommand(t, runAds, tp.args("-cid", tp.ads[1].String(), "-n", "1")...)
+	require.NoError(t, err)
+	infos = decodeNDJSON[adInfo](t, out)
+	require.Len(t, infos, 1)
+	require.Equal(t, tp.ads[1].String(), infos[0].Cid)
+
+	// Text output.
+	out, err = runCommand(t, runAds, "-addr", tp.url, "-peer", tp.peerID, "-n", "1")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out, "Advertisement: "+tp.ads[0].String()+"\n"), out)
+}
+
+func TestRunEntries(t *testing.T) {
+	tp := newTestPublisher(t, 2)
+
+	out, err := runCommand(t, runEntries, tp.args("-cid", tp.ads[1].String())...)
+	require.NoError(t, err)
+	chunks := decodeNDJSON[chunkInfo](t, out)
+	require.Len(t, chunks, 1)
+	require.Equal(t, tp.entries[1].String(), chunks[0].Cid)
+	require.Len(t, chunks[0].Entries, len(tp.mhs[1]))
+	for i, mh := range tp.mhs[1] {
+		require.Equal(t, mh.B58String(), chunks[0].Entries[i])
+	}
+}
+
+func TestRunVerify(t *testing.T) {
+	tp := newTestPublisher(t, 2)
+
+	out, err := runCommand(t, runVerify, tp.args("-n", "0")...)
+	require.NoError(t, err)
+	results := decodeNDJSON[verifyResult](t, out)
+	require.Len(t, results, 2)
+	for i, r := range results {
+		require.Equal(t, tp.ads[i].String(), r.Cid)
+		require.True(t, r.Valid)
+		require.Equal(t, tp.peerID, r.Signer)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/ipni/go-libipni/announce/message"
+	"github.com/ipni/go-libipni/maurl"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/multiformats/go-multiaddr"
+)
+
+type diagnosticInfo struct {
+	Level    string
+	Offset   int
+	Protocol string `json:",omitempty"`
+	Message  string
+}
+
+type metadataResult struct {
+	Metadata    json.RawMessage  `json:",omitempty"`
+	Diagnostics []diagnosticInfo `json:",omitempty"`
+}
+
+func runMetadata(_ context.Context, args []string) error {
+	fs, out := newFlagSet("metadata")
+	strict := fs.Bool("strict", false, "Reject metadata that is not well-formed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	data, err := decodeBytes(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	mode := metadata.DecodeLenient
+	if *strict {
+		mode = metadata.DecodeStrict
+	}
+	md, report, decErr := metadata.Decode(metadata.Default, data, mode)
+
+	var result metadataResult
+	for _, d := range report.Diagnostics {
+		di := diagnosticInfo{
+			Level:   d.Level.String(),
+			Offset:  d.Offset,
+			Message: d.Message,
+		}
+		if d.Protocol != 0 {
+			di.Protocol = d.Protocol.String()
+			if strings.HasPrefix(di.Protocol, "Code(") {
+				di.Protocol = fmt.Sprintf("0x%x", uint64(d.Protocol))
+			}
+		}
+		result.Diagnostics = append(result.Diagnostics, di)
+	}
+	if decErr == nil {
+		if result.Metadata, err = json.Marshal(md); err != nil {
+			return err
+		}
+	}
+
+	err = out.write(result, func(w io.Writer) {
+		printMetadata(w, "", result.Metadata, "")
+		for _, d := range report.Diagnostics {
+			fmt.Fprintln(w, d)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return decErr
+}
+
+type addrInfo struct {
+	Multiaddr string
+	URL       string `json:",omitempty"`
+}
+
+type announceResult struct {
+	Cid       string
+	Addrs     []addrInfo `json:",omitempty"`
+	ExtraData []byte     `json:",omitempty"`
+	OrigPeer  string     `json:",omitempty"`
+}
+
+func runAnnounce(_ context.Context, args []string) error {
+	fs, out := newFlagSet("announce")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var msg message.Message
+	arg := strings.TrimSpace(fs.Arg(0))
+	if strings.HasPrefix(arg, "{") {
+		// JSON encoded message, as sent by httpsender.
+		if err := json.Unmarshal([]byte(arg), &msg); err != nil {
+			return fmt.Errorf("cannot decode json announce message: %w", err)
+		}
+	} else {
+		data, err := decodeBytes(arg)
+		if err != nil {
+			return err
+		}
+		if err = msg.UnmarshalCBOR(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("cannot decode cbor announce message: %w", err)
+		}
+	}
+
+	addrs, err := msg.GetAddrs()
+	if err != nil {
+		return fmt.Errorf("bad announce addresses: %w", err)
+	}
+	result := announceResult{
+		Cid:       msg.Cid.String(),
+		Addrs:     make([]addrInfo, len(addrs)),
+		ExtraData: msg.ExtraData,
+		OrigPeer:  msg.OrigPeer,
+	}
+	for i, addr := range addrs {
+		result.Addrs[i] = addrInfo{Multiaddr: addr.String()}
+		if isHTTP(addr) {
+			if u, err := maurl.ToURL(addr); err == nil {
+				result.Addrs[i].URL = u.String()
+			}
+		}
+	}
+
+	return out.write(result, func(w io.Writer) {
+		fmt.Fprintf(w, "Cid:       %s\n", result.Cid)
+		for _, a := range result.Addrs {
+			if a.URL != "" {
+				fmt.Fprintf(w, "Addr:      %s (%s)\n", a.Multiaddr, a.URL)
+			} else {
+				fmt.Fprintf(w, "Addr:      %s\n", a.Multiaddr)
+			}
+		}
+		if len(result.ExtraData) != 0 {
+			fmt.Fprintf(w, "ExtraData: %s\n", encodeBase64(result.ExtraData))
+		}
+		if result.OrigPeer != "" {
+			fmt.Fprintf(w, "OrigPeer:  %s\n", result.OrigPeer)
+		}
+	})
+}
+
+type conversion struct {
+	Input     string
+	Multiaddr string `json:",omitempty"`
+	URL       string `json:",omitempty"`
+	Error     string `json:",omitempty"`
+}
+
+func runMaurl(_ context.Context, args []string) error {
+	fs, out := newFlagSet("maurl")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var failed bool
+	for _, arg := range fs.Args() {
+		conv := convert(arg)
+		if conv.Error != "" {
+			failed = true
+		}
+		err := out.write(conv, func(w io.Writer) {
+			switch {
+			case conv.Error != "":
+				fmt.Fprintf(w, "%s: error: %s\n", conv.Input, conv.Error)
+			case conv.URL != conv.Input:
+				fmt.Fprintln(w, conv.URL)
+			default:
+				fmt.Fprintln(w, conv.Multiaddr)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errors.New("some conversions failed")
+	}
+	return nil
+}
+
+// convert converts a multiaddr to a URL, or a URL to a multiaddr.
+func convert(s string) conversion {
+	conv := conversion{Input: s}
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			conv.Error = err.Error()
+			return conv
+		}
+		maddr, err := maurl.FromURL(u)
+		if err != nil {
+			conv.Error = err.Error()
+			return conv
+		}
+		conv.URL = s
+		conv.Multiaddr = maddr.String()
+		return conv
+	}
+
+	maddr, err := multiaddr.NewMultiaddr(s)
+	if err != nil {
+		conv.Error = err.Error()
+		return conv
+	}
+	u, err := maurl.ToURL(maddr)
+	if err != nil {
+		conv.Error = err.Error()
+		return conv
+	}
+	conv.Multiaddr = s
+	conv.URL = u.String()
+	return conv
+}
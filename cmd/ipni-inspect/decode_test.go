@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ipni/go-libipni/announce/message"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/ipni/go-libipni/test"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMetadata(t *testing.T) {
+	md := metadata.Default.New(metadata.Bitswap{}, &metadata.HTTPRetrievalV1{})
+	data, err := md.MarshalBinary()
+	require.NoError(t, err)
+	wantJSON, err := json.Marshal(md)
+	require.NoError(t, err)
+
+	out, err := runCommand(t, runMetadata, "-json", hex.EncodeToString(data))
+	require.NoError(t, err)
+	results := decodeNDJSON[metadataResult](t, out)
+	require.Len(t, results, 1)
+	require.JSONEq(t, string(wantJSON), string(results[0].Metadata))
+	require.Empty(t, results[0].Diagnostics)
+
+	// Trailing garbage is reported in lenient mode and rejected in strict
+	// mode.
+	bad := hex.EncodeToString(append(data, 0xff))
+	out, err = runCommand(t, runMetadata, "-json", bad)
+	require.NoError(t, err)
+	results = decodeNDJSON[metadataResult](t, out)
+	require.Len(t, results, 1)
+	require.NotEmpty(t, results[0].Diagnostics)
+
+	_, err = runCommand(t, runMetadata, "-json", "-strict", bad)
+	require.Error(t, err)
+
+	_, err = runCommand(t, runMetadata)
+	require.ErrorIs(t, err, errUsage)
+}
+
+func TestRunAnnounce(t *testing.T) {
+	c := test.RandomCids(1)[0]
+	maddrs := []multiaddr.Multiaddr{
+		multiaddr.StringCast("/ip4/127.0.0.1/tcp/3103"),
+		multiaddr.StringCast("/dns4/example.com/tcp/8080/http"),
+	}
+	msg := message.Message{
+		Cid:       c,
+		ExtraData: []byte("extra"),
+	}
+	msg.SetAddrs(maddrs)
+
+	want := announceResult{
+		Cid: c.String(),
+		Addrs: []addrInfo{
+			{Multiaddr: "/ip4/127.0.0.1/tcp/3103"},
+			{Multiaddr: "/dns4/example.com/tcp/8080/http", URL: "http://example.com:8080"},
+		},
+		ExtraData: []byte("extra"),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, msg.MarshalCBOR(&buf))
+	out, err := runCommand(t, runAnnounce, "-json", hex.EncodeToString(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, []announceResult{want}, decodeNDJSON[announceResult](t, out))
+
+	msgJSON, err := json.Marshal(&msg)
+	require.NoError(t, err)
+	out, err = runCommand(t, runAnnounce, "-json", string(msgJSON))
+	require.NoError(t, err)
+	require.Equal(t, []announceResult{want}, decodeNDJSON[announceResult](t, out))
+
+	_, err = runCommand(t, runAnnounce, "-json", "{")
+	require.ErrorContains(t, err, "cannot decode json announce message")
+}
+
+func TestRunMaurl(t *testing.T) {
+	out, err := runCommand(t, runMaurl, "-json", "/dns4/example.com/tcp/8080/http", "http://example.com:8080", "/fish")
+	require.ErrorContains(t, err, "some conversions failed")
+	convs := decodeNDJSON[conversion](t, out)
+	require.Len(t, convs, 3)
+	require.Equal(t, conversion{
+		Input:     "/dns4/example.com/tcp/8080/http",
+		Multiaddr: "/dns4/example.com/tcp/8080/http",
+		URL:       "http://example.com:8080",
+	}, convs[0])
+	require.Equal(t, conversion{
+		Input:     "http://example.com:8080",
+		Multiaddr: "/dns/example.com/tcp/8080/http",
+		URL:       "http://example.com:8080",
+	}, convs[1])
+	require.Equal(t, "/fish", convs[2].Input)
+	require.NotEmpty(t, convs[2].Error)
+
+	out, err = runCommand(t, runMaurl, "http://example.com:8080")
+	require.NoError(t, err)
+	require.Equal(t, "/dns/example.com/tcp/8080/http\n", out)
+}
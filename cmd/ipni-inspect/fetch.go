@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/storage/memstore"
+	selectorparse "github.com/ipld/go-ipld-prime/traversal/selector/parse"
+	"github.com/ipni/go-libipni/dagsync"
+	"github.com/ipni/go-libipni/dagsync/dtsync"
+	"github.com/ipni/go-libipni/dagsync/httpsync"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/ipni/go-libipni/maurl"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const defaultTopic = "/indexer/ingest/mainnet"
+
+// publisherFlags are the flags used by commands that fetch data from a
+// publisher.
+type publisherFlags struct {
+	addr    string
+	peerID  string
+	topic   string
+	timeout time.Duration
+}
+
+func (pf *publisherFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&pf.addr, "addr", "", "Publisher multiaddr or URL. The peer ID may be given as a /p2p component")
+	fs.StringVar(&pf.peerID, "peer", "", "Publisher peer ID, if not in -addr")
+	fs.StringVar(&pf.topic, "topic", defaultTopic, "Topic used to query the head from a libp2p publisher")
+	fs.DurationVar(&pf.timeout, "timeout", time.Minute, "Timeout for fetching data from the publisher")
+}
+
+// fetcher fetches advertisements and entries from a publisher, over HTTP
+// using httpsync or over libp2p using dtsync, and stores them in memory.
+type fetcher struct {
+	lsys   ipld.LinkSystem
+	syncer dagsync.Syncer
+	closer func()
+}
+
+func (pf *publisherFlags) newFetcher() (*fetcher, error) {
+	if pf.addr == "" {
+		return nil, errors.New("missing publisher address, use -addr")
+	}
+	maddr, err := parseAddr(pf.addr)
+	if err != nil {
+		return nil, err
+	}
+	transport, pid := peer.SplitAddr(maddr)
+	if pf.peerID != "" {
+		pid, err = peer.Decode(pf.peerID)
+		if err != nil {
+			return nil, fmt.Errorf("bad peer id: %w", err)
+		}
+	}
+	if pid == "" {
+		return nil, errors.New("missing publisher peer id, use -peer or a /p2p multiaddr")
+	}
+
+	store := &memstore.Store{}
+	lsys := cidlink.DefaultLinkSystem()
+	lsys.SetReadStorage(store)
+	lsys.SetWriteStorage(store)
+
+	if isHTTP(transport) {
+		sync := httpsync.NewSync(lsys, nil, nil)
+		syncer, err := sync.NewSyncer(pid, []multiaddr.Multiaddr{transport})
+		if err != nil {
+			return nil, err
+		}
+		return &fetcher{
+			lsys:   lsys,
+			syncer: syncer,
+			closer: sync.Close,
+		}, nil
+	}
+
+	h, err := libp2p.New(libp2p.NoListenAddrs)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		h.Peerstore().AddAddr(pid, transport, peerstore.TempAddrTTL)
+	}
+	sync, err := dtsync.NewSync(h, dssync.MutexWrap(datastore.NewMapDatastore()), lsys, nil, 0, 0)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	return &fetcher{
+		lsys:   lsys,
+		syncer: sync.NewSyncer(pid, pf.topic),
+		closer: func() {
+			sync.Close()
+			h.Close()
+		},
+	}, nil
+}
+
+func (f *fetcher) close() {
+	f.closer()
+}
+
+// head returns the CID of the publisher's most recent advertisement.
+func (f *fetcher) head(ctx context.Context) (cid.Cid, error) {
+	return f.syncer.GetHead(ctx)
+}
+
+// load fetches the single block identified by the CID, if it is not already
+// stored, and decodes it using the prototype.
+func (f *fetcher) load(ctx context.Context, c cid.Cid, proto ipld.NodePrototype) (ipld.Node, error) {
+	if err := f.syncer.Sync(ctx, c, selectorparse.CommonSelector_MatchPoint); err != nil {
+		return nil, err
+	}
+	return f.lsys.Load(ipld.LinkContext{Ctx: ctx}, cidlink.Link{Cid: c}, proto)
+}
+
+func (f *fetcher) loadAd(ctx context.Context, c cid.Cid) (*schema.Advertisement, error) {
+	node, err := f.load(ctx, c, schema.AdvertisementPrototype)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load advertisement %s: %w", c, err)
+	}
+	return schema.UnwrapAdvertisement(node)
+}
+
+func (f *fetcher) loadEntryChunk(ctx context.Context, c cid.Cid) (*schema.EntryChunk, error) {
+	node, err := f.load(ctx, c, schema.EntryChunkPrototype)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load entries %s: %w", c, err)
+	}
+	return schema.UnwrapEntryChunk(node)
+}
+
+// startCid returns the CID given by the -cid flag value, or the publisher's
+// head if no CID is given.
+func (f *fetcher) startCid(ctx context.Context, cidStr string) (cid.Cid, error) {
+	if cidStr != "" {
+		c, err := cid.Decode(cidStr)
+		if err != nil {
+			return cid.Undef, fmt.Errorf("bad cid: %w", err)
+		}
+		return c, nil
+	}
+	head, err := f.head(ctx)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("cannot get head: %w", err)
+	}
+	if head == cid.Undef {
+		return cid.Undef, errors.New("publisher has no advertisements")
+	}
+	return head, nil
+}
+
+// parseAddr parses a multiaddr, or a URL that is converted to a multiaddr.
+func parseAddr(s string) (multiaddr.Multiaddr, error) {
+	if !strings.Contains(s, "://") {
+		return multiaddr.NewMultiaddr(s)
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return maurl.FromURL(u)
+}
+
+func isHTTP(maddr multiaddr.Multiaddr) bool {
+	if maddr == nil {
+		return false
+	}
+	for _, p := range maddr.Protocols() {
+		if p.Code == multiaddr.P_HTTP || p.Code == multiaddr.P_HTTPS {
+			return true
+		}
+	}
+	return false
+}
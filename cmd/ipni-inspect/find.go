@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ipni/go-libipni/find/client"
+	"github.com/ipni/go-libipni/find/model"
+)
+
+type resultInfo struct {
+	Provider      string
+	Addresses     []string        `json:",omitempty"`
+	ContextID     []byte          `json:",omitempty"`
+	Metadata      json.RawMessage `json:",omitempty"`
+	MetadataError string          `json:",omitempty"`
+}
+
+type multihashInfo struct {
+	Multihash string
+	Results   []resultInfo
+}
+
+func runFind(ctx context.Context, args []string) error {
+	fs, out := newFlagSet("find")
+	indexer := fs.String("indexer", "https://cid.contact", "URL of indexer to query")
+	timeout := fs.Duration("timeout", time.Minute, "Timeout for the query")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	c, err := client.New(*indexer)
+	if err != nil {
+		return err
+	}
+	return find(ctx, c, fs.Args(), *timeout, out)
+}
+
+func runDHash(ctx context.Context, args []string) error {
+	fs, out := newFlagSet("dhash")
+	dhstore := fs.String("dhstore", "", "URL of dhstore to query")
+	providers := fs.String("providers", "", "URL to get provider information from. Default is the dhstore URL")
+	timeout := fs.Duration("timeout", time.Minute, "Timeout for the query")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dhstore == "" {
+		return errors.New("missing dhstore url, use -dhstore")
+	}
+	opts := []client.Option{client.WithDHStoreURL(*dhstore)}
+	if *providers != "" {
+		opts = append(opts, client.WithProvidersURL(*providers))
+	}
+	c, err := client.NewDHashClient(opts...)
+	if err != nil {
+		return err
+	}
+	return find(ctx, c, fs.Args(), *timeout, out)
+}
+
+func find(ctx context.Context, c client.Interface, args []string, timeout time.Duration, out *output) error {
+	mhs, err := parseMultihashes(args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rsp, err := c.FindBatch(ctx, mhs)
+	if err != nil {
+		return err
+	}
+	if rsp == nil || len(rsp.MultihashResults) == 0 {
+		return errors.New("not found")
+	}
+
+	for _, mhr := range rsp.MultihashResults {
+		info := newMultihashInfo(mhr)
+		err = out.write(info, func(w io.Writer) {
+			fmt.Fprintf(w, "Multihash: %s\n", info.Multihash)
+			for _, r := range info.Results {
+				fmt.Fprintf(w, "  Provider:   %s\n", r.Provider)
+				for _, addr := range r.Addresses {
+					fmt.Fprintf(w, "    Address:    %s\n", addr)
+				}
+				if len(r.ContextID) != 0 {
+					fmt.Fprintf(w, "    ContextID:  %s\n", encodeBase64(r.ContextID))
+				}
+				printMetadata(w, "    ", r.Metadata, r.MetadataError)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newMultihashInfo(mhr model.MultihashResult) multihashInfo {
+	info := multihashInfo{
+		Multihash: mhr.Multihash.B58String(),
+		Results:   make([]resultInfo, len(mhr.ProviderResults)),
+	}
+	for i, pr := range mhr.ProviderResults {
+		var ri resultInfo
+		if pr.Provider != nil {
+			ri.Provider = pr.Provider.ID.String()
+			for _, addr := range pr.Provider.Addrs {
+				ri.Addresses = append(ri.Addresses, addr.String())
+			}
+		}
+		ri.ContextID = pr.ContextID
+		if len(pr.Metadata) != 0 {
+			ri.Metadata, ri.MetadataError = metadataJSON(pr.Metadata)
+		}
+		info.Results[i] = ri
+	}
+	return info
+}
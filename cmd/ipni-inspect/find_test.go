@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunFind(t *testing.T) {
+	mhs := test.RandomMultihashes(2)
+	providerID, _, _ := test.RandomIdentity()
+	md := metadata.Default.New(metadata.Bitswap{})
+	mdBytes, err := md.MarshalBinary()
+	require.NoError(t, err)
+
+	rsp := &model.FindResponse{}
+	for _, mh := range mhs {
+		rsp.MultihashResults = append(rsp.MultihashResults, model.MultihashResult{
+			Multihash: mh,
+			ProviderResults: []model.ProviderResult{{
+				ContextID: []byte("ctx"),
+				Metadata:  mdBytes,
+				Provider: &peer.AddrInfo{
+					ID:    providerID,
+					Addrs: test.RandomMultiaddrs(1),
+				},
+			}},
+		})
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req, err := model.UnmarshalFindRequest(body)
+		require.NoError(t, err)
+		require.Equal(t, mhs, req.Multihashes)
+		data, err := model.MarshalFindResponse(rsp)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	out, err := runCommand(t, runFind, "-json", "-indexer", srv.URL, mhs[0].B58String(), mhs[1].B58String())
+	require.NoError(t, err)
+	infos := decodeNDJSON[multihashInfo](t, out)
+	require.Len(t, infos, 2)
+	for i, info := range infos {
+		require.Equal(t, mhs[i].B58String(), info.Multihash)
+		require.Len(t, info.Results, 1)
+		r := info.Results[0]
+		require.Equal(t, providerID.String(), r.Provider)
+		require.Equal(t, []string{rsp.MultihashResults[i].ProviderResults[0].Provider.Addrs[0].String()}, r.Addresses)
+		require.Equal(t, []byte("ctx"), r.ContextID)
+		require.JSONEq(t, `[{"protocol":"transport-bitswap"}]`, string(r.Metadata))
+	}
+}
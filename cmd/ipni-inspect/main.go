@@ -0,0 +1,123 @@
+// Command ipni-inspect inspects IPNI data. It fetches advertisement chains
+// and entries from publishers, decodes metadata and announce messages,
+// verifies advertisement signatures, converts between multiaddrs and URLs, and
+// queries indexers.
+//
+// Usage:
+//
+//	ipni-inspect <command> [flags] [arguments]
+//
+// Run "ipni-inspect help" to list the commands, and "ipni-inspect <command>
+// -h" to see the flags for a command. Every command accepts the -json flag to
+// write its output as newline delimited JSON for use in scripts: each result
+// is written as one JSON object on its own line, so commands such as ads,
+// entries and find write one line for each advertisement, entry chunk or
+// multihash.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands []command
+
+// stdout is where commands write their output.
+var stdout io.Writer = os.Stdout
+
+// errUsage is returned by a command that was given the wrong arguments.
+var errUsage = errors.New("wrong number of arguments")
+
+func init() {
+	commands = []command{
+		{"head", "-addr <publisher>", "Fetch the head of a publisher's advertisement chain", runHead},
+		{"ads", "-addr <publisher>", "Walk and print an advertisement chain", runAds},
+		{"entries", "-addr <publisher> -cid <ad>", "Dump the multihashes in an advertisement's entries", runEntries},
+		{"verify", "-addr <publisher>", "Verify advertisement signatures and lint advertisements", runVerify},
+		{"metadata", "<hex|base64>", "Decode advertisement or provider result metadata", runMetadata},
+		{"announce", "<hex|base64|json>", "Decode an announce message", runAnnounce},
+		{"maurl", "<multiaddr|url>...", "Convert between multiaddrs and URLs", runMaurl},
+		{"find", "-indexer <url> <multihash|cid>...", "Look up multihashes at an indexer", runFind},
+		{"dhash", "-dhstore <url> <multihash|cid>...", "Look up multihashes using double-hashed reader privacy", runDHash},
+	}
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	if name == "help" {
+		usage()
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := cmd.run(ctx, flag.Args()[1:])
+		cancel()
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				os.Exit(2)
+			}
+			fmt.Fprintf(os.Stderr, "ipni-inspect %s: %s\n", name, err)
+			if errors.Is(err, errUsage) {
+				os.Exit(2)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "ipni-inspect: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	var b strings.Builder
+	b.WriteString("Usage: ipni-inspect <command> [flags] [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-10s %s\n", cmd.name, cmd.short)
+	}
+	b.WriteString("\nRun \"ipni-inspect <command> -h\" for command flags.\n")
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// newFlagSet creates the flag set for a command, with the flags common to all
+// commands.
+func newFlagSet(name string) (*flag.FlagSet, *output) {
+	var args string
+	for _, cmd := range commands {
+		if cmd.name == name {
+			args = cmd.args
+			break
+		}
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ipni-inspect %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	out := &output{w: stdout}
+	fs.BoolVar(&out.json, "json", false, "Write output as JSON")
+	return fs, out
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+)
+
+// output writes command results either as human readable text or as JSON.
+type output struct {
+	json bool
+	w    io.Writer
+}
+
+// write writes v as a single line of JSON if JSON output is selected,
+// otherwise it calls text to write the human readable form. Commands that
+// produce several results call write once for each, so JSON output is
+// newline delimited.
+func (o *output) write(v any, text func(w io.Writer)) error {
+	if o.json {
+		return json.NewEncoder(o.w).Encode(v)
+	}
+	text(o.w)
+	return nil
+}
+
+// decodeBytes decodes data given on the command line as hex or base64. If the
+// argument is "-" then the data is read from stdin.
+func decodeBytes(arg string) ([]byte, error) {
+	if arg == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		arg = string(data)
+	}
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return nil, errors.New("no data")
+	}
+	s := strings.TrimPrefix(arg, "0x")
+	if data, err := hex.DecodeString(s); err == nil {
+		return data, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if data, err := enc.DecodeString(arg); err == nil {
+			return data, nil
+		}
+	}
+	return nil, errors.New("data is not hex or base64 encoded")
+}
+
+// parseMultihashes parses arguments that are either base58 encoded multihashes
+// or CIDs.
+func parseMultihashes(args []string) ([]multihash.Multihash, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no multihashes or cids given")
+	}
+	mhs := make([]multihash.Multihash, len(args))
+	for i, arg := range args {
+		mh, err := multihash.FromB58String(arg)
+		if err != nil {
+			c, cerr := cid.Decode(arg)
+			if cerr != nil {
+				return nil, fmt.Errorf("%q is not a multihash or cid", arg)
+			}
+			mh = c.Hash()
+		}
+		mhs[i] = mh
+	}
+	return mhs, nil
+}
+
+func encodeBase64(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ipni/go-libipni/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBytes(t *testing.T) {
+	want := []byte{0x80, 0x12, 0xa2, 0xff}
+	for _, arg := range []string{"8012a2ff", "0x8012a2ff", " 8012a2ff\n", "gBKi/w==", "gBKi/w", "gBKi_w==", "gBKi_w"} {
+		data, err := decodeBytes(arg)
+		require.NoError(t, err, arg)
+		require.Equal(t, want, data, arg)
+	}
+
+	_, err := decodeBytes("")
+	require.ErrorContains(t, err, "no data")
+	_, err = decodeBytes("not*encoded")
+	require.ErrorContains(t, err, "not hex or base64")
+}
+
+func TestParseMultihashes(t *testing.T) {
+	mhs := test.RandomMultihashes(2)
+	cids := test.RandomCids(1)
+
+	parsed, err := parseMultihashes([]string{mhs[0].B58String(), cids[0].String(), mhs[1].B58String()})
+	require.NoError(t, err)
+	require.Len(t, parsed, 3)
+	require.Equal(t, mhs[0], parsed[0])
+	require.Equal(t, cids[0].Hash(), parsed[1])
+	require.Equal(t, mhs[1], parsed[2])
+
+	_, err = parseMultihashes(nil)
+	require.Error(t, err)
+	_, err = parseMultihashes([]string{"fish"})
+	require.ErrorContains(t, err, `"fish" is not a multihash or cid`)
+}
+
+func TestOutputNDJSON(t *testing.T) {
+	type result struct {
+		Name  string
+		Items []string
+	}
+	var buf bytes.Buffer
+	out := &output{json: true, w: &buf}
+	text := func(io.Writer) { t.Fatal("text output written in json mode") }
+	require.NoError(t, out.write(result{"a", []string{"x", "y"}}, text))
+	require.NoError(t, out.write(result{"b", nil}, text))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Equal(t, []string{
+		`{"Name":"a","Items":["x","y"]}`,
+		`{"Name":"b","Items":null}`,
+	}, lines)
+
+	buf.Reset()
+	out.json = false
+	require.NoError(t, out.write(result{"a", nil}, func(w io.Writer) { io.WriteString(w, "text\n") }))
+	require.Equal(t, "text\n", buf.String())
+}
+
+// runCommand runs a command and returns what it wrote to stdout.
+func runCommand(t *testing.T, run func(context.Context, []string) error, args ...string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	saved := stdout
+	stdout = &buf
+	defer func() { stdout = saved }()
+	err := run(context.Background(), args)
+	return buf.String(), err
+}
+
+// decodeNDJSON decodes newline delimited JSON, checking that each value is
+// on its own line.
+func decodeNDJSON[T any](t *testing.T, s string) []T {
+	t.Helper()
+	var values []T
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		var v T
+		require.NoError(t, json.Unmarshal([]byte(line), &v), line)
+		values = append(values, v)
+	}
+	return values
+}
@@ -3,6 +3,7 @@ package dtsync
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 
@@ -13,6 +14,7 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/ipld/go-ipld-prime"
 	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipni/go-libipni/apierror"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
@@ -200,11 +202,7 @@ func (s *Sync) onEvent(event dt.Event, channelState dt.ChannelState) {
 		// Communicate the error back to the waiting handler.
 		msg := channelState.Message()
 		log.Errorw("Datatransfer failed", "err", msg, "cid", channelState.BaseCID(), "peer", channelState.OtherPeer())
-		if strings.Contains(msg, "content not found") {
-			err = fmt.Errorf("content not found: %w", ipld.ErrNotExists{})
-		} else {
-			err = fmt.Errorf("datatransfer failed: %s", msg)
-		}
+		err = failedTransferError(msg)
 
 	default:
 		// Ignore non-terminal channel states.
@@ -222,3 +220,19 @@ func (s *Sync) onEvent(event dt.Event, channelState dt.ChannelState) {
 		return
 	}
 }
+
+// failedTransferError converts the message from a failed data transfer into an
+// error. Known failures are returned as an apierror.Error with a code that
+// identifies the kind of failure, so that callers do not need to inspect the
+// message.
+func failedTransferError(msg string) error {
+	switch {
+	case strings.Contains(msg, "content not found"):
+		return apierror.New(fmt.Errorf("content not found: %w", ipld.ErrNotExists{}), http.StatusNotFound)
+	case strings.Contains(msg, dt.ErrRejected.Error()):
+		// The transfer was rejected because the provider is not allowed.
+		return apierror.New(fmt.Errorf("datatransfer failed: %s", msg), http.StatusForbidden).
+			WithCode(apierror.CodeProviderNotAllowed)
+	}
+	return fmt.Errorf("datatransfer failed: %s", msg)
+}
@@ -0,0 +1,105 @@
+package httpsync
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// latencyKeyPrefix is prepended to an address to form the peerstore
+	// metadata key for the address latency.
+	latencyKeyPrefix = "httpsync/latency/"
+	// latencySmoothing is the weight given to a new latency sample in the
+	// exponentially weighted moving average of an address's latency.
+	latencySmoothing = 0.1
+)
+
+// addrLatency is the latency information recorded for an address.
+type addrLatency struct {
+	// ewma is the moving average latency of successful requests.
+	ewma time.Duration
+	// failed is true if the most recent request to the address failed.
+	failed bool
+}
+
+func latencyKey(maddr multiaddr.Multiaddr) string {
+	return latencyKeyPrefix + maddr.String()
+}
+
+// AddrLatency returns the average time taken to receive a response from the
+// peer's address, as recorded in the latency store. Returns false if there is
+// no latency recorded for the address, or if the most recent request to the
+// address failed.
+func AddrLatency(store peerstore.PeerMetadata, peerID peer.ID, maddr multiaddr.Multiaddr) (time.Duration, bool) {
+	al, ok := getLatency(store, peerID, maddr)
+	if !ok || al.failed {
+		return 0, false
+	}
+	return al.ewma, true
+}
+
+func getLatency(store peerstore.PeerMetadata, peerID peer.ID, maddr multiaddr.Multiaddr) (addrLatency, bool) {
+	val, err := store.Get(peerID, latencyKey(maddr))
+	if err != nil {
+		return addrLatency{}, false
+	}
+	al, ok := val.(addrLatency)
+	return al, ok
+}
+
+// recordLatency updates the latency recorded for an address with the result
+// of a request.
+func recordLatency(store peerstore.PeerMetadata, peerID peer.ID, maddr multiaddr.Multiaddr, latency time.Duration, failed bool) {
+	prev, ok := getLatency(store, peerID, maddr)
+	al := addrLatency{failed: failed}
+	switch {
+	case failed:
+		al.ewma = prev.ewma
+	case !ok || prev.ewma == 0:
+		al.ewma = latency
+	default:
+		al.ewma = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(prev.ewma))
+	}
+	if err := store.Put(peerID, latencyKey(maddr), al); err != nil {
+		log.Warnw("Cannot record address latency", "err", err, "peer", peerID, "addr", maddr)
+	}
+}
+
+// sortByLatency orders addresses with the lowest recorded latency first,
+// followed by addresses with no recorded latency, followed by addresses whose
+// most recent request failed. Addresses that sort equally keep their original
+// relative order.
+func sortByLatency(store peerstore.PeerMetadata, peerID peer.ID, maddrs []multiaddr.Multiaddr) {
+	const (
+		known = iota
+		unknown
+		failed
+	)
+	type sortKey struct {
+		group   int
+		latency time.Duration
+	}
+	keys := make(map[string]sortKey, len(maddrs))
+	for _, maddr := range maddrs {
+		al, ok := getLatency(store, peerID, maddr)
+		switch {
+		case !ok:
+			keys[string(maddr.Bytes())] = sortKey{group: unknown}
+		case al.failed:
+			keys[string(maddr.Bytes())] = sortKey{group: failed}
+		default:
+			keys[string(maddr.Bytes())] = sortKey{group: known, latency: al.ewma}
+		}
+	}
+	sort.SliceStable(maddrs, func(i, j int) bool {
+		a, b := keys[string(maddrs[i].Bytes())], keys[string(maddrs[j].Bytes())]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		return a.latency < b.latency
+	})
+}
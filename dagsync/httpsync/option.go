@@ -0,0 +1,62 @@
+package httpsync
+
+import (
+	"time"
+
+	"github.com/ipni/go-libipni/mautil"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+)
+
+// defaultStaggerDelay is the default time to wait for a response from one
+// address before also sending the request to the next address.
+const defaultStaggerDelay = 250 * time.Millisecond
+
+// config contains all options for configuring Sync.
+type config struct {
+	latencyStore peerstore.PeerMetadata
+	rankOpts     []mautil.RankOption
+	staggerDelay time.Duration
+}
+
+// Option is a function that sets a value in a config.
+type Option func(*config)
+
+// getOpts creates a config and applies Options to it.
+func getOpts(opts []Option) config {
+	cfg := config{
+		staggerDelay: defaultStaggerDelay,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithAddrRanking sets the preferences used to rank a peer's addresses, so
+// that the most preferred address is tried first. By default addresses are
+// ranked using the mautil.Rank default preferences.
+func WithAddrRanking(opts ...mautil.RankOption) Option {
+	return func(c *config) {
+		c.rankOpts = opts
+	}
+}
+
+// WithLatencyStore sets a store in which the latency of each of a peer's
+// addresses is recorded. Addresses with a lower recorded latency are tried
+// before others in future syncs with the peer. Use AddrLatency to read the
+// recorded latency.
+func WithLatencyStore(store peerstore.PeerMetadata) Option {
+	return func(c *config) {
+		c.latencyStore = store
+	}
+}
+
+// WithStaggerDelay sets the time to wait for a response from one of a peer's
+// addresses before also sending the request to the next address. The first
+// address to respond is used for the remainder of the sync. A value of 0 sends
+// the request to all addresses at once. Default is 250ms.
+func WithStaggerDelay(delay time.Duration) Option {
+	return func(c *config) {
+		c.staggerDelay = delay
+	}
+}
@@ -18,9 +18,12 @@ import (
 	basicnode "github.com/ipld/go-ipld-prime/node/basic"
 	"github.com/ipld/go-ipld-prime/traversal"
 	"github.com/ipld/go-ipld-prime/traversal/selector"
+	"github.com/ipni/go-libipni/apierror"
 	"github.com/ipni/go-libipni/maurl"
+	"github.com/ipni/go-libipni/mautil"
 	ic "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multihash"
 )
@@ -31,27 +34,44 @@ var log = logging.Logger("dagsync/httpsync")
 
 // Sync provides sync functionality for use with all http syncs.
 type Sync struct {
-	blockHook func(peer.ID, cid.Cid)
-	client    *http.Client
-	lsys      ipld.LinkSystem
+	blockHook    func(peer.ID, cid.Cid)
+	client       *http.Client
+	lsys         ipld.LinkSystem
+	latencyStore peerstore.PeerMetadata
+	rankOpts     []mautil.RankOption
+	staggerDelay time.Duration
 }
 
 // NewSync creates a new Sync.
-func NewSync(lsys ipld.LinkSystem, client *http.Client, blockHook func(peer.ID, cid.Cid)) *Sync {
+func NewSync(lsys ipld.LinkSystem, client *http.Client, blockHook func(peer.ID, cid.Cid), options ...Option) *Sync {
+	opts := getOpts(options)
 	if client == nil {
 		client = &http.Client{
 			Timeout: defaultHttpTimeout,
 		}
 	}
 	return &Sync{
-		blockHook: blockHook,
-		client:    client,
-		lsys:      lsys,
+		blockHook:    blockHook,
+		client:       client,
+		lsys:         lsys,
+		latencyStore: opts.latencyStore,
+		rankOpts:     opts.rankOpts,
+		staggerDelay: opts.staggerDelay,
 	}
 }
 
-// NewSyncer creates a new Syncer to use for a single sync operation against a peer.
+// NewSyncer creates a new Syncer to use for a single sync operation against a
+// peer. The peer's addresses are ranked so that the most preferred address is
+// tried first. If a latency store is configured, then addresses with the
+// lowest recorded latency are tried first.
 func (s *Sync) NewSyncer(peerID peer.ID, peerAddrs []multiaddr.Multiaddr) (*Syncer, error) {
+	if len(peerAddrs) == 0 {
+		return nil, errors.New("no peer addresses")
+	}
+	peerAddrs = mautil.Rank(peerAddrs, s.rankOpts...)
+	if s.latencyStore != nil {
+		sortByLatency(s.latencyStore, peerID, peerAddrs)
+	}
 	urls := make([]*url.URL, len(peerAddrs))
 	for i := range peerAddrs {
 		var err error
@@ -62,10 +82,11 @@ func (s *Sync) NewSyncer(peerID peer.ID, peerAddrs []multiaddr.Multiaddr) (*Sync
 	}
 
 	return &Syncer{
-		peerID:  peerID,
-		rootURL: *urls[0],
-		urls:    urls[1:],
-		sync:    s,
+		peerID: peerID,
+		addrs:  peerAddrs,
+		urls:   urls,
+		winner: -1,
+		sync:   s,
 	}, nil
 }
 
@@ -77,10 +98,15 @@ var errHeadFromUnexpectedPeer = errors.New("found head signed from an unexpected
 
 // Syncer provides sync functionality for a single sync with a peer.
 type Syncer struct {
-	peerID  peer.ID
-	rootURL url.URL
-	urls    []*url.URL
-	sync    *Sync
+	peerID peer.ID
+	// addrs and urls are the peer's addresses, in order of preference, that
+	// have not failed.
+	addrs []multiaddr.Multiaddr
+	urls  []*url.URL
+	// winner is the index of the address that responded first, and is used
+	// for all requests until it fails. It is -1 until an address responds.
+	winner int
+	sync   *Sync
 }
 
 // GetHead fetches the head of the peer's advertisement chain.
@@ -197,22 +223,8 @@ func (s *Syncer) walkFetch(ctx context.Context, rootCid cid.Cid, sel selector.Se
 }
 
 func (s *Syncer) fetch(ctx context.Context, rsrc string, cb func(io.Reader) error) error {
-nextURL:
-	fetchURL := s.rootURL.JoinPath(rsrc)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", fetchURL.String(), nil)
+	resp, err := s.request(ctx, rsrc)
 	if err != nil {
-		return err
-	}
-
-	resp, err := s.sync.client.Do(req)
-	if err != nil {
-		if len(s.urls) != 0 {
-			log.Errorw("Fetch request failed, will retry with next address", "err", err)
-			s.rootURL = *s.urls[0]
-			s.urls = s.urls[1:]
-			goto nextURL
-		}
 		return fmt.Errorf("fetch request failed: %w", err)
 	}
 	defer resp.Body.Close()
@@ -223,13 +235,187 @@ nextURL:
 		// Include the string "content not found" so that indexers that have not
 		// upgraded gracefully handle the error case. Because, this string is
 		// being checked already.
-		return fmt.Errorf("content not found: %w", ipld.ErrNotExists{})
+		return apierror.New(fmt.Errorf("content not found: %w", ipld.ErrNotExists{}), http.StatusNotFound)
 	case http.StatusOK:
 		log.Debugw("Found block from HTTP publisher", "resource", rsrc)
 		return cb(resp.Body)
 	default:
-		return fmt.Errorf("non success http fetch response at %s: %d", fetchURL.String(), resp.StatusCode)
+		return apierror.New(fmt.Errorf("non success http fetch response at %s: %d", resp.Request.URL, resp.StatusCode), resp.StatusCode)
+	}
+}
+
+// request sends a request for the resource to the address that previously
+// responded first. If no address has responded yet, or that address fails,
+// then the request is raced across the remaining addresses.
+func (s *Syncer) request(ctx context.Context, rsrc string) (*http.Response, error) {
+	if s.winner != -1 {
+		resp, err := s.do(ctx, s.urls[s.winner], s.addrs[s.winner], rsrc)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || len(s.urls) == 1 {
+			return nil, err
+		}
+		log.Errorw("Fetch request failed, will retry with other addresses", "err", err, "addr", s.addrs[s.winner])
+		s.addrs = append(s.addrs[:s.winner:s.winner], s.addrs[s.winner+1:]...)
+		s.urls = append(s.urls[:s.winner:s.winner], s.urls[s.winner+1:]...)
+		s.winner = -1
+	}
+	return s.race(ctx, rsrc)
+}
+
+type attempt struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+// race sends the request to the peer's addresses, in order, starting the
+// request to the next address if there is no response within the stagger
+// delay or if the previous request fails. The first address to respond is
+// remembered and used for subsequent requests, and all other requests are
+// canceled. Addresses that failed before one responded are removed, as in
+// request. If every address fails, then none are removed so that they can be
+// tried again by a later request.
+func (s *Syncer) race(ctx context.Context, rsrc string) (*http.Response, error) {
+	// Requests that are still running after race returns use these, so that
+	// they are not affected by removing failed addresses.
+	urls, addrs := s.urls, s.addrs
+	results := make(chan attempt, len(urls))
+	cancels := make([]context.CancelFunc, len(urls))
+	failed := make([]bool, len(urls))
+	var next, pending int
+
+	start := func() {
+		i := next
+		next++
+		pending++
+		actx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		go func() {
+			resp, err := s.do(actx, urls[i], addrs[i], rsrc)
+			results <- attempt{index: i, resp: resp, err: err}
+		}()
+	}
+	// drain cancels and cleans up the remaining requests.
+	drain := func(except int) {
+		for i, cancel := range cancels {
+			if i != except && cancel != nil {
+				cancel()
+			}
+		}
+		go func(pending int) {
+			for ; pending != 0; pending-- {
+				if a := <-results; a.resp != nil {
+					a.resp.Body.Close()
+				}
+			}
+		}(pending)
 	}
+
+	start()
+	stagger := time.NewTimer(s.sync.staggerDelay)
+	defer stagger.Stop()
+	// resetStagger restarts the stagger delay. The timer is stopped and its
+	// channel drained first, since it may have fired without being received.
+	resetStagger := func() {
+		if !stagger.Stop() {
+			select {
+			case <-stagger.C:
+			default:
+			}
+		}
+		stagger.Reset(s.sync.staggerDelay)
+	}
+
+	var lastErr error
+	for pending != 0 {
+		select {
+		case <-stagger.C:
+			if next < len(urls) {
+				start()
+				resetStagger()
+			}
+		case a := <-results:
+			pending--
+			if a.err != nil {
+				cancels[a.index]()
+				lastErr = a.err
+				if ctx.Err() != nil {
+					continue
+				}
+				failed[a.index] = true
+				log.Infow("Fetch request failed", "err", a.err, "addr", addrs[a.index])
+				if next < len(urls) {
+					start()
+					resetStagger()
+				}
+				continue
+			}
+			drain(a.index)
+			s.winner = s.removeFailed(failed, a.index)
+			a.resp.Body = &cancelOnClose{ReadCloser: a.resp.Body, cancel: cancels[a.index]}
+			return a.resp, nil
+		case <-ctx.Done():
+			drain(-1)
+			return nil, ctx.Err()
+		}
+	}
+	return nil, lastErr
+}
+
+// removeFailed removes the addresses marked as failed, and returns the new
+// index of the address at index keep.
+func (s *Syncer) removeFailed(failed []bool, keep int) int {
+	addrs := make([]multiaddr.Multiaddr, 0, len(s.addrs))
+	urls := make([]*url.URL, 0, len(s.urls))
+	newKeep := -1
+	for i := range s.addrs {
+		if failed[i] {
+			continue
+		}
+		if i == keep {
+			newKeep = len(addrs)
+		}
+		addrs = append(addrs, s.addrs[i])
+		urls = append(urls, s.urls[i])
+	}
+	s.addrs = addrs
+	s.urls = urls
+	return newKeep
+}
+
+// do sends a request for the resource to the URL of the address, and records
+// the time taken to receive a response.
+func (s *Syncer) do(ctx context.Context, u *url.URL, addr multiaddr.Multiaddr, rsrc string) (*http.Response, error) {
+	fetchURL := u.JoinPath(rsrc)
+	req, err := http.NewRequestWithContext(ctx, "GET", fetchURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := s.sync.client.Do(req)
+	if s.sync.latencyStore != nil {
+		// Do not record a failure for a request canceled because another
+		// address responded first.
+		if err == nil || ctx.Err() == nil {
+			recordLatency(s.sync.latencyStore, s.peerID, addr, time.Since(start), err != nil)
+		}
+	}
+	return resp, err
+}
+
+// cancelOnClose cancels a request's context when the response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
 }
 
 // fetchBlock fetches an item into the datastore at c if not locally available.
@@ -8,7 +8,9 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"path"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipld/go-ipld-prime"
@@ -25,6 +27,7 @@ import (
 	"github.com/ipni/go-libipni/maurl"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multicodec"
 	"github.com/multiformats/go-multihash"
@@ -217,3 +220,178 @@ func TestHttpsync_NotFoundReturnsContentNotFoundErr(t *testing.T) {
 	require.NotNil(t, err)
 	require.Contains(t, err.Error(), "content not found")
 }
+
+func TestHttpsync_RacesAddrsAndRecordsLatency(t *testing.T) {
+	ctx := context.Background()
+	pubid, err := peer.Decode("QmQzqxhK82kAmKvARFZSkUVS6fo9sySaiogAnx5EnZ6ZmC")
+	require.NoError(t, err)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) == "head" {
+			_, err := w.Write([]byte(sampleNFTStorageHead))
+			require.NoError(t, err)
+			return
+		}
+		http.NotFound(w, r)
+	}
+	var slowReqs atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowReqs.Add(1)
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+		handler(w, r)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(handler))
+	defer fast.Close()
+
+	var maddrs []multiaddr.Multiaddr
+	for _, srvURL := range []string{slow.URL, fast.URL} {
+		u, err := url.Parse(srvURL)
+		require.NoError(t, err)
+		maddr, err := maurl.FromURL(u)
+		require.NoError(t, err)
+		maddrs = append(maddrs, maddr)
+	}
+
+	ls := cidlink.DefaultLinkSystem()
+	store := &memstore.Store{}
+	ls.SetWriteStorage(store)
+	ls.SetReadStorage(store)
+
+	latencyStore := pstoremem.NewPeerMetadata()
+	sync := httpsync.NewSync(ls, http.DefaultClient, nil,
+		httpsync.WithLatencyStore(latencyStore), httpsync.WithStaggerDelay(50*time.Millisecond))
+
+	// The slow address is tried first, but the fast address responds first.
+	syncer, err := sync.NewSyncer(pubid, maddrs)
+	require.NoError(t, err)
+	start := time.Now()
+	head, err := syncer.GetHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sampleNFTStorageCid, head.String())
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Equal(t, int32(1), slowReqs.Load())
+
+	_, ok := httpsync.AddrLatency(latencyStore, pubid, maddrs[1])
+	require.True(t, ok)
+	_, ok = httpsync.AddrLatency(latencyStore, pubid, maddrs[0])
+	require.False(t, ok)
+
+	// The fast address has a recorded latency, so it is tried first by the
+	// next syncer and the slow address is never contacted.
+	syncer, err = sync.NewSyncer(pubid, maddrs)
+	require.NoError(t, err)
+	_, err = syncer.GetHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), slowReqs.Load())
+}
+
+func TestHttpsync_FailsOverToNextAddr(t *testing.T) {
+	ctx := context.Background()
+	pubid, err := peer.Decode("QmQzqxhK82kAmKvARFZSkUVS6fo9sySaiogAnx5EnZ6ZmC")
+	require.NoError(t, err)
+
+	pub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) == "head" {
+			_, err := w.Write([]byte(sampleNFTStorageHead))
+			require.NoError(t, err)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer pub.Close()
+
+	// Get an address that nothing is listening on.
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL, err := url.Parse(dead.URL)
+	require.NoError(t, err)
+	dead.Close()
+
+	pubURL, err := url.Parse(pub.URL)
+	require.NoError(t, err)
+	var maddrs []multiaddr.Multiaddr
+	for _, u := range []*url.URL{deadURL, pubURL} {
+		maddr, err := maurl.FromURL(u)
+		require.NoError(t, err)
+		maddrs = append(maddrs, maddr)
+	}
+
+	latencyStore := pstoremem.NewPeerMetadata()
+	sync := httpsync.NewSync(cidlink.DefaultLinkSystem(), http.DefaultClient, nil,
+		httpsync.WithLatencyStore(latencyStore), httpsync.WithStaggerDelay(time.Minute))
+	syncer, err := sync.NewSyncer(pubid, maddrs)
+	require.NoError(t, err)
+
+	// The failed request to the dead address starts the request to the next
+	// address without waiting for the stagger delay.
+	start := time.Now()
+	head, err := syncer.GetHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sampleNFTStorageCid, head.String())
+	require.Less(t, time.Since(start), time.Minute)
+
+	_, ok := httpsync.AddrLatency(latencyStore, pubid, maddrs[1])
+	require.True(t, ok)
+	_, ok = httpsync.AddrLatency(latencyStore, pubid, maddrs[0])
+	require.False(t, ok)
+}
+
+func TestHttpsync_RemovesAddrsThatFailDuringRace(t *testing.T) {
+	ctx := context.Background()
+	pubid, err := peer.Decode("QmQzqxhK82kAmKvARFZSkUVS6fo9sySaiogAnx5EnZ6ZmC")
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) == "head" {
+			_, err := w.Write([]byte(sampleNFTStorageHead))
+			require.NoError(t, err)
+			return
+		}
+		http.NotFound(w, r)
+	})
+	// The broken server closes each connection without responding.
+	var brokenReqs atomic.Int32
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		brokenReqs.Add(1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	defer broken.Close()
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	var maddrs []multiaddr.Multiaddr
+	for _, srvURL := range []string{broken.URL, first.URL, second.URL} {
+		u, err := url.Parse(srvURL)
+		require.NoError(t, err)
+		maddr, err := maurl.FromURL(u)
+		require.NoError(t, err)
+		maddrs = append(maddrs, maddr)
+	}
+
+	sync := httpsync.NewSync(cidlink.DefaultLinkSystem(), &http.Client{Transport: &http.Transport{}}, nil,
+		httpsync.WithStaggerDelay(time.Minute))
+	syncer, err := sync.NewSyncer(pubid, maddrs)
+	require.NoError(t, err)
+
+	// The broken address fails and the first working address responds.
+	_, err = syncer.GetHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), brokenReqs.Load())
+
+	// When the first working address fails, the request is raced across the
+	// remaining addresses, which no longer include the broken address.
+	first.CloseClientConnections()
+	first.Close()
+	head, err := syncer.GetHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sampleNFTStorageCid, head.String())
+	require.Equal(t, int32(1), brokenReqs.Load())
+}
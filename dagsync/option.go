@@ -11,6 +11,7 @@ import (
 	"github.com/ipld/go-ipld-prime"
 	"github.com/ipld/go-ipld-prime/traversal/selector"
 	"github.com/ipni/go-libipni/announce"
+	"github.com/ipni/go-libipni/mautil"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
@@ -57,6 +58,8 @@ type config struct {
 
 	gsMaxInRequests  uint64
 	gsMaxOutRequests uint64
+
+	rankOpts []mautil.RankOption
 }
 
 // Option is a function that sets a value in a config.
@@ -193,6 +196,17 @@ func WithLastKnownSync(f LastKnownSyncFunc) Option {
 	}
 }
 
+// AddrRanking sets the preferences used to rank publisher addresses, so that
+// the most preferred address is tried first when syncing over HTTP, and
+// addresses are added to the libp2p peerstore in order of preference. By
+// default addresses are ranked using the mautil.Rank default preferences.
+func AddrRanking(opts ...mautil.RankOption) Option {
+	return func(c *config) error {
+		c.rankOpts = opts
+		return nil
+	}
+}
+
 type syncCfg struct {
 	forceUpdateLatest bool
 	scopedBlockHook   BlockHookFunc
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +16,7 @@ import (
 	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 	"github.com/ipld/go-ipld-prime/traversal/selector"
 	"github.com/ipni/go-libipni/announce"
+	"github.com/ipni/go-libipni/apierror"
 	"github.com/ipni/go-libipni/dagsync/dtsync"
 	"github.com/ipni/go-libipni/dagsync/httpsync"
 	"github.com/ipni/go-libipni/mautil"
@@ -91,6 +91,8 @@ type Subscriber struct {
 	// be stored in the libp2p peerstore as those are not usable by the libp2p
 	// transport.
 	httpPeerstore peerstore.Peerstore
+	// rankOpts are the preferences used to rank publisher addresses.
+	rankOpts []mautil.RankOption
 
 	idleHandlerTTL    time.Duration
 	latestSyncHandler latestSyncHandler
@@ -201,11 +203,13 @@ func NewSubscriber(host host.Host, ds datastore.Batching, lsys ipld.LinkSystem,
 		addEventChan: make(chan chan<- SyncFinished),
 		rmEventChan:  make(chan chan<- SyncFinished),
 
-		dtSync:       dtSync,
-		httpSync:     httpsync.NewSync(lsys, opts.httpClient, blockHook),
+		dtSync: dtSync,
+		httpSync: httpsync.NewSync(lsys, opts.httpClient, blockHook,
+			httpsync.WithAddrRanking(opts.rankOpts...), httpsync.WithLatencyStore(httpPeerstore)),
 		syncRecLimit: opts.syncRecLimit,
 
 		httpPeerstore: httpPeerstore,
+		rankOpts:      opts.rankOpts,
 
 		scopedBlockHookMutex: scopedBlockHookMutex,
 		scopedBlockHook:      scopedBlockHook,
@@ -484,7 +488,7 @@ func (s *Subscriber) Sync(ctx context.Context, peerInfo peer.AddrInfo, nextCid c
 			// Not an http address, so add to the host's libp2p peerstore.
 			peerStore := s.host.Peerstore()
 			if peerStore != nil {
-				peerStore.AddAddrs(peerInfo.ID, peerInfo.Addrs, s.addrTTL)
+				peerStore.AddAddrs(peerInfo.ID, mautil.Rank(peerInfo.Addrs, s.rankOpts...), s.addrTTL)
 			}
 		}
 	}
@@ -655,7 +659,7 @@ func (s *Subscriber) makeSyncer(peerInfo peer.AddrInfo, addrTTL time.Duration) (
 	// decreased here.
 	peerStore := s.host.Peerstore()
 	if peerStore != nil && len(peerInfo.Addrs) != 0 {
-		peerStore.AddAddrs(peerInfo.ID, peerInfo.Addrs, addrTTL)
+		peerStore.AddAddrs(peerInfo.ID, mautil.Rank(peerInfo.Addrs, s.rankOpts...), addrTTL)
 	}
 
 	return s.dtSync.NewSyncer(peerInfo.ID, s.topicName), false, nil
@@ -702,10 +706,9 @@ func (h *handler) handleAsync(ctx context.Context, nextCid cid.Cid, syncer Synce
 					h.subscriber.receiver.UncacheCid(c)
 				}
 				log.Errorw("Cannot process message", "err", err, "publisher", h.peerID)
-				if strings.Contains(err.Error(), "response rejected") {
-					// A "response rejected" error happens when the indexer
-					// does no allow a provider. This is not an error with
-					// provider, so do not send an error event.
+				if apierror.IsCode(err, apierror.CodeProviderNotAllowed) {
+					// The indexer does not allow the provider. This is not an
+					// error with provider, so do not send an error event.
 					return
 				}
 				h.subscriber.inEvents <- SyncFinished{
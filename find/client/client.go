@@ -4,17 +4,32 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 
 	"github.com/ipni/go-libipni/apierror"
 	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/metadata"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-msgio"
 	"github.com/multiformats/go-multihash"
 )
 
+const (
+	mediaTypeNDJson     = "application/x-ndjson"
+	mediaTypeJson       = "application/json"
+	mediaTypeCbor       = "application/cbor"
+	mediaTypeCborStream = "application/vnd.ipni.cbor-stream"
+
+	// maxStreamMsgSize is the maximum size of a single length-prefixed
+	// result in a cbor-stream response.
+	maxStreamMsgSize = 4 << 20
+)
+
 const (
 	findPath      = "multihash"
 	providersPath = "providers"
@@ -23,10 +38,14 @@ const (
 
 // Client is an http client for the indexer find API
 type Client struct {
+	binary       bool
 	c            *http.Client
 	findURL      *url.URL
 	providersURL *url.URL
 	statsURL     *url.URL
+
+	metadataCtx     metadata.MetadataContext
+	metadataFilters []model.MetadataFilter
 }
 
 // Client must implement Interface.
@@ -49,10 +68,14 @@ func New(baseURL string, options ...Option) (*Client, error) {
 	u.Path = ""
 
 	return &Client{
+		binary:       opts.binary,
 		c:            opts.httpClient,
 		findURL:      u.JoinPath(findPath),
 		providersURL: u.JoinPath(providersPath),
 		statsURL:     u.JoinPath(statsPath),
+
+		metadataCtx:     opts.metadataCtx,
+		metadataFilters: opts.metadataFilters,
 	}, nil
 }
 
@@ -67,6 +90,122 @@ func (c *Client) Find(ctx context.Context, m multihash.Multihash) (*model.FindRe
 	return c.sendRequest(req)
 }
 
+// FindAsync looks up content entries by multihash, and returns results on
+// resChan as they are received. Results are requested as NDJSON, or as
+// length-prefixed dag-cbor if binary encoding is enabled, so that they can be
+// streamed. If the server does not support streaming, then the whole response
+// is read and its results are written to resChan. When finished, resChan is
+// closed and the error or nil is returned.
+func (c *Client) FindAsync(ctx context.Context, mh multihash.Multihash, resChan chan<- model.ProviderResult) error {
+	defer close(resChan)
+
+	u := c.findURL.JoinPath(mh.B58String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.binary {
+		req.Header.Add("Accept", mediaTypeCborStream)
+	}
+	req.Header.Add("Accept", mediaTypeNDJson)
+	c.addAccept(req)
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return apierror.FromHTTPResponse(resp)
+	}
+
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mt {
+	case mediaTypeNDJson:
+	case mediaTypeCborStream:
+		return c.readCborStream(ctx, resp.Body, resChan)
+	default:
+		// Server does not support streaming, so read whole response.
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		findResp, err := unmarshalFindResponse(mt, body)
+		if err != nil {
+			return err
+		}
+		for _, mhr := range findResp.MultihashResults {
+			for _, pr := range mhr.ProviderResults {
+				if !model.MatchMetadata(pr, c.metadataCtx, c.metadataFilters...) {
+					continue
+				}
+				select {
+				case resChan <- pr:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var pr model.ProviderResult
+		if err = dec.Decode(&pr); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if !model.MatchMetadata(pr, c.metadataCtx, c.metadataFilters...) {
+			continue
+		}
+		select {
+		case resChan <- pr:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// readCborStream reads length-prefixed dag-cbor provider results and writes
+// them to resChan.
+func (c *Client) readCborStream(ctx context.Context, r io.Reader, resChan chan<- model.ProviderResult) error {
+	mr := msgio.NewVarintReaderSize(r, maxStreamMsgSize)
+	for {
+		msg, err := mr.ReadMsg()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		pr, err := model.UnmarshalProviderResultCBOR(msg)
+		if err != nil {
+			return err
+		}
+		if !model.MatchMetadata(pr, c.metadataCtx, c.metadataFilters...) {
+			continue
+		}
+		select {
+		case resChan <- pr:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 // FindBatch looks up content entries for a batch of multihashes
 func (c *Client) FindBatch(ctx context.Context, mhs []multihash.Multihash) (*model.FindResponse, error) {
 	if len(mhs) == 0 {
@@ -89,6 +228,7 @@ func (c *Client) ListProviders(ctx context.Context) ([]*model.ProviderInfo, erro
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Accept", apierror.MediaTypeProblem)
 
 	resp, err := c.c.Do(req)
 	if err != nil {
@@ -96,17 +236,15 @@ func (c *Client) ListProviders(ctx context.Context) ([]*model.ProviderInfo, erro
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, apierror.FromHTTPResponse(resp)
+	}
 
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, apierror.FromResponse(resp.StatusCode, body)
-	}
-
 	var providers []*model.ProviderInfo
 	err = json.Unmarshal(body, &providers)
 	if err != nil {
@@ -123,6 +261,7 @@ func (c *Client) GetProvider(ctx context.Context, providerID peer.ID) (*model.Pr
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Accept", apierror.MediaTypeProblem)
 
 	resp, err := c.c.Do(req)
 	if err != nil {
@@ -130,15 +269,15 @@ func (c *Client) GetProvider(ctx context.Context, providerID peer.ID) (*model.Pr
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, apierror.FromHTTPResponse(resp)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, apierror.FromResponse(resp.StatusCode, body)
-	}
-
 	var providerInfo model.ProviderInfo
 	err = json.Unmarshal(body, &providerInfo)
 	if err != nil {
@@ -153,6 +292,7 @@ func (c *Client) GetStats(ctx context.Context) (*model.Stats, error) {
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Accept", apierror.MediaTypeProblem)
 
 	resp, err := c.c.Do(req)
 	if err != nil {
@@ -160,37 +300,64 @@ func (c *Client) GetStats(ctx context.Context) (*model.Stats, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, apierror.FromHTTPResponse(resp)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, apierror.FromResponse(resp.StatusCode, body)
+	return model.UnmarshalStats(body)
+}
+
+// addAccept adds the Accept headers for a complete find response, and for
+// error responses as problem details.
+func (c *Client) addAccept(req *http.Request) {
+	if c.binary {
+		req.Header.Add("Accept", mediaTypeCbor)
 	}
+	req.Header.Add("Accept", mediaTypeJson)
+	req.Header.Add("Accept", apierror.MediaTypeProblem)
+}
 
-	return model.UnmarshalStats(body)
+// unmarshalFindResponse de-serializes a find response according to its media
+// type.
+func unmarshalFindResponse(mediaType string, b []byte) (*model.FindResponse, error) {
+	if mediaType == mediaTypeCbor {
+		return model.UnmarshalFindResponseCBOR(b)
+	}
+	return model.UnmarshalFindResponse(b)
 }
 
 func (c *Client) sendRequest(req *http.Request) (*model.FindResponse, error) {
 	req.Header.Set("Content-Type", "application/json")
+	c.addAccept(req)
 	resp, err := c.c.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
+
 	// Handle failed requests
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusNotFound {
 			return &model.FindResponse{}, nil
 		}
-		return nil, fmt.Errorf("batch find query failed: %v", http.StatusText(resp.StatusCode))
+		return nil, apierror.FromHTTPResponse(resp)
 	}
 
-	defer resp.Body.Close()
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	return model.UnmarshalFindResponse(b)
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	findResp, err := unmarshalFindResponse(mt, b)
+	if err != nil {
+		return nil, err
+	}
+	model.FilterFindResponse(findResp, c.metadataCtx, c.metadataFilters...)
+	return findResp, nil
 }
@@ -0,0 +1,155 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/find/client"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/rwriter"
+	"github.com/ipni/go-libipni/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAsync(t *testing.T) {
+	prs := []model.ProviderResult{
+		newProviderResult(t, "ctx1"),
+		newProviderResult(t, "ctx2"),
+		newProviderResult(t, "ctx3"),
+	}
+	var gotAccept []string
+	ndServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAccept = r.Header.Values("Accept")
+		rw, err := rwriter.New(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		require.True(t, rw.IsND())
+		pw := rwriter.NewProviderResponseWriter(rw)
+		for _, pr := range prs {
+			require.NoError(t, pw.WriteProviderResult(pr))
+		}
+		require.NoError(t, pw.Close())
+	}))
+	defer ndServer.Close()
+	jsonServer, _ := newFindServer(t, 0, http.StatusOK, prs...)
+	notFoundServer, _ := newFindServer(t, 0, http.StatusNotFound)
+	errServer, _ := newFindServer(t, 0, http.StatusInternalServerError)
+
+	mh := test.RandomMultihashes(1)[0]
+
+	for _, u := range []string{ndServer.URL, jsonServer.URL} {
+		c, err := client.New(u)
+		require.NoError(t, err)
+
+		resChan := make(chan model.ProviderResult)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- c.FindAsync(context.Background(), mh, resChan)
+		}()
+		var got []model.ProviderResult
+		for pr := range resChan {
+			got = append(got, pr)
+		}
+		require.NoError(t, <-errChan)
+		require.Len(t, got, len(prs))
+		for i := range prs {
+			require.True(t, prs[i].Equal(got[i]))
+		}
+	}
+	require.Contains(t, gotAccept, "application/x-ndjson")
+
+	c, err := client.New(notFoundServer.URL)
+	require.NoError(t, err)
+	resChan := make(chan model.ProviderResult)
+	require.NoError(t, c.FindAsync(context.Background(), mh, resChan))
+	_, open := <-resChan
+	require.False(t, open)
+
+	c, err = client.New(errServer.URL)
+	require.NoError(t, err)
+	resChan = make(chan model.ProviderResult)
+	require.Error(t, c.FindAsync(context.Background(), mh, resChan))
+}
+
+func TestBinaryEncoding(t *testing.T) {
+	prs := []model.ProviderResult{
+		newProviderResult(t, "ctx1"),
+		newProviderResult(t, "ctx2"),
+	}
+	var gotContentType string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw, err := rwriter.New(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gotContentType = rw.MediaType()
+		pw := rwriter.NewProviderResponseWriter(rw)
+		for _, pr := range prs {
+			require.NoError(t, pw.WriteProviderResult(pr))
+		}
+		require.NoError(t, pw.Close())
+	}))
+	defer ts.Close()
+
+	mh := test.RandomMultihashes(1)[0]
+	c, err := client.New(ts.URL, client.WithBinaryEncoding(true))
+	require.NoError(t, err)
+
+	rsp, err := c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	require.Equal(t, "application/cbor", gotContentType)
+	require.Len(t, rsp.MultihashResults, 1)
+	require.Equal(t, prs, rsp.MultihashResults[0].ProviderResults)
+
+	resChan := make(chan model.ProviderResult)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- c.FindAsync(context.Background(), mh, resChan)
+	}()
+	var got []model.ProviderResult
+	for pr := range resChan {
+		got = append(got, pr)
+	}
+	require.NoError(t, <-errChan)
+	require.Equal(t, "application/vnd.ipni.cbor-stream", gotContentType)
+	require.Equal(t, prs, got)
+
+	// Without binary encoding, JSON is used.
+	c, err = client.New(ts.URL)
+	require.NoError(t, err)
+	rsp, err = c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	require.Equal(t, "application/json", gotContentType)
+	require.Len(t, rsp.MultihashResults, 1)
+}
+
+func TestProblemErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, apierror.AcceptsProblem(r))
+		err := apierror.New(errors.New("too busy"), http.StatusTooManyRequests).WithRetryAfter(30 * time.Second)
+		apierror.WriteError(w, r, err)
+	}))
+	defer ts.Close()
+
+	c, err := client.New(ts.URL)
+	require.NoError(t, err)
+
+	_, err = c.Find(context.Background(), test.RandomMultihashes(1)[0])
+	var apierr *apierror.Error
+	require.ErrorAs(t, err, &apierr)
+	require.Equal(t, "too busy", apierr.Error())
+	require.Equal(t, apierror.CodeRateLimited, apierr.Code())
+	require.Equal(t, 30*time.Second, apierr.RetryAfter())
+
+	_, err = c.GetStats(context.Background())
+	require.ErrorAs(t, err, &apierr)
+	require.Equal(t, http.StatusTooManyRequests, apierr.Status())
+}
@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"sync"
 
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/ipni/go-libipni/dhash"
 	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/metadata"
 	"github.com/ipni/go-libipni/pcache"
+	"github.com/libp2p/go-libp2p/core/peer"
 	b58 "github.com/mr-tron/base58/base58"
 	"github.com/multiformats/go-multihash"
 )
@@ -33,12 +36,30 @@ type DHStoreAPI interface {
 	FindMetadata(context.Context, []byte) ([]byte, error)
 }
 
+// DHStoreBatchAPI is optionally implemented by a DHStoreAPI that is able to
+// look up multiple multihashes in a single request.
+type DHStoreBatchAPI interface {
+	// FindMultihashBatch does a dh-multihash lookup for multiple multihashes
+	// and returns the EncryptedMultihashResults for those that are found.
+	// Returns no data and no error, (nil, nil), if no data found.
+	FindMultihashBatch(context.Context, []multihash.Multihash) ([]model.EncryptedMultihashResult, error)
+}
+
 // DHashClient is a client that does double-hashed lookups on a dhstore. By
 // default, it does multihash and metadata lookups over HTTP. If given a
 // DHStoreAPI, it can do the lookups any the underlying implementation defined.
 type DHashClient struct {
 	dhstoreAPI DHStoreAPI
 	pcache     *pcache.ProviderCache
+
+	mdCache             *metadataCache
+	metadataConcurrency int
+
+	// statsClient gets stats from the source of provider information.
+	statsClient *Client
+
+	metadataCtx     metadata.MetadataContext
+	metadataFilters []model.MetadataFilter
 }
 
 // NewDHashClient instantiates a new client that uses Reader Privacy API for
@@ -87,16 +108,48 @@ func NewDHashClient(options ...Option) (*DHashClient, error) {
 		}
 	}
 
+	statsClient, err := New(opts.providersURLs[0], options...)
+	if err != nil {
+		return nil, err
+	}
+
 	return &DHashClient{
 		dhstoreAPI: dhsAPI,
 		pcache:     pc,
+
+		mdCache:             newMetadataCache(opts.metadataCacheSize),
+		metadataConcurrency: opts.metadataConcurrency,
+
+		statsClient: statsClient,
+
+		metadataCtx:     opts.metadataCtx,
+		metadataFilters: opts.metadataFilters,
 	}, nil
 }
 
+// DHashClient must implement Interface.
+var _ Interface = (*DHashClient)(nil)
+
 func (c *DHashClient) PCache() *pcache.ProviderCache {
 	return c.pcache
 }
 
+// GetProvider gets information about the provider identified by peer.ID from
+// the provider cache. Returns nil if the provider is not found.
+func (c *DHashClient) GetProvider(ctx context.Context, providerID peer.ID) (*model.ProviderInfo, error) {
+	return c.pcache.Get(ctx, providerID)
+}
+
+// ListProviders gets information about all providers in the provider cache.
+func (c *DHashClient) ListProviders(ctx context.Context) ([]*model.ProviderInfo, error) {
+	return c.pcache.List(), nil
+}
+
+// GetStats gets statistics from the first source of provider information.
+func (c *DHashClient) GetStats(ctx context.Context) (*model.Stats, error) {
+	return c.statsClient.GetStats(ctx)
+}
+
 // Find launches FindAsync in a separate go routine and assembles the result
 // into FindResponse as if it was a synchronous invocation.
 func (c *DHashClient) Find(ctx context.Context, mh multihash.Multihash) (*model.FindResponse, error) {
@@ -122,23 +175,109 @@ func (c *DHashClient) Find(ctx context.Context, mh multihash.Multihash) (*model.
 	}, nil
 }
 
+// FindBatch looks up content entries for a batch of multihashes using the
+// double hashed lookup workflow. Multihash lookups are done in a single
+// request if the DHStoreAPI implements DHStoreBatchAPI. Metadata lookups for
+// all multihashes are done concurrently.
+func (c *DHashClient) FindBatch(ctx context.Context, mhs []multihash.Multihash) (*model.FindResponse, error) {
+	if len(mhs) == 0 {
+		return &model.FindResponse{}, nil
+	}
+
+	vks, err := c.findValueKeys(ctx, mhs)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock sync.Mutex
+	resultsByMH := make(map[string][]model.ProviderResult, len(mhs))
+	err = c.resolveValueKeys(ctx, vks, func(mh multihash.Multihash, pr model.ProviderResult) error {
+		lock.Lock()
+		resultsByMH[string(mh)] = append(resultsByMH[string(mh)], pr)
+		lock.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &model.FindResponse{}
+	for _, mh := range mhs {
+		prs, ok := resultsByMH[string(mh)]
+		if !ok {
+			continue
+		}
+		delete(resultsByMH, string(mh))
+		rsp.MultihashResults = append(rsp.MultihashResults, model.MultihashResult{
+			Multihash:       mh,
+			ProviderResults: prs,
+		})
+	}
+	return rsp, nil
+}
+
 // FindAsync implements double hashed lookup workflow. FindAsync returns
 // results on resChan until there are no more results or error. When finished,
 // resChan is closed and the error or nil is returned.
 func (c *DHashClient) FindAsync(ctx context.Context, mh multihash.Multihash, resChan chan<- model.ProviderResult) error {
 	defer close(resChan)
 
-	dhmh, err := dhash.SecondMultihash(mh)
+	vks, err := c.findValueKeys(ctx, []multihash.Multihash{mh})
 	if err != nil {
 		return err
 	}
 
-	encryptedMultihashResults, err := c.dhstoreAPI.FindMultihash(ctx, dhmh)
+	return c.resolveValueKeys(ctx, vks, func(_ multihash.Multihash, pr model.ProviderResult) error {
+		select {
+		case resChan <- pr:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// valueKey is a decrypted value key for a multihash.
+type valueKey struct {
+	mh    multihash.Multihash
+	vk    []byte
+	pid   peer.ID
+	ctxID []byte
+}
+
+// findValueKeys looks up the encrypted value keys for the multihashes, and
+// returns the decrypted value keys.
+func (c *DHashClient) findValueKeys(ctx context.Context, mhs []multihash.Multihash) ([]valueKey, error) {
+	// Map each second multihash back to the original multihash.
+	dhmhs := make([]multihash.Multihash, 0, len(mhs))
+	origMH := make(map[string]multihash.Multihash, len(mhs))
+	for _, mh := range mhs {
+		dhmh, err := dhash.SecondMultihash(mh)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := origMH[string(dhmh)]; ok {
+			continue
+		}
+		origMH[string(dhmh)] = mh
+		dhmhs = append(dhmhs, dhmh)
+	}
+
+	encryptedMultihashResults, err := c.findMultihashes(ctx, dhmhs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var vks []valueKey
 	for _, emhrs := range encryptedMultihashResults {
+		mh, ok := origMH[string(emhrs.Multihash)]
+		if !ok {
+			if len(dhmhs) != 1 {
+				log.Warnw("Ignoring result for unrequested multihash", "multihash", emhrs.Multihash.B58String())
+				continue
+			}
+			mh = mhs[0]
+		}
 		for _, evk := range emhrs.EncryptedValueKeys {
 			vk, err := dhash.DecryptValueKey(evk, mh)
 			// skip errors as we don't want to fail the whole query, warn
@@ -153,47 +292,148 @@ func (c *DHashClient) FindAsync(ctx context.Context, mh multihash.Multihash, res
 				log.Warnw("Error splitting value key", "multihash", mh.B58String(), "evk", b58.Encode(evk), "err", err)
 				continue
 			}
+			vks = append(vks, valueKey{
+				mh:    mh,
+				vk:    vk,
+				pid:   pid,
+				ctxID: ctxId,
+			})
+		}
+	}
+	return vks, nil
+}
 
-			// fetch and decrypt metadata.
-			metadata, err := c.fetchMetadata(ctx, vk)
-			if err != nil {
-				log.Warnw("Error fetching metadata", "multihash", mh.B58String(), "evk", b58.Encode(evk), "err", err)
-				continue
-			}
-			if len(metadata) == 0 {
-				// Metadata not found; multihash has no metadata. This was
-				// probably deleted by context ID and the associated
-				// multihashes were not removed.
-				continue
-			}
+// findMultihashes looks up encrypted value keys for all of the second
+// multihashes. If the DHStoreAPI supports batch lookups, then this is done in
+// a single request, otherwise a separate lookup is done for each multihash.
+func (c *DHashClient) findMultihashes(ctx context.Context, dhmhs []multihash.Multihash) ([]model.EncryptedMultihashResult, error) {
+	if len(dhmhs) == 1 {
+		return c.dhstoreAPI.FindMultihash(ctx, dhmhs[0])
+	}
+	if batchAPI, ok := c.dhstoreAPI.(DHStoreBatchAPI); ok {
+		return batchAPI.FindMultihashBatch(ctx, dhmhs)
+	}
+	return findEachMultihash(ctx, c.dhstoreAPI, dhmhs)
+}
 
-			prs, err := c.pcache.GetResults(ctx, pid, ctxId, metadata)
-			if err != nil {
-				log.Warnw("Error fetching provider infos", "multihash", mh.B58String(), "evk", b58.Encode(evk), "err", err)
-				continue
+// findEachMultihash does a separate lookup for each of the second multihashes.
+func findEachMultihash(ctx context.Context, dhstoreAPI DHStoreAPI, dhmhs []multihash.Multihash) ([]model.EncryptedMultihashResult, error) {
+	var results []model.EncryptedMultihashResult
+	for _, dhmh := range dhmhs {
+		emhrs, err := dhstoreAPI.FindMultihash(ctx, dhmh)
+		if err != nil {
+			return nil, err
+		}
+		for _, emhr := range emhrs {
+			if len(emhr.Multihash) == 0 {
+				emhr.Multihash = dhmh
 			}
+			results = append(results, emhr)
+		}
+	}
+	return results, nil
+}
+
+// resolveValueKeys fetches the metadata and provider information for each
+// value key, and calls yield with each resulting provider result. Metadata is
+// fetched concurrently by a bounded number of workers, so yield may be called
+// concurrently. If yield returns an error, then no more results are resolved
+// and that error is returned.
+func (c *DHashClient) resolveValueKeys(ctx context.Context, vks []valueKey, yield func(multihash.Multihash, model.ProviderResult) error) error {
+	if len(vks) == 0 {
+		return nil
+	}
 
-			for _, pr := range prs {
-				select {
-				case resChan <- pr:
-				case <-ctx.Done():
-					return ctx.Err()
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var yieldErr error
+	var errOnce sync.Once
+
+	jobs := make(chan valueKey)
+	workers := c.metadataConcurrency
+	if workers > len(vks) {
+		workers = len(vks)
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for vk := range jobs {
+				for _, pr := range c.resolveValueKey(workerCtx, vk) {
+					if err := yield(vk.mh, pr); err != nil {
+						errOnce.Do(func() {
+							yieldErr = err
+							cancel()
+						})
+						return
+					}
 				}
 			}
+		}()
+	}
+
+feed:
+	for _, vk := range vks {
+		select {
+		case jobs <- vk:
+		case <-workerCtx.Done():
+			break feed
 		}
 	}
-	return nil
+	close(jobs)
+	wg.Wait()
+
+	if yieldErr != nil {
+		return yieldErr
+	}
+	return ctx.Err()
 }
 
-// fetchMetadata fetches metadata from a remote server using a value-key-hash,
-// and then decrypts the metadata using the value-key.
-func (c *DHashClient) fetchMetadata(ctx context.Context, vk []byte) ([]byte, error) {
-	encryptedMetadata, err := c.dhstoreAPI.FindMetadata(ctx, dhash.SHA256(vk, nil))
+// resolveValueKey fetches the metadata and provider information for a value
+// key, and returns the provider results that match the configured metadata
+// filters. Errors are logged and result in no provider results, so that a
+// single failure does not fail the whole query.
+func (c *DHashClient) resolveValueKey(ctx context.Context, vk valueKey) []model.ProviderResult {
+	// fetch and decrypt metadata.
+	md, err := c.fetchMetadata(ctx, vk.vk)
 	if err != nil {
-		return nil, err
+		if ctx.Err() == nil {
+			log.Warnw("Error fetching metadata", "multihash", vk.mh.B58String(), "provider", vk.pid, "err", err)
+		}
+		return nil
 	}
-	if len(encryptedMetadata) == 0 {
-		return nil, nil
+	if len(md) == 0 {
+		// Metadata not found; multihash has no metadata. This was probably
+		// deleted by context ID and the associated multihashes were not
+		// removed.
+		return nil
 	}
-	return dhash.DecryptMetadata(encryptedMetadata, vk)
+
+	prs, err := c.pcache.GetResults(ctx, vk.pid, vk.ctxID, md)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warnw("Error fetching provider infos", "multihash", vk.mh.B58String(), "provider", vk.pid, "err", err)
+		}
+		return nil
+	}
+	return model.FilterResults(prs, c.metadataCtx, c.metadataFilters...)
+}
+
+// fetchMetadata fetches metadata from a remote server using a value-key-hash,
+// and then decrypts the metadata using the value-key. Decrypted metadata is
+// cached, and concurrent fetches for the same metadata are deduplicated.
+func (c *DHashClient) fetchMetadata(ctx context.Context, vk []byte) ([]byte, error) {
+	hvk := dhash.SHA256(vk, nil)
+	return c.mdCache.get(ctx, hvk, func(ctx context.Context) ([]byte, error) {
+		encryptedMetadata, err := c.dhstoreAPI.FindMetadata(ctx, hvk)
+		if err != nil {
+			return nil, err
+		}
+		if len(encryptedMetadata) == 0 {
+			return nil, nil
+		}
+		return dhash.DecryptMetadata(encryptedMetadata, vk)
+	})
 }
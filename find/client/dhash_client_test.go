@@ -0,0 +1,262 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipni/go-libipni/dhash"
+	"github.com/ipni/go-libipni/find/client"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p/core/peer"
+	b58 "github.com/mr-tron/base58/base58"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDHStore struct {
+	values   map[string][][]byte
+	metadata map[string][]byte
+
+	findCalls     atomic.Int32
+	metadataCalls atomic.Int32
+}
+
+func newMockDHStore() *mockDHStore {
+	return &mockDHStore{
+		values:   make(map[string][][]byte),
+		metadata: make(map[string][]byte),
+	}
+}
+
+func (s *mockDHStore) put(t *testing.T, mh multihash.Multihash, pid peer.ID, ctxID, md []byte) {
+	vk := dhash.CreateValueKey(pid, ctxID)
+	evk, err := dhash.EncryptValueKey(vk, mh)
+	require.NoError(t, err)
+	dhmh, err := dhash.SecondMultihash(mh)
+	require.NoError(t, err)
+	s.values[string(dhmh)] = append(s.values[string(dhmh)], evk)
+	emd, err := dhash.EncryptMetadata(md, vk)
+	require.NoError(t, err)
+	s.metadata[string(dhash.SHA256(vk, nil))] = emd
+}
+
+func (s *mockDHStore) FindMultihash(ctx context.Context, dhmh multihash.Multihash) ([]model.EncryptedMultihashResult, error) {
+	s.findCalls.Add(1)
+	evks, ok := s.values[string(dhmh)]
+	if !ok {
+		return nil, nil
+	}
+	return []model.EncryptedMultihashResult{{
+		Multihash:          dhmh,
+		EncryptedValueKeys: evks,
+	}}, nil
+}
+
+func (s *mockDHStore) FindMetadata(ctx context.Context, hvk []byte) ([]byte, error) {
+	s.metadataCalls.Add(1)
+	// Delay so that concurrent requests for the same metadata overlap.
+	time.Sleep(10 * time.Millisecond)
+	return s.metadata[string(hvk)], nil
+}
+
+type mockBatchDHStore struct {
+	*mockDHStore
+	batchCalls atomic.Int32
+}
+
+func (s *mockBatchDHStore) FindMultihashBatch(ctx context.Context, dhmhs []multihash.Multihash) ([]model.EncryptedMultihashResult, error) {
+	s.batchCalls.Add(1)
+	var results []model.EncryptedMultihashResult
+	for _, dhmh := range dhmhs {
+		if evks, ok := s.values[string(dhmh)]; ok {
+			results = append(results, model.EncryptedMultihashResult{
+				Multihash:          dhmh,
+				EncryptedValueKeys: evks,
+			})
+		}
+	}
+	return results, nil
+}
+
+func newProvidersServer(t *testing.T, pinfos ...*model.ProviderInfo) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/providers" {
+			require.NoError(t, json.NewEncoder(w).Encode(pinfos))
+			return
+		}
+		for _, pinfo := range pinfos {
+			if r.URL.Path == "/providers/"+pinfo.AddrInfo.ID.String() {
+				require.NoError(t, json.NewEncoder(w).Encode(pinfo))
+				return
+			}
+		}
+		http.Error(w, "", http.StatusNotFound)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestDHashClientFindBatch(t *testing.T) {
+	pid, err := peer.Decode(testPeerID)
+	require.NoError(t, err)
+	ps := newProvidersServer(t, &model.ProviderInfo{
+		AddrInfo: peer.AddrInfo{ID: pid},
+	})
+
+	mhs := test.RandomMultihashes(10)
+	dhs := newMockDHStore()
+	// All multihashes share the same value key, and so the same metadata.
+	for _, mh := range mhs[:9] {
+		dhs.put(t, mh, pid, []byte("ctx1"), []byte("metadata1"))
+	}
+
+	c, err := client.NewDHashClient(client.WithProvidersURL(ps.URL), client.WithDHStoreAPI(dhs))
+	require.NoError(t, err)
+
+	resp, err := c.FindBatch(context.Background(), mhs)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 9)
+	for i, mhr := range resp.MultihashResults {
+		require.Equal(t, mhs[i], mhr.Multihash)
+		require.Len(t, mhr.ProviderResults, 1)
+		require.Equal(t, []byte("metadata1"), mhr.ProviderResults[0].Metadata)
+	}
+	// No batch API, so one lookup per multihash.
+	require.Equal(t, int32(10), dhs.findCalls.Load())
+	// Concurrent metadata fetches deduplicated.
+	require.Equal(t, int32(1), dhs.metadataCalls.Load())
+
+	// Metadata is cached.
+	resp, err = c.Find(context.Background(), mhs[0])
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults[0].ProviderResults, 1)
+	require.Equal(t, int32(11), dhs.findCalls.Load())
+	require.Equal(t, int32(1), dhs.metadataCalls.Load())
+
+	bdhs := &mockBatchDHStore{mockDHStore: dhs}
+	c, err = client.NewDHashClient(client.WithProvidersURL(ps.URL), client.WithDHStoreAPI(bdhs),
+		client.WithMetadataCacheSize(0))
+	require.NoError(t, err)
+	resp, err = c.FindBatch(context.Background(), mhs)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 9)
+	require.Equal(t, int32(1), bdhs.batchCalls.Load())
+	require.Equal(t, int32(11), dhs.findCalls.Load())
+
+	// Stats come from the providers URL.
+	_, err = c.GetStats(context.Background())
+	require.Error(t, err)
+
+	pinfo, err := c.GetProvider(context.Background(), pid)
+	require.NoError(t, err)
+	require.Equal(t, pid, pinfo.AddrInfo.ID)
+}
+
+func TestDHStoreHTTPFindBatch(t *testing.T) {
+	pid, err := peer.Decode(testPeerID)
+	require.NoError(t, err)
+	mhs := test.RandomMultihashes(3)
+	dhs := &mockBatchDHStore{mockDHStore: newMockDHStore()}
+	for _, mh := range mhs {
+		dhs.put(t, mh, pid, []byte("ctx1"), []byte("metadata1"))
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/multihash":
+			var req model.FindRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			emhrs, _ := dhs.FindMultihashBatch(r.Context(), req.Multihashes)
+			require.NoError(t, json.NewEncoder(w).Encode(model.FindResponse{EncryptedMultihashResults: emhrs}))
+		case r.URL.Path == "/providers/"+pid.String():
+			require.NoError(t, json.NewEncoder(w).Encode(&model.ProviderInfo{AddrInfo: peer.AddrInfo{ID: pid}}))
+		case strings.HasPrefix(r.URL.Path, "/metadata/"):
+			hvk, err := b58.Decode(strings.TrimPrefix(r.URL.Path, "/metadata/"))
+			require.NoError(t, err)
+			md, _ := dhs.FindMetadata(r.Context(), hvk)
+			require.NoError(t, json.NewEncoder(w).Encode(map[string][]byte{"EncryptedMetadata": md}))
+		default:
+			http.Error(w, "", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := client.NewDHashClient(client.WithDHStoreURL(ts.URL))
+	require.NoError(t, err)
+	resp, err := c.FindBatch(context.Background(), mhs)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 3)
+	require.Equal(t, int32(1), dhs.batchCalls.Load())
+}
+
+func TestDHStoreHTTPFindBatchFallback(t *testing.T) {
+	pid, err := peer.Decode(testPeerID)
+	require.NoError(t, err)
+	mhs := test.RandomMultihashes(3)
+	dhs := newMockDHStore()
+	for _, mh := range mhs[:2] {
+		dhs.put(t, mh, pid, []byte("ctx1"), []byte("metadata1"))
+	}
+
+	var batchStatus atomic.Int32
+	var posts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/multihash":
+			posts.Add(1)
+			http.Error(w, "", int(batchStatus.Load()))
+		case strings.HasPrefix(r.URL.Path, "/multihash/"):
+			dhmh, err := multihash.FromB58String(strings.TrimPrefix(r.URL.Path, "/multihash/"))
+			require.NoError(t, err)
+			emhrs, _ := dhs.FindMultihash(r.Context(), dhmh)
+			if len(emhrs) == 0 {
+				http.Error(w, "", http.StatusNotFound)
+				return
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(model.FindResponse{EncryptedMultihashResults: emhrs}))
+		case r.URL.Path == "/providers/"+pid.String():
+			require.NoError(t, json.NewEncoder(w).Encode(&model.ProviderInfo{AddrInfo: peer.AddrInfo{ID: pid}}))
+		case strings.HasPrefix(r.URL.Path, "/metadata/"):
+			hvk, err := b58.Decode(strings.TrimPrefix(r.URL.Path, "/metadata/"))
+			require.NoError(t, err)
+			md, _ := dhs.FindMetadata(r.Context(), hvk)
+			require.NoError(t, json.NewEncoder(w).Encode(map[string][]byte{"EncryptedMetadata": md}))
+		default:
+			http.Error(w, "", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := client.NewDHashClient(client.WithDHStoreURL(ts.URL))
+	require.NoError(t, err)
+
+	// A 404 from the batch endpoint is checked with separate lookups.
+	batchStatus.Store(http.StatusNotFound)
+	resp, err := c.FindBatch(context.Background(), mhs)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 2)
+	require.Equal(t, int32(1), posts.Load())
+
+	// Batch lookups are not attempted again after a 405.
+	batchStatus.Store(http.StatusMethodNotAllowed)
+	for i := 0; i < 2; i++ {
+		resp, err = c.FindBatch(context.Background(), mhs)
+		require.NoError(t, err)
+		require.Len(t, resp.MultihashResults, 2)
+	}
+	require.Equal(t, int32(2), posts.Load())
+
+	// Other failures are returned.
+	c, err = client.NewDHashClient(client.WithDHStoreURL(ts.URL))
+	require.NoError(t, err)
+	batchStatus.Store(http.StatusInternalServerError)
+	_, err = c.FindBatch(context.Background(), mhs)
+	require.Error(t, err)
+}
@@ -1,11 +1,13 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
+	"sync/atomic"
 
 	"github.com/ipni/go-libipni/apierror"
 	"github.com/ipni/go-libipni/find/model"
@@ -13,10 +15,17 @@ import (
 	"github.com/multiformats/go-multihash"
 )
 
+// dhstoreHTTP must implement DHStoreBatchAPI.
+var _ DHStoreBatchAPI = (*dhstoreHTTP)(nil)
+
 type dhstoreHTTP struct {
 	c             *http.Client
 	dhFindURL     *url.URL
 	dhMetadataURL *url.URL
+
+	// noBatch is set when the dhstore responds in a way that shows it does
+	// not support batch lookups, so that later batches are not attempted.
+	noBatch atomic.Bool
 }
 
 // FindMultihash implements DHStoreAPI. Returns no data and no error if data
@@ -28,24 +37,81 @@ func (d *dhstoreHTTP) FindMultihash(ctx context.Context, dhmh multihash.Multihas
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Accept", apierror.MediaTypeProblem)
 
 	resp, err := d.c.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 
-	if err != nil {
-		return nil, err
-	}
-
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusNotFound {
 			return nil, nil
 		}
-		return nil, apierror.FromResponse(resp.StatusCode, body)
+		return nil, apierror.FromHTTPResponse(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	encResponse := &model.FindResponse{}
+	err = json.Unmarshal(body, encResponse)
+	if err != nil {
+		return nil, err
+	}
+	return encResponse.EncryptedMultihashResults, nil
+}
+
+// FindMultihashBatch implements DHStoreBatchAPI. Returns no data and no error
+// if data not found.
+//
+// An older dhstore that does not support batch lookups may reject the request
+// with a 400, 404, 405, or 501 status. When that happens a separate lookup is
+// done for each multihash instead. A 404 may also mean that nothing was found,
+// which the separate lookups confirm. After a 405 or 501 status, batch lookups
+// are not attempted again.
+func (d *dhstoreHTTP) FindMultihashBatch(ctx context.Context, dhmhs []multihash.Multihash) ([]model.EncryptedMultihashResult, error) {
+	if d.noBatch.Load() {
+		return findEachMultihash(ctx, d, dhmhs)
+	}
+
+	data, err := model.MarshalFindRequest(&model.FindRequest{Multihashes: dhmhs})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.dhFindURL.String(), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Accept", apierror.MediaTypeProblem)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		d.noBatch.Store(true)
+		fallthrough
+	case http.StatusBadRequest, http.StatusNotFound:
+		return findEachMultihash(ctx, d, dhmhs)
+	default:
+		return nil, apierror.FromHTTPResponse(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
 	}
 
 	encResponse := &model.FindResponse{}
@@ -65,24 +131,25 @@ func (d *dhstoreHTTP) FindMetadata(ctx context.Context, hvk []byte) ([]byte, err
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Accept", apierror.MediaTypeProblem)
 
 	resp, err := d.c.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 
-	if err != nil {
-		return nil, err
-	}
-
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusNotFound {
 			return nil, nil
 		}
-		return nil, apierror.FromResponse(resp.StatusCode, body)
+		return nil, apierror.FromHTTPResponse(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
 	}
 
 	type GetMetadataResponse struct {
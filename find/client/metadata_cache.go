@@ -0,0 +1,115 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// metadataCache is a small LRU cache of decrypted metadata, keyed by
+// value-key hash. It also deduplicates concurrent fetches of the same
+// metadata, so that only one fetch is in flight for any value-key hash.
+type metadataCache struct {
+	lock     sync.Mutex
+	cache    map[string]*list.Element
+	ll       *list.List
+	max      int
+	inflight map[string]*metadataFetch
+}
+
+// metadataFetch is a metadata fetch that is in progress.
+type metadataFetch struct {
+	done     chan struct{}
+	metadata []byte
+	err      error
+}
+
+type metadataEntry struct {
+	key      string
+	metadata []byte
+}
+
+func newMetadataCache(maxEntries int) *metadataCache {
+	return &metadataCache{
+		cache:    make(map[string]*list.Element),
+		ll:       list.New(),
+		max:      maxEntries,
+		inflight: make(map[string]*metadataFetch),
+	}
+}
+
+// get returns the cached metadata for the value-key hash. If the metadata is
+// not cached, then it is fetched by calling fetch, unless a fetch for the same
+// value-key hash is already in progress, in which case the result of that
+// fetch is returned. If that fetch is canceled, then the cache is checked
+// again and a new fetch is started, or joined if another caller already
+// started one. Metadata that is not found is not cached.
+func (mc *metadataCache) get(ctx context.Context, hvk []byte, fetch func(context.Context) ([]byte, error)) ([]byte, error) {
+	key := string(hvk)
+
+	for {
+		mc.lock.Lock()
+		if elem, ok := mc.cache[key]; ok {
+			mc.ll.MoveToFront(elem)
+			mc.lock.Unlock()
+			return elem.Value.(*metadataEntry).metadata, nil
+		}
+		f, ok := mc.inflight[key]
+		if !ok {
+			break
+		}
+		mc.lock.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if f.err == nil || !isContextErr(f.err) {
+			return f.metadata, f.err
+		}
+		// The other fetch was canceled, so try again using this context.
+	}
+	f := &metadataFetch{
+		done: make(chan struct{}),
+	}
+	mc.inflight[key] = f
+	mc.lock.Unlock()
+
+	f.metadata, f.err = fetch(ctx)
+
+	mc.lock.Lock()
+	delete(mc.inflight, key)
+	if f.err == nil && len(f.metadata) != 0 {
+		mc.put(key, f.metadata)
+	}
+	mc.lock.Unlock()
+	close(f.done)
+
+	return f.metadata, f.err
+}
+
+// put adds metadata to the cache. Must be called with lock held.
+func (mc *metadataCache) put(key string, metadata []byte) {
+	if mc.max <= 0 {
+		return
+	}
+	if elem, ok := mc.cache[key]; ok {
+		mc.ll.MoveToFront(elem)
+		elem.Value.(*metadataEntry).metadata = metadata
+		return
+	}
+	if mc.ll.Len() >= mc.max {
+		oldest := mc.ll.Back()
+		mc.ll.Remove(oldest)
+		delete(mc.cache, oldest.Value.(*metadataEntry).key)
+	}
+	mc.cache[key] = mc.ll.PushFront(&metadataEntry{
+		key:      key,
+		metadata: metadata,
+	})
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
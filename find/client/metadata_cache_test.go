@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataCacheCanceledFetch(t *testing.T) {
+	mc := newMetadataCache(10)
+	hvk := []byte("hvk")
+
+	// Start a fetch that blocks until it is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := mc.get(ctx, hvk, func(ctx context.Context) ([]byte, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		firstErr <- err
+	}()
+	<-started
+
+	// Wait on the in-flight fetch from other callers.
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func(context.Context) ([]byte, error) {
+		fetches.Add(1)
+		<-release
+		return []byte("metadata"), nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			md, err := mc.get(context.Background(), hvk, fetch)
+			require.NoError(t, err)
+			require.Equal(t, []byte("metadata"), md)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// After the first fetch is canceled, only one of the waiting callers
+	// fetches again and the others wait for it.
+	cancel()
+	require.ErrorIs(t, <-firstErr, context.Canceled)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), fetches.Load())
+
+	// The fetched metadata is cached.
+	md, err := mc.get(context.Background(), hvk, fetch)
+	require.NoError(t, err)
+	require.Equal(t, []byte("metadata"), md)
+	require.Equal(t, int32(1), fetches.Load())
+}
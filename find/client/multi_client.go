@@ -0,0 +1,464 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+)
+
+// latencyWeight is the weight given to the latest latency sample when
+// updating an endpoint's average latency.
+const latencyWeight = 0.2
+
+// MultiClient is a find client that sends requests to multiple indexers. Each
+// request is sent to the healthiest indexer first, and fails over to the
+// others when a request fails. Optionally, requests can be hedged by sending
+// them to the next indexer if the previous is slow to respond, or can be sent
+// to all indexers with their results merged.
+type MultiClient struct {
+	endpoints   []*endpoint
+	failBackoff time.Duration
+	fanOut      bool
+	hedgeDelay  time.Duration
+}
+
+// MultiClient must implement Interface.
+var _ Interface = (*MultiClient)(nil)
+
+// endpoint is an indexer and its health information.
+type endpoint struct {
+	client *Client
+	url    string
+
+	lock      sync.Mutex
+	fails     int
+	downUntil time.Time
+	latency   time.Duration
+}
+
+// NewMultiClient creates a new find client that sends requests to the indexers
+// at the given base URLs.
+func NewMultiClient(baseURLs []string, options ...Option) (*MultiClient, error) {
+	if len(baseURLs) == 0 {
+		return nil, errors.New("no indexer urls")
+	}
+	opts, err := getOpts(options)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]*endpoint, len(baseURLs))
+	for i, u := range baseURLs {
+		c, err := New(u, options...)
+		if err != nil {
+			return nil, err
+		}
+		endpoints[i] = &endpoint{
+			client: c,
+			url:    u,
+		}
+	}
+
+	return &MultiClient{
+		endpoints:   endpoints,
+		failBackoff: opts.failBackoff,
+		fanOut:      opts.fanOut,
+		hedgeDelay:  opts.hedgeDelay,
+	}, nil
+}
+
+// Find looks up content entries by multihash.
+func (c *MultiClient) Find(ctx context.Context, mh multihash.Multihash) (*model.FindResponse, error) {
+	fn := func(ctx context.Context, cl *Client) (*model.FindResponse, error) {
+		return cl.Find(ctx, mh)
+	}
+	if c.fanOut {
+		return fanOutFind(ctx, c, fn)
+	}
+	return hedged(ctx, c, fn, hasResults)
+}
+
+// FindBatch looks up content entries for a batch of multihashes.
+func (c *MultiClient) FindBatch(ctx context.Context, mhs []multihash.Multihash) (*model.FindResponse, error) {
+	if len(mhs) == 0 {
+		return &model.FindResponse{}, nil
+	}
+	fn := func(ctx context.Context, cl *Client) (*model.FindResponse, error) {
+		return cl.FindBatch(ctx, mhs)
+	}
+	if c.fanOut {
+		return fanOutFind(ctx, c, fn)
+	}
+	return hedged(ctx, c, fn, hasResults)
+}
+
+// GetProvider gets information about the provider identified by peer.ID from
+// the healthiest indexer that responds. If that indexer does not know about
+// the provider, then the other indexers are asked.
+func (c *MultiClient) GetProvider(ctx context.Context, providerID peer.ID) (*model.ProviderInfo, error) {
+	return hedged(ctx, c, func(ctx context.Context, cl *Client) (*model.ProviderInfo, error) {
+		return cl.GetProvider(ctx, providerID)
+	}, nil)
+}
+
+// ListProviders gets information about all providers known to all the
+// indexers. If multiple indexers know about the same provider, then the
+// information with the most recent LastAdvertisementTime is returned.
+func (c *MultiClient) ListProviders(ctx context.Context) ([]*model.ProviderInfo, error) {
+	lists, err := fanOut(ctx, c, func(ctx context.Context, cl *Client) ([]*model.ProviderInfo, error) {
+		return cl.ListProviders(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeProviderInfos(lists), nil
+}
+
+// GetStats gets statistics from the healthiest indexer that responds.
+func (c *MultiClient) GetStats(ctx context.Context) (*model.Stats, error) {
+	return hedged(ctx, c, func(ctx context.Context, cl *Client) (*model.Stats, error) {
+		return cl.GetStats(ctx)
+	}, nil)
+}
+
+// ordered returns the endpoints sorted from most to least healthy. Endpoints
+// that have recently failed are placed after those that have not, and then
+// endpoints are ordered by fewest consecutive failures and lowest latency.
+func (c *MultiClient) ordered() []*endpoint {
+	type health struct {
+		ep      *endpoint
+		down    bool
+		fails   int
+		latency time.Duration
+	}
+	now := time.Now()
+	hs := make([]health, len(c.endpoints))
+	for i, ep := range c.endpoints {
+		ep.lock.Lock()
+		hs[i] = health{
+			ep:      ep,
+			down:    now.Before(ep.downUntil),
+			fails:   ep.fails,
+			latency: ep.latency,
+		}
+		ep.lock.Unlock()
+	}
+	sort.SliceStable(hs, func(i, j int) bool {
+		if hs[i].down != hs[j].down {
+			return !hs[i].down
+		}
+		if hs[i].fails != hs[j].fails {
+			return hs[i].fails < hs[j].fails
+		}
+		return hs[i].latency < hs[j].latency
+	})
+	eps := make([]*endpoint, len(hs))
+	for i := range hs {
+		eps[i] = hs[i].ep
+	}
+	return eps
+}
+
+// healthy returns all endpoints that have not recently failed. If all have
+// recently failed, then all endpoints are returned.
+func (c *MultiClient) healthy() []*endpoint {
+	eps := c.ordered()
+	now := time.Now()
+	for i, ep := range eps {
+		ep.lock.Lock()
+		down := now.Before(ep.downUntil)
+		ep.lock.Unlock()
+		if down {
+			if i == 0 {
+				return eps
+			}
+			return eps[:i]
+		}
+	}
+	return eps
+}
+
+// record updates the health of an endpoint using the outcome of a request.
+// An error response caused by the request, such as not found, does not count
+// as a failure since the indexer is working.
+func (ep *endpoint) record(elapsed time.Duration, err error, backoff time.Duration) {
+	ep.lock.Lock()
+	defer ep.lock.Unlock()
+
+	if err != nil && !requestError(err) {
+		ep.fails++
+		ep.downUntil = time.Now().Add(backoff)
+		return
+	}
+	ep.fails = 0
+	ep.downUntil = time.Time{}
+	if ep.latency == 0 {
+		ep.latency = elapsed
+	} else {
+		ep.latency += time.Duration(latencyWeight * float64(elapsed-ep.latency))
+	}
+}
+
+// requestError returns true if err is a 4xx response, other than 429, that
+// means the indexer could not satisfy the request rather than that the
+// indexer is unhealthy.
+func requestError(err error) bool {
+	status := errorStatus(err)
+	return status >= 400 && status < 500 && status != http.StatusTooManyRequests
+}
+
+// errorStatus returns the HTTP status of an apierror.Error, or 0 if err is
+// not an apierror.Error.
+func errorStatus(err error) int {
+	var apierr *apierror.Error
+	if !errors.As(err, &apierr) {
+		return 0
+	}
+	return apierr.Status()
+}
+
+// hasResults returns true if a find response contains any results.
+func hasResults(resp *model.FindResponse) bool {
+	return resp != nil && (len(resp.MultihashResults) != 0 || len(resp.EncryptedMultihashResults) != 0)
+}
+
+// call calls fn with the endpoint's client and records the outcome, unless
+// the request was canceled by the caller.
+func call[T any](ctx context.Context, c *MultiClient, ep *endpoint, fn func(context.Context, *Client) (T, error)) (T, error) {
+	start := time.Now()
+	val, err := fn(ctx, ep.client)
+	if ctx.Err() == nil {
+		ep.record(time.Since(start), err, c.failBackoff)
+		if err != nil && !requestError(err) {
+			log.Warnw("Indexer request failed", "indexer", ep.url, "err", err)
+		}
+	}
+	return val, err
+}
+
+// hedged sends the request to endpoints in order of health until one
+// succeeds. If the client has a hedge delay, then the request is also sent to
+// the next endpoint each time the delay elapses without a response.
+//
+// A not found response, either a 404 error or a value for which found returns
+// false, is not a failure, but the other endpoints are still tried since they
+// may have what the first did not. The not found response is returned if no
+// endpoint finds anything. Other 4xx errors are returned without trying other
+// endpoints. If found is nil, then every value is found.
+func hedged[T any](ctx context.Context, c *MultiClient, fn func(context.Context, *Client) (T, error), found func(T) bool) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		val T
+		err error
+	}
+
+	eps := c.ordered()
+	results := make(chan result, len(eps))
+	var next, pending int
+	launch := func() {
+		ep := eps[next]
+		next++
+		pending++
+		go func() {
+			val, err := call(ctx, c, ep, fn)
+			results <- result{val, err}
+		}()
+	}
+
+	var hedgeTimer *time.Timer
+	if c.hedgeDelay != 0 {
+		hedgeTimer = time.NewTimer(c.hedgeDelay)
+		defer hedgeTimer.Stop()
+	}
+
+	var errs error
+	var notFound *result
+	launch()
+	for pending != 0 {
+		var hedgeC <-chan time.Time
+		if hedgeTimer != nil && next < len(eps) {
+			hedgeC = hedgeTimer.C
+		}
+		select {
+		case r := <-results:
+			pending--
+			switch {
+			case r.err == nil && (found == nil || found(r.val)):
+				return r.val, nil
+			case r.err == nil || errorStatus(r.err) == http.StatusNotFound:
+				if notFound == nil || notFound.err != nil {
+					notFound = &r
+				}
+			case requestError(r.err):
+				return r.val, r.err
+			default:
+				errs = multierror.Append(errs, r.err)
+			}
+			// Failover to the next endpoint.
+			if next < len(eps) {
+				launch()
+			}
+		case <-hedgeC:
+			launch()
+			hedgeTimer.Reset(c.hedgeDelay)
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+	if notFound != nil {
+		return notFound.val, notFound.err
+	}
+	var zero T
+	return zero, errs
+}
+
+// fanOut sends the request to all healthy endpoints concurrently and returns
+// the successful results. An error is only returned if all requests fail.
+func fanOut[T any](ctx context.Context, c *MultiClient, fn func(context.Context, *Client) (T, error)) ([]T, error) {
+	eps := c.healthy()
+	vals := make([]T, len(eps))
+	errs := make([]error, len(eps))
+	var wg sync.WaitGroup
+	for i, ep := range eps {
+		wg.Add(1)
+		go func(i int, ep *endpoint) {
+			defer wg.Done()
+			vals[i], errs[i] = call(ctx, c, ep, fn)
+		}(i, ep)
+	}
+	wg.Wait()
+
+	var merr error
+	results := make([]T, 0, len(eps))
+	for i := range eps {
+		if errs[i] != nil {
+			merr = multierror.Append(merr, errs[i])
+			continue
+		}
+		results = append(results, vals[i])
+	}
+	if len(results) == 0 {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, merr
+	}
+	return results, nil
+}
+
+func fanOutFind(ctx context.Context, c *MultiClient, fn func(context.Context, *Client) (*model.FindResponse, error)) (*model.FindResponse, error) {
+	resps, err := fanOut(ctx, c, fn)
+	if err != nil {
+		return nil, err
+	}
+	return MergeFindResponses(resps...), nil
+}
+
+// MergeFindResponses combines multiple find responses into one. Results for
+// the same multihash are combined, and duplicate provider results, as
+// determined by ProviderResult.Equal, are removed.
+func MergeFindResponses(resps ...*model.FindResponse) *model.FindResponse {
+	merged := &model.FindResponse{}
+	mhrIndex := make(map[string]int)
+	emhrIndex := make(map[string]int)
+	for _, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		for _, mhr := range resp.MultihashResults {
+			i, ok := mhrIndex[string(mhr.Multihash)]
+			if !ok {
+				mhrIndex[string(mhr.Multihash)] = len(merged.MultihashResults)
+				merged.MultihashResults = append(merged.MultihashResults, model.MultihashResult{
+					Multihash: mhr.Multihash,
+				})
+				i = len(merged.MultihashResults) - 1
+			}
+			target := &merged.MultihashResults[i]
+			for _, pr := range mhr.ProviderResults {
+				if !containsResult(target.ProviderResults, pr) {
+					target.ProviderResults = append(target.ProviderResults, pr)
+				}
+			}
+		}
+		for _, emhr := range resp.EncryptedMultihashResults {
+			i, ok := emhrIndex[string(emhr.Multihash)]
+			if !ok {
+				emhrIndex[string(emhr.Multihash)] = len(merged.EncryptedMultihashResults)
+				merged.EncryptedMultihashResults = append(merged.EncryptedMultihashResults, model.EncryptedMultihashResult{
+					Multihash: emhr.Multihash,
+				})
+				i = len(merged.EncryptedMultihashResults) - 1
+			}
+			target := &merged.EncryptedMultihashResults[i]
+		nextEVK:
+			for _, evk := range emhr.EncryptedValueKeys {
+				for _, have := range target.EncryptedValueKeys {
+					if bytes.Equal(evk, have) {
+						continue nextEVK
+					}
+				}
+				target.EncryptedValueKeys = append(target.EncryptedValueKeys, evk)
+			}
+		}
+	}
+	return merged
+}
+
+func containsResult(prs []model.ProviderResult, pr model.ProviderResult) bool {
+	for _, have := range prs {
+		if have.Provider == nil || pr.Provider == nil {
+			if have.Provider == pr.Provider && bytes.Equal(have.ContextID, pr.ContextID) && bytes.Equal(have.Metadata, pr.Metadata) {
+				return true
+			}
+			continue
+		}
+		if have.Equal(pr) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeProviderInfos combines lists of provider information, keeping the
+// information with the most recent LastAdvertisementTime for each provider.
+func mergeProviderInfos(lists [][]*model.ProviderInfo) []*model.ProviderInfo {
+	latest := make(map[peer.ID]*model.ProviderInfo)
+	var order []peer.ID
+	for _, list := range lists {
+		for _, pinfo := range list {
+			pid := pinfo.AddrInfo.ID
+			have, ok := latest[pid]
+			if !ok {
+				order = append(order, pid)
+			} else if !newerInfo(pinfo, have) {
+				continue
+			}
+			latest[pid] = pinfo
+		}
+	}
+	merged := make([]*model.ProviderInfo, len(order))
+	for i, pid := range order {
+		merged[i] = latest[pid]
+	}
+	return merged
+}
+
+func newerInfo(a, b *model.ProviderInfo) bool {
+	aTime, _ := time.Parse(time.RFC3339, a.LastAdvertisementTime)
+	bTime, _ := time.Parse(time.RFC3339, b.LastAdvertisementTime)
+	return aTime.After(bTime)
+}
@@ -0,0 +1,183 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/find/client"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+const testPeerID = "12D3KooWKRyzVWW6ChFjQjK4miCty85Niy48tpPV95XdKu1BcvMA"
+
+func newFindServer(t *testing.T, delay time.Duration, status int, results ...model.ProviderResult) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if delay != 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if status != http.StatusOK {
+			http.Error(w, "", status)
+			return
+		}
+		mh, err := multihash.FromB58String(r.URL.Path[len("/multihash/"):])
+		require.NoError(t, err)
+		data, err := model.MarshalFindResponse(&model.FindResponse{
+			MultihashResults: []model.MultihashResult{{
+				Multihash:       mh,
+				ProviderResults: results,
+			}},
+		})
+		require.NoError(t, err)
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &calls
+}
+
+func newProviderResult(t *testing.T, ctxID string) model.ProviderResult {
+	pid, err := peer.Decode(testPeerID)
+	require.NoError(t, err)
+	return model.ProviderResult{
+		ContextID: []byte(ctxID),
+		Metadata:  []byte("test-metadata"),
+		Provider:  &peer.AddrInfo{ID: pid},
+	}
+}
+
+func TestMultiClientFailover(t *testing.T) {
+	mh := test.RandomMultihashes(1)[0]
+	pr := newProviderResult(t, "ctx1")
+	bad, badCalls := newFindServer(t, 0, http.StatusInternalServerError)
+	good, goodCalls := newFindServer(t, 0, http.StatusOK, pr)
+
+	c, err := client.NewMultiClient([]string{bad.URL, good.URL}, client.WithFailBackoff(time.Minute))
+	require.NoError(t, err)
+
+	resp, err := c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 1)
+	require.Len(t, resp.MultihashResults[0].ProviderResults, 1)
+	require.Equal(t, int32(1), badCalls.Load())
+	require.Equal(t, int32(1), goodCalls.Load())
+
+	// Failed indexer should now be tried last.
+	_, err = c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), badCalls.Load())
+	require.Equal(t, int32(2), goodCalls.Load())
+
+	// All indexers fail.
+	c, err = client.NewMultiClient([]string{bad.URL, bad.URL})
+	require.NoError(t, err)
+	_, err = c.Find(context.Background(), mh)
+	require.Error(t, err)
+
+	_, err = client.NewMultiClient([]string{good.URL}, client.WithFailBackoff(-time.Second))
+	require.Error(t, err)
+}
+
+func TestMultiClientHedged(t *testing.T) {
+	mh := test.RandomMultihashes(1)[0]
+	pr := newProviderResult(t, "ctx1")
+	slow, slowCalls := newFindServer(t, 5*time.Second, http.StatusOK, pr)
+	fast, fastCalls := newFindServer(t, 0, http.StatusOK, pr)
+
+	c, err := client.NewMultiClient([]string{slow.URL, fast.URL}, client.WithHedgeDelay(50*time.Millisecond))
+	require.NoError(t, err)
+
+	start := time.Now()
+	resp, err := c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Len(t, resp.MultihashResults, 1)
+	require.Equal(t, int32(1), slowCalls.Load())
+	require.Equal(t, int32(1), fastCalls.Load())
+}
+
+func TestMultiClientFanOut(t *testing.T) {
+	mh := test.RandomMultihashes(1)[0]
+	pr1 := newProviderResult(t, "ctx1")
+	pr2 := newProviderResult(t, "ctx2")
+	ts1, _ := newFindServer(t, 0, http.StatusOK, pr1)
+	ts2, _ := newFindServer(t, 0, http.StatusOK, pr1, pr2)
+	ts3, _ := newFindServer(t, 0, http.StatusNotFound)
+	ts4, _ := newFindServer(t, 0, http.StatusInternalServerError)
+
+	c, err := client.NewMultiClient([]string{ts1.URL, ts2.URL, ts3.URL, ts4.URL}, client.WithFanOut(true))
+	require.NoError(t, err)
+
+	resp, err := c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 1)
+	prs := resp.MultihashResults[0].ProviderResults
+	require.Len(t, prs, 2)
+	require.True(t, prs[0].Equal(pr1) || prs[1].Equal(pr1))
+	require.True(t, prs[0].Equal(pr2) || prs[1].Equal(pr2))
+}
+
+func TestMultiClientNotFound(t *testing.T) {
+	mh := test.RandomMultihashes(1)[0]
+	pr := newProviderResult(t, "ctx1")
+	missing, missingCalls := newFindServer(t, 0, http.StatusNotFound)
+	good, goodCalls := newFindServer(t, 0, http.StatusOK, pr)
+
+	c, err := client.NewMultiClient([]string{missing.URL, good.URL}, client.WithFailBackoff(time.Minute))
+	require.NoError(t, err)
+
+	// The indexer that does not have the multihash is not a failure, but the
+	// other indexer is still asked.
+	resp, err := c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 1)
+	require.Equal(t, int32(1), missingCalls.Load())
+	require.Equal(t, int32(1), goodCalls.Load())
+
+	// Nothing found by any indexer.
+	c, err = client.NewMultiClient([]string{missing.URL, missing.URL})
+	require.NoError(t, err)
+	resp, err = c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	require.Empty(t, resp.MultihashResults)
+
+	// An unknown provider is looked up at every indexer and reported as not
+	// found.
+	pid, err := peer.Decode(testPeerID)
+	require.NoError(t, err)
+	_, err = c.GetProvider(context.Background(), pid)
+	var apierr *apierror.Error
+	require.ErrorAs(t, err, &apierr)
+	require.Equal(t, http.StatusNotFound, apierr.Status())
+	require.Equal(t, int32(5), missingCalls.Load())
+
+	// A bad request is not retried at other indexers and does not take the
+	// indexer out of rotation.
+	slow, slowCalls := newFindServer(t, 50*time.Millisecond, http.StatusOK, pr)
+	bad, badCalls := newFindServer(t, 0, http.StatusBadRequest)
+	c, err = client.NewMultiClient([]string{slow.URL, bad.URL}, client.WithFailBackoff(time.Minute))
+	require.NoError(t, err)
+	// Give the slow indexer a higher latency than the bad one will have.
+	_, err = c.Find(context.Background(), mh)
+	require.NoError(t, err)
+	for i := 1; i <= 2; i++ {
+		_, err = c.Find(context.Background(), mh)
+		require.ErrorAs(t, err, &apierr)
+		require.Equal(t, http.StatusBadRequest, apierr.Status())
+		require.Equal(t, int32(i), badCalls.Load())
+		require.Equal(t, int32(1), slowCalls.Load())
+	}
+}
@@ -1,23 +1,47 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/metadata"
 )
 
 const (
 	// defaultPcacheTTL is the default time to live for provider info cache.
 	defaultPcacheTTL = 5 * time.Minute
+	// defaultFailBackoff is the default time that an indexer endpoint is
+	// considered unhealthy after a failed request.
+	defaultFailBackoff = 30 * time.Second
+	// defaultMetadataCacheSize is the default number of decrypted metadata
+	// entries cached by a DHashClient.
+	defaultMetadataCacheSize = 1024
+	// defaultMetadataConcurrency is the default maximum number of concurrent
+	// metadata lookups done by a DHashClient for a single find.
+	defaultMetadataConcurrency = 8
 )
 
 type config struct {
+	binary        bool
 	httpClient    *http.Client
 	providersURLs []string
 	dhstoreURL    string
 	dhstoreAPI    DHStoreAPI
 	pcacheTTL     time.Duration
 	preload       bool
+
+	metadataCacheSize   int
+	metadataConcurrency int
+
+	failBackoff time.Duration
+	fanOut      bool
+	hedgeDelay  time.Duration
+
+	metadataCtx     metadata.MetadataContext
+	metadataFilters []model.MetadataFilter
 }
 
 // Option is a function that sets a value in a config.
@@ -26,8 +50,12 @@ type Option func(*config) error
 // getOpts creates a config and applies Options to it.
 func getOpts(opts []Option) (config, error) {
 	cfg := config{
-		httpClient: http.DefaultClient,
-		pcacheTTL:  defaultPcacheTTL,
+		httpClient:  http.DefaultClient,
+		pcacheTTL:   defaultPcacheTTL,
+		failBackoff: defaultFailBackoff,
+
+		metadataCacheSize:   defaultMetadataCacheSize,
+		metadataConcurrency: defaultMetadataConcurrency,
 	}
 	for i, opt := range opts {
 		if err := opt(&cfg); err != nil {
@@ -48,6 +76,19 @@ func WithClient(c *http.Client) Option {
 	}
 }
 
+// WithBinaryEncoding configures a Client to request find responses encoded
+// as dag-cbor, and streamed results encoded as length-prefixed dag-cbor. This
+// is more compact than JSON for responses with many results. JSON is still
+// accepted from servers that do not support binary encodings.
+//
+// Default is false (disabled).
+func WithBinaryEncoding(enable bool) Option {
+	return func(cfg *config) error {
+		cfg.binary = enable
+		return nil
+	}
+}
+
 // WithProvidersURL specifies one or more URLs for retrieving provider
 // information (/providers and /providers/<pid> endpoints). Multiple URLs may
 // be given to specify multiple sources of provider information,
@@ -98,3 +139,95 @@ func WithPcachePreload(preload bool) Option {
 		return nil
 	}
 }
+
+// WithMetadataCacheSize sets the maximum number of decrypted metadata entries
+// that a DHashClient keeps in its local cache. A value of 0 disables caching.
+//
+// Default is 1024.
+func WithMetadataCacheSize(size int) Option {
+	return func(cfg *config) error {
+		cfg.metadataCacheSize = size
+		return nil
+	}
+}
+
+// WithMetadataConcurrency sets the maximum number of concurrent metadata
+// lookups that a DHashClient does for a single Find or FindBatch.
+//
+// Default is 8.
+func WithMetadataConcurrency(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return errors.New("metadata concurrency must be at least 1")
+		}
+		cfg.metadataConcurrency = n
+		return nil
+	}
+}
+
+// WithHedgeDelay sets the time that a MultiClient waits for a response from
+// one indexer before sending the same request to the next indexer. The first
+// successful response is used and the other requests are canceled. A value of
+// 0 disables hedged requests, so that the next indexer is only tried after the
+// previous one fails.
+//
+// Default is 0 (disabled).
+func WithHedgeDelay(delay time.Duration) Option {
+	return func(cfg *config) error {
+		if delay < 0 {
+			return errors.New("hedge delay cannot be negative")
+		}
+		cfg.hedgeDelay = delay
+		return nil
+	}
+}
+
+// WithFanOut configures a MultiClient to send Find and FindBatch requests to
+// all healthy indexers concurrently, and merge the results. Duplicate provider
+// results are removed. If not enabled, then find requests are sent to the
+// healthiest indexer and failover to others only on error.
+//
+// Default is false (disabled).
+func WithFanOut(fanOut bool) Option {
+	return func(cfg *config) error {
+		cfg.fanOut = fanOut
+		return nil
+	}
+}
+
+// WithFailBackoff sets the time that a MultiClient avoids using an indexer
+// after a request to that indexer fails. An unhealthy indexer is still used if
+// no healthy indexers remain.
+//
+// Default is 30 seconds.
+func WithFailBackoff(backoff time.Duration) Option {
+	return func(cfg *config) error {
+		if backoff < 0 {
+			return errors.New("fail backoff cannot be negative")
+		}
+		cfg.failBackoff = backoff
+		return nil
+	}
+}
+
+// WithMetadataContext sets the metadata context used to decode provider result
+// metadata when applying metadata filters. Protocols not known to the context
+// are decoded as metadata.Unknown.
+//
+// Default is metadata.Default.
+func WithMetadataContext(mctx metadata.MetadataContext) Option {
+	return func(cfg *config) error {
+		cfg.metadataCtx = mctx
+		return nil
+	}
+}
+
+// WithMetadataFilter adds one or more filters that provider results must match
+// to be returned from a find. Results with metadata that cannot be decoded are
+// not returned when any filters are configured.
+func WithMetadataFilter(filters ...model.MetadataFilter) Option {
+	return func(cfg *config) error {
+		cfg.metadataFilters = append(cfg.metadataFilters, filters...)
+		return nil
+	}
+}
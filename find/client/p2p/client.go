@@ -0,0 +1,178 @@
+// Package p2pfindclient provides a find client that sends requests to an
+// indexer over libp2p instead of HTTP.
+//
+// Each request is a p2pclient pb.Message whose type is one of the message
+// types defined in this package. An indexer handles these requests using a
+// p2pserver.Server that registers handlers for the message types on
+// ProtocolID.
+//
+// The request and response data carried in the envelope are the protobuf
+// messages defined in the pb subpackage. The functions in this package that
+// convert between those messages and the find/model types let an indexer
+// serve the same model values over libp2p as over HTTP.
+package p2pfindclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/find/client"
+	"github.com/ipni/go-libipni/find/client/p2p/pb"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/p2pclient"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multihash"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtocolID is the libp2p protocol that an indexer handles find requests on.
+const ProtocolID = protocol.ID("/ipni/find/1.0.0")
+
+// Message types of find requests. The response to each request has the same
+// type as the request.
+const (
+	// FindMessageType is a request to find the providers of multihashes. The
+	// request data is a pb.FindRequest, and the response data is a
+	// pb.FindResponse.
+	FindMessageType uint32 = iota + 1
+	// GetProviderMessageType is a request for information about a provider.
+	// The request data is a pb.GetProviderRequest, and the response data is a
+	// pb.GetProviderResponse.
+	GetProviderMessageType
+	// ListProvidersMessageType is a request for information about all
+	// providers. The request data is a pb.ListProvidersRequest, and the
+	// response data is a pb.ListProvidersResponse.
+	ListProvidersMessageType
+	// GetStatsMessageType is a request for indexer statistics. The request
+	// data is a pb.GetStatsRequest, and the response data is a
+	// pb.GetStatsResponse.
+	GetStatsMessageType
+)
+
+// Client is a libp2p client for the indexer find API.
+type Client struct {
+	p2pc *p2pclient.Client
+}
+
+// Client must implement client.Interface.
+var _ client.Interface = (*Client)(nil)
+
+// New creates a new find client that sends requests to the indexer identified
+// by peerID. If host is nil, then one is created.
+func New(p2pHost host.Host, peerID peer.ID, options ...p2pclient.Option) (*Client, error) {
+	p2pc, err := p2pclient.New(p2pHost, peerID, ProtocolID, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		p2pc: p2pc,
+	}, nil
+}
+
+// Connect connects the client to the indexer at the location specified by
+// hostname. The value of hostname is a host or host:port, where the host is a
+// hostname or IP address.
+func (c *Client) Connect(ctx context.Context, hostname string) error {
+	return c.p2pc.Connect(ctx, hostname)
+}
+
+// ConnectAddrs connects the client to the indexer at any of the given
+// multiaddrs.
+func (c *Client) ConnectAddrs(ctx context.Context, maddrs ...multiaddr.Multiaddr) error {
+	return c.p2pc.ConnectAddrs(ctx, maddrs...)
+}
+
+// Close closes the client's streams to the indexer.
+func (c *Client) Close() error {
+	return c.p2pc.Close()
+}
+
+// Find looks up content entries by multihash.
+func (c *Client) Find(ctx context.Context, m multihash.Multihash) (*model.FindResponse, error) {
+	return c.FindBatch(ctx, []multihash.Multihash{m})
+}
+
+// FindBatch looks up content entries for a batch of multihashes.
+func (c *Client) FindBatch(ctx context.Context, mhs []multihash.Multihash) (*model.FindResponse, error) {
+	if len(mhs) == 0 {
+		return &model.FindResponse{}, nil
+	}
+	var rsp pb.FindResponse
+	err := c.call(ctx, FindMessageType, FindRequestToPB(&model.FindRequest{Multihashes: mhs}), &rsp)
+	if err != nil {
+		var apierr *apierror.Error
+		if errors.As(err, &apierr) && apierr.Status() == http.StatusNotFound {
+			return &model.FindResponse{}, nil
+		}
+		return nil, err
+	}
+	return FindResponseFromPB(&rsp)
+}
+
+// GetProvider gets information about the provider identified by providerID.
+func (c *Client) GetProvider(ctx context.Context, providerID peer.ID) (*model.ProviderInfo, error) {
+	req := &pb.GetProviderRequest{
+		ProviderId: []byte(providerID),
+	}
+	var rsp pb.GetProviderResponse
+	if err := c.call(ctx, GetProviderMessageType, req, &rsp); err != nil {
+		return nil, err
+	}
+	if rsp.GetProvider() == nil {
+		return nil, errors.New("response is missing provider info")
+	}
+	return ProviderInfoFromPB(rsp.Provider)
+}
+
+// ListProviders gets information about all providers known to the indexer.
+func (c *Client) ListProviders(ctx context.Context) ([]*model.ProviderInfo, error) {
+	var rsp pb.ListProvidersResponse
+	if err := c.call(ctx, ListProvidersMessageType, &pb.ListProvidersRequest{}, &rsp); err != nil {
+		return nil, err
+	}
+	providers := make([]*model.ProviderInfo, len(rsp.GetProviders()))
+	for i, pbInfo := range rsp.GetProviders() {
+		info, err := ProviderInfoFromPB(pbInfo)
+		if err != nil {
+			return nil, err
+		}
+		providers[i] = info
+	}
+	return providers, nil
+}
+
+// GetStats gets statistics for the indexer.
+func (c *Client) GetStats(ctx context.Context) (*model.Stats, error) {
+	var rsp pb.GetStatsResponse
+	if err := c.call(ctx, GetStatsMessageType, &pb.GetStatsRequest{}, &rsp); err != nil {
+		return nil, err
+	}
+	return StatsFromPB(&rsp), nil
+}
+
+// call sends a request and decodes the response data into rsp, checking that
+// the response has the same type as the request. Find requests do not change
+// anything at the indexer, so they are sent as idempotent requests.
+func (c *Client) call(ctx context.Context, msgType uint32, req, rsp proto.Message) error {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	msg, err := c.p2pc.CallIdempotent(ctx, msgType, data)
+	if err != nil {
+		return err
+	}
+	if msg.GetType() != msgType {
+		return fmt.Errorf("response message type %d does not match request type %d", msg.GetType(), msgType)
+	}
+	if err = proto.Unmarshal(msg.GetData(), rsp); err != nil {
+		return fmt.Errorf("cannot decode response: %w", err)
+	}
+	return nil
+}
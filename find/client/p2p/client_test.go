@@ -0,0 +1,159 @@
+package p2pfindclient_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ipni/go-libipni/apierror"
+	p2pfindclient "github.com/ipni/go-libipni/find/client/p2p"
+	findpb "github.com/ipni/go-libipni/find/client/p2p/pb"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/p2pclient/pb"
+	"github.com/ipni/go-libipni/p2pserver"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestClient(t *testing.T) {
+	mhs := test.RandomMultihashes(2)
+	providerID, _, _ := test.RandomIdentity()
+	info := &model.ProviderInfo{
+		AddrInfo: peer.AddrInfo{
+			ID:    providerID,
+			Addrs: test.RandomMultiaddrs(1),
+		},
+		LastAdvertisement: test.RandomCids(1)[0],
+	}
+	result := model.ProviderResult{
+		ContextID: []byte("ctx-id"),
+		Metadata:  []byte("metadata"),
+		Provider:  &info.AddrInfo,
+	}
+
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	defer h.Close()
+	s, err := p2pserver.New(h, p2pfindclient.ProtocolID)
+	require.NoError(t, err)
+	defer s.Close()
+
+	err = s.Handle(p2pfindclient.FindMessageType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		var pbReq findpb.FindRequest
+		if err := proto.Unmarshal(msg.Data, &pbReq); err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		req, err := p2pfindclient.FindRequestFromPB(&pbReq)
+		if err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		var resp model.FindResponse
+		for _, mh := range req.Multihashes {
+			if bytes.Equal(mh, mhs[0]) {
+				resp.MultihashResults = append(resp.MultihashResults, model.MultihashResult{
+					Multihash:       mh,
+					ProviderResults: []model.ProviderResult{result},
+				})
+			}
+		}
+		if len(resp.MultihashResults) == 0 {
+			return nil, apierror.New(nil, http.StatusNotFound)
+		}
+		data, err := proto.Marshal(p2pfindclient.FindResponseToPB(&resp))
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Message{Type: msg.Type, Data: data}, nil
+	})
+	require.NoError(t, err)
+	err = s.Handle(p2pfindclient.GetProviderMessageType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		var pbReq findpb.GetProviderRequest
+		if err := proto.Unmarshal(msg.Data, &pbReq); err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		pid, err := peer.IDFromBytes(pbReq.ProviderId)
+		if err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		if pid != providerID {
+			return nil, apierror.New(errors.New("provider not found"), http.StatusNotFound)
+		}
+		data, err := proto.Marshal(&findpb.GetProviderResponse{
+			Provider: p2pfindclient.ProviderInfoToPB(info),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Message{Type: msg.Type, Data: data}, nil
+	})
+	require.NoError(t, err)
+	err = s.Handle(p2pfindclient.ListProvidersMessageType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		data, err := proto.Marshal(&findpb.ListProvidersResponse{
+			Providers: []*findpb.ProviderInfo{p2pfindclient.ProviderInfoToPB(info)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Message{Type: msg.Type, Data: data}, nil
+	})
+	require.NoError(t, err)
+	err = s.Handle(p2pfindclient.GetStatsMessageType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		data, err := proto.Marshal(p2pfindclient.StatsToPB(&model.Stats{EntriesEstimate: 40, EntriesCount: 42}))
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Message{Type: msg.Type, Data: data}, nil
+	})
+	require.NoError(t, err)
+
+	c, err := p2pfindclient.New(nil, h.ID())
+	require.NoError(t, err)
+	defer c.Close()
+	ctx := context.Background()
+	require.NoError(t, c.ConnectAddrs(ctx, h.Addrs()...))
+
+	resp, err := c.Find(ctx, mhs[0])
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 1)
+	require.Equal(t, mhs[0], resp.MultihashResults[0].Multihash)
+	require.Len(t, resp.MultihashResults[0].ProviderResults, 1)
+	require.True(t, result.Equal(resp.MultihashResults[0].ProviderResults[0]))
+
+	resp, err = c.FindBatch(ctx, []multihash.Multihash{mhs[1], mhs[0]})
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 1)
+
+	// Not found is an empty response, not an error.
+	resp, err = c.Find(ctx, mhs[1])
+	require.NoError(t, err)
+	require.Empty(t, resp.MultihashResults)
+
+	pinfo, err := c.GetProvider(ctx, providerID)
+	require.NoError(t, err)
+	require.Equal(t, info, pinfo)
+
+	otherID, _, _ := test.RandomIdentity()
+	_, err = c.GetProvider(ctx, otherID)
+	require.True(t, apierror.IsCode(err, apierror.CodeNotFound))
+
+	providers, err := c.ListProviders(ctx)
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+	require.Equal(t, info, providers[0])
+
+	stats, err := c.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), stats.EntriesEstimate)
+	require.Equal(t, int64(42), stats.EntriesCount)
+
+	// A request that the indexer does not handle returns an error.
+	require.NoError(t, s.Handle(p2pfindclient.GetStatsMessageType, nil))
+	_, err = c.GetStats(ctx)
+	require.True(t, apierror.IsCode(err, apierror.CodeNotImplemented))
+}
@@ -0,0 +1,310 @@
+package p2pfindclient
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipni/go-libipni/find/client/p2p/pb"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multihash"
+)
+
+// FindRequestToPB converts a model.FindRequest to its protobuf message.
+func FindRequestToPB(req *model.FindRequest) *pb.FindRequest {
+	pbReq := &pb.FindRequest{
+		Multihashes: make([][]byte, len(req.Multihashes)),
+	}
+	for i, mh := range req.Multihashes {
+		pbReq.Multihashes[i] = mh
+	}
+	return pbReq
+}
+
+// FindRequestFromPB converts a FindRequest protobuf message to a
+// model.FindRequest.
+func FindRequestFromPB(pbReq *pb.FindRequest) (*model.FindRequest, error) {
+	mhs, err := multihashesFromPB(pbReq.GetMultihashes())
+	if err != nil {
+		return nil, err
+	}
+	return &model.FindRequest{Multihashes: mhs}, nil
+}
+
+// FindResponseToPB converts a model.FindResponse to its protobuf message.
+func FindResponseToPB(resp *model.FindResponse) *pb.FindResponse {
+	pbResp := &pb.FindResponse{}
+	if len(resp.MultihashResults) != 0 {
+		pbResp.MultihashResults = make([]*pb.MultihashResult, len(resp.MultihashResults))
+		for i, mhr := range resp.MultihashResults {
+			pbMhr := &pb.MultihashResult{
+				Multihash:       mhr.Multihash,
+				ProviderResults: make([]*pb.ProviderResult, len(mhr.ProviderResults)),
+			}
+			for j, pr := range mhr.ProviderResults {
+				pbMhr.ProviderResults[j] = &pb.ProviderResult{
+					ContextId: pr.ContextID,
+					Metadata:  pr.Metadata,
+					Provider:  addrInfoToPB(pr.Provider),
+				}
+			}
+			pbResp.MultihashResults[i] = pbMhr
+		}
+	}
+	if len(resp.EncryptedMultihashResults) != 0 {
+		pbResp.EncryptedMultihashResults = make([]*pb.EncryptedMultihashResult, len(resp.EncryptedMultihashResults))
+		for i, emhr := range resp.EncryptedMultihashResults {
+			pbResp.EncryptedMultihashResults[i] = &pb.EncryptedMultihashResult{
+				Multihash:          emhr.Multihash,
+				EncryptedValueKeys: emhr.EncryptedValueKeys,
+			}
+		}
+	}
+	return pbResp
+}
+
+// FindResponseFromPB converts a FindResponse protobuf message to a
+// model.FindResponse.
+func FindResponseFromPB(pbResp *pb.FindResponse) (*model.FindResponse, error) {
+	resp := &model.FindResponse{}
+	if len(pbResp.GetMultihashResults()) != 0 {
+		resp.MultihashResults = make([]model.MultihashResult, len(pbResp.MultihashResults))
+		for i, pbMhr := range pbResp.MultihashResults {
+			mh, err := multihash.Cast(pbMhr.GetMultihash())
+			if err != nil {
+				return nil, fmt.Errorf("bad multihash in result: %w", err)
+			}
+			prs := make([]model.ProviderResult, len(pbMhr.GetProviderResults()))
+			for j, pbPr := range pbMhr.GetProviderResults() {
+				var provider *peer.AddrInfo
+				if pbPr.GetProvider() != nil {
+					provider, err = addrInfoFromPB(pbPr.Provider)
+					if err != nil {
+						return nil, err
+					}
+				}
+				prs[j] = model.ProviderResult{
+					ContextID: pbPr.GetContextId(),
+					Metadata:  pbPr.GetMetadata(),
+					Provider:  provider,
+				}
+			}
+			resp.MultihashResults[i] = model.MultihashResult{
+				Multihash:       mh,
+				ProviderResults: prs,
+			}
+		}
+	}
+	if len(pbResp.GetEncryptedMultihashResults()) != 0 {
+		resp.EncryptedMultihashResults = make([]model.EncryptedMultihashResult, len(pbResp.EncryptedMultihashResults))
+		for i, pbEmhr := range pbResp.EncryptedMultihashResults {
+			mh, err := multihash.Cast(pbEmhr.GetMultihash())
+			if err != nil {
+				return nil, fmt.Errorf("bad multihash in encrypted result: %w", err)
+			}
+			resp.EncryptedMultihashResults[i] = model.EncryptedMultihashResult{
+				Multihash:          mh,
+				EncryptedValueKeys: pbEmhr.GetEncryptedValueKeys(),
+			}
+		}
+	}
+	return resp, nil
+}
+
+// ProviderInfoToPB converts a model.ProviderInfo to its protobuf message.
+func ProviderInfoToPB(info *model.ProviderInfo) *pb.ProviderInfo {
+	pbInfo := &pb.ProviderInfo{
+		AddrInfo:              addrInfoToPB(&info.AddrInfo),
+		LastAdvertisement:     cidToPB(info.LastAdvertisement),
+		LastAdvertisementTime: info.LastAdvertisementTime,
+		Lag:                   int64(info.Lag),
+		Publisher:             addrInfoToPB(info.Publisher),
+		FrozenAt:              cidToPB(info.FrozenAt),
+		FrozenAtTime:          info.FrozenAtTime,
+		Inactive:              info.Inactive,
+		LastError:             info.LastError,
+		LastErrorTime:         info.LastErrorTime,
+	}
+	if info.ExtendedProviders != nil {
+		xp := info.ExtendedProviders
+		pbXp := &pb.ExtendedProviders{
+			Providers: addrInfosToPB(xp.Providers),
+			Metadatas: xp.Metadatas,
+		}
+		if len(xp.Contextual) != 0 {
+			pbXp.Contextual = make([]*pb.ContextualExtendedProviders, len(xp.Contextual))
+			for i, cxp := range xp.Contextual {
+				pbXp.Contextual[i] = &pb.ContextualExtendedProviders{
+					Override:  cxp.Override,
+					ContextId: cxp.ContextID,
+					Providers: addrInfosToPB(cxp.Providers),
+					Metadatas: cxp.Metadatas,
+				}
+			}
+		}
+		pbInfo.ExtendedProviders = pbXp
+	}
+	return pbInfo
+}
+
+// ProviderInfoFromPB converts a ProviderInfo protobuf message to a
+// model.ProviderInfo.
+func ProviderInfoFromPB(pbInfo *pb.ProviderInfo) (*model.ProviderInfo, error) {
+	if pbInfo.GetAddrInfo() == nil {
+		return nil, errors.New("missing provider addr info")
+	}
+	addrInfo, err := addrInfoFromPB(pbInfo.AddrInfo)
+	if err != nil {
+		return nil, err
+	}
+	info := &model.ProviderInfo{
+		AddrInfo:              *addrInfo,
+		LastAdvertisementTime: pbInfo.GetLastAdvertisementTime(),
+		Lag:                   int(pbInfo.GetLag()),
+		FrozenAtTime:          pbInfo.GetFrozenAtTime(),
+		Inactive:              pbInfo.GetInactive(),
+		LastError:             pbInfo.GetLastError(),
+		LastErrorTime:         pbInfo.GetLastErrorTime(),
+	}
+	if info.LastAdvertisement, err = cidFromPB(pbInfo.GetLastAdvertisement()); err != nil {
+		return nil, fmt.Errorf("bad last advertisement cid: %w", err)
+	}
+	if info.FrozenAt, err = cidFromPB(pbInfo.GetFrozenAt()); err != nil {
+		return nil, fmt.Errorf("bad frozen at cid: %w", err)
+	}
+	if pbInfo.GetPublisher() != nil {
+		if info.Publisher, err = addrInfoFromPB(pbInfo.Publisher); err != nil {
+			return nil, err
+		}
+	}
+	if pbXp := pbInfo.GetExtendedProviders(); pbXp != nil {
+		xp := &model.ExtendedProviders{
+			Metadatas: pbXp.GetMetadatas(),
+		}
+		if xp.Providers, err = addrInfosFromPB(pbXp.GetProviders()); err != nil {
+			return nil, err
+		}
+		if len(pbXp.GetContextual()) != 0 {
+			xp.Contextual = make([]model.ContextualExtendedProviders, len(pbXp.Contextual))
+			for i, pbCxp := range pbXp.Contextual {
+				providers, err := addrInfosFromPB(pbCxp.GetProviders())
+				if err != nil {
+					return nil, err
+				}
+				xp.Contextual[i] = model.ContextualExtendedProviders{
+					Override:  pbCxp.GetOverride(),
+					ContextID: pbCxp.GetContextId(),
+					Providers: providers,
+					Metadatas: pbCxp.GetMetadatas(),
+				}
+			}
+		}
+		info.ExtendedProviders = xp
+	}
+	return info, nil
+}
+
+// StatsToPB converts a model.Stats to its protobuf message.
+func StatsToPB(stats *model.Stats) *pb.GetStatsResponse {
+	return &pb.GetStatsResponse{
+		EntriesEstimate: stats.EntriesEstimate,
+		EntriesCount:    stats.EntriesCount,
+	}
+}
+
+// StatsFromPB converts a GetStatsResponse protobuf message to a model.Stats.
+func StatsFromPB(pbStats *pb.GetStatsResponse) *model.Stats {
+	return &model.Stats{
+		EntriesEstimate: pbStats.GetEntriesEstimate(),
+		EntriesCount:    pbStats.GetEntriesCount(),
+	}
+}
+
+func multihashesFromPB(data [][]byte) ([]multihash.Multihash, error) {
+	mhs := make([]multihash.Multihash, len(data))
+	for i, b := range data {
+		mh, err := multihash.Cast(b)
+		if err != nil {
+			return nil, fmt.Errorf("bad multihash in request: %w", err)
+		}
+		mhs[i] = mh
+	}
+	return mhs, nil
+}
+
+func addrInfoToPB(ai *peer.AddrInfo) *pb.AddrInfo {
+	if ai == nil {
+		return nil
+	}
+	pbAi := &pb.AddrInfo{
+		Id: []byte(ai.ID),
+	}
+	if len(ai.Addrs) != 0 {
+		pbAi.Addrs = make([][]byte, len(ai.Addrs))
+		for i, a := range ai.Addrs {
+			pbAi.Addrs[i] = a.Bytes()
+		}
+	}
+	return pbAi
+}
+
+func addrInfoFromPB(pbAi *pb.AddrInfo) (*peer.AddrInfo, error) {
+	id, err := peer.IDFromBytes(pbAi.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("bad peer id: %w", err)
+	}
+	ai := &peer.AddrInfo{
+		ID: id,
+	}
+	if len(pbAi.GetAddrs()) != 0 {
+		ai.Addrs = make([]multiaddr.Multiaddr, len(pbAi.Addrs))
+		for i, b := range pbAi.Addrs {
+			if ai.Addrs[i], err = multiaddr.NewMultiaddrBytes(b); err != nil {
+				return nil, fmt.Errorf("bad multiaddr for peer %s: %w", id, err)
+			}
+		}
+	}
+	return ai, nil
+}
+
+func addrInfosToPB(ais []peer.AddrInfo) []*pb.AddrInfo {
+	if len(ais) == 0 {
+		return nil
+	}
+	pbAis := make([]*pb.AddrInfo, len(ais))
+	for i := range ais {
+		pbAis[i] = addrInfoToPB(&ais[i])
+	}
+	return pbAis
+}
+
+func addrInfosFromPB(pbAis []*pb.AddrInfo) ([]peer.AddrInfo, error) {
+	if len(pbAis) == 0 {
+		return nil, nil
+	}
+	ais := make([]peer.AddrInfo, len(pbAis))
+	for i, pbAi := range pbAis {
+		ai, err := addrInfoFromPB(pbAi)
+		if err != nil {
+			return nil, err
+		}
+		ais[i] = *ai
+	}
+	return ais, nil
+}
+
+func cidToPB(c cid.Cid) []byte {
+	if c == cid.Undef {
+		return nil
+	}
+	return c.Bytes()
+}
+
+func cidFromPB(b []byte) (cid.Cid, error) {
+	if len(b) == 0 {
+		return cid.Undef, nil
+	}
+	return cid.Cast(b)
+}
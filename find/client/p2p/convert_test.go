@@ -0,0 +1,88 @@
+package p2pfindclient_test
+
+import (
+	"testing"
+
+	p2pfindclient "github.com/ipni/go-libipni/find/client/p2p"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderInfoPB(t *testing.T) {
+	ids := make([]peer.ID, 3)
+	for i := range ids {
+		ids[i], _, _ = test.RandomIdentity()
+	}
+	cids := test.RandomCids(2)
+	info := &model.ProviderInfo{
+		AddrInfo: peer.AddrInfo{
+			ID:    ids[0],
+			Addrs: test.RandomMultiaddrs(2),
+		},
+		LastAdvertisement:     cids[0],
+		LastAdvertisementTime: "2023-01-02T03:04:05Z",
+		Lag:                   7,
+		Publisher: &peer.AddrInfo{
+			ID:    ids[1],
+			Addrs: test.RandomMultiaddrs(1),
+		},
+		ExtendedProviders: &model.ExtendedProviders{
+			Providers: []peer.AddrInfo{{ID: ids[2], Addrs: test.RandomMultiaddrs(1)}},
+			Contextual: []model.ContextualExtendedProviders{{
+				Override:  true,
+				ContextID: "ctx-id",
+				Providers: []peer.AddrInfo{{ID: ids[1]}},
+				Metadatas: [][]byte{[]byte("metadata")},
+			}},
+			Metadatas: [][]byte{[]byte("metadata")},
+		},
+		FrozenAt:      cids[1],
+		FrozenAtTime:  "2023-01-02T03:04:06Z",
+		Inactive:      true,
+		LastError:     "some error",
+		LastErrorTime: "2023-01-02T03:04:07Z",
+	}
+	got, err := p2pfindclient.ProviderInfoFromPB(p2pfindclient.ProviderInfoToPB(info))
+	require.NoError(t, err)
+	require.Equal(t, info, got)
+
+	// Undefined CIDs and missing optional fields are preserved.
+	info = &model.ProviderInfo{
+		AddrInfo: peer.AddrInfo{ID: ids[0]},
+	}
+	got, err = p2pfindclient.ProviderInfoFromPB(p2pfindclient.ProviderInfoToPB(info))
+	require.NoError(t, err)
+	require.Equal(t, info, got)
+}
+
+func TestFindResponsePB(t *testing.T) {
+	mhs := test.RandomMultihashes(2)
+	providerID, _, _ := test.RandomIdentity()
+	resp := &model.FindResponse{
+		MultihashResults: []model.MultihashResult{{
+			Multihash: mhs[0],
+			ProviderResults: []model.ProviderResult{{
+				ContextID: []byte("ctx-id"),
+				Metadata:  []byte("metadata"),
+				Provider: &peer.AddrInfo{
+					ID:    providerID,
+					Addrs: test.RandomMultiaddrs(1),
+				},
+			}},
+		}},
+		EncryptedMultihashResults: []model.EncryptedMultihashResult{{
+			Multihash:          mhs[1],
+			EncryptedValueKeys: [][]byte{[]byte("evk1"), []byte("evk2")},
+		}},
+	}
+	got, err := p2pfindclient.FindResponseFromPB(p2pfindclient.FindResponseToPB(resp))
+	require.NoError(t, err)
+	require.Equal(t, resp, got)
+
+	req := &model.FindRequest{Multihashes: mhs}
+	gotReq, err := p2pfindclient.FindRequestFromPB(p2pfindclient.FindRequestToPB(req))
+	require.NoError(t, err)
+	require.Equal(t, req, gotReq)
+}
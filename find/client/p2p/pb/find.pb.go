@@ -0,0 +1,1288 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        (unknown)
+// source: find/client/p2p/pb/find.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// FindRequest is a request to find the providers of multihashes.
+type FindRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Multihashes [][]byte `protobuf:"bytes,1,rep,name=multihashes,proto3" json:"multihashes,omitempty"`
+}
+
+func (x *FindRequest) Reset() {
+	*x = FindRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindRequest) ProtoMessage() {}
+
+func (x *FindRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindRequest.ProtoReflect.Descriptor instead.
+func (*FindRequest) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FindRequest) GetMultihashes() [][]byte {
+	if x != nil {
+		return x.Multihashes
+	}
+	return nil
+}
+
+// FindResponse is the response to a FindRequest.
+type FindResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MultihashResults          []*MultihashResult          `protobuf:"bytes,1,rep,name=multihash_results,json=multihashResults,proto3" json:"multihash_results,omitempty"`
+	EncryptedMultihashResults []*EncryptedMultihashResult `protobuf:"bytes,2,rep,name=encrypted_multihash_results,json=encryptedMultihashResults,proto3" json:"encrypted_multihash_results,omitempty"`
+}
+
+func (x *FindResponse) Reset() {
+	*x = FindResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindResponse) ProtoMessage() {}
+
+func (x *FindResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindResponse.ProtoReflect.Descriptor instead.
+func (*FindResponse) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FindResponse) GetMultihashResults() []*MultihashResult {
+	if x != nil {
+		return x.MultihashResults
+	}
+	return nil
+}
+
+func (x *FindResponse) GetEncryptedMultihashResults() []*EncryptedMultihashResult {
+	if x != nil {
+		return x.EncryptedMultihashResults
+	}
+	return nil
+}
+
+// MultihashResult contains the provider results for a single multihash.
+type MultihashResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Multihash       []byte            `protobuf:"bytes,1,opt,name=multihash,proto3" json:"multihash,omitempty"`
+	ProviderResults []*ProviderResult `protobuf:"bytes,2,rep,name=provider_results,json=providerResults,proto3" json:"provider_results,omitempty"`
+}
+
+func (x *MultihashResult) Reset() {
+	*x = MultihashResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MultihashResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultihashResult) ProtoMessage() {}
+
+func (x *MultihashResult) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultihashResult.ProtoReflect.Descriptor instead.
+func (*MultihashResult) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MultihashResult) GetMultihash() []byte {
+	if x != nil {
+		return x.Multihash
+	}
+	return nil
+}
+
+func (x *MultihashResult) GetProviderResults() []*ProviderResult {
+	if x != nil {
+		return x.ProviderResults
+	}
+	return nil
+}
+
+// ProviderResult is a provider of the content identified by a multihash.
+type ProviderResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ContextId []byte    `protobuf:"bytes,1,opt,name=context_id,json=contextId,proto3" json:"context_id,omitempty"`
+	Metadata  []byte    `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Provider  *AddrInfo `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+}
+
+func (x *ProviderResult) Reset() {
+	*x = ProviderResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProviderResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProviderResult) ProtoMessage() {}
+
+func (x *ProviderResult) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProviderResult.ProtoReflect.Descriptor instead.
+func (*ProviderResult) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProviderResult) GetContextId() []byte {
+	if x != nil {
+		return x.ContextId
+	}
+	return nil
+}
+
+func (x *ProviderResult) GetMetadata() []byte {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ProviderResult) GetProvider() *AddrInfo {
+	if x != nil {
+		return x.Provider
+	}
+	return nil
+}
+
+// EncryptedMultihashResult contains the encrypted value keys for a single
+// multihash.
+type EncryptedMultihashResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Multihash          []byte   `protobuf:"bytes,1,opt,name=multihash,proto3" json:"multihash,omitempty"`
+	EncryptedValueKeys [][]byte `protobuf:"bytes,2,rep,name=encrypted_value_keys,json=encryptedValueKeys,proto3" json:"encrypted_value_keys,omitempty"`
+}
+
+func (x *EncryptedMultihashResult) Reset() {
+	*x = EncryptedMultihashResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EncryptedMultihashResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncryptedMultihashResult) ProtoMessage() {}
+
+func (x *EncryptedMultihashResult) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncryptedMultihashResult.ProtoReflect.Descriptor instead.
+func (*EncryptedMultihashResult) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EncryptedMultihashResult) GetMultihash() []byte {
+	if x != nil {
+		return x.Multihash
+	}
+	return nil
+}
+
+func (x *EncryptedMultihashResult) GetEncryptedValueKeys() [][]byte {
+	if x != nil {
+		return x.EncryptedValueKeys
+	}
+	return nil
+}
+
+// AddrInfo is a peer ID and the binary encoded multiaddrs of the peer.
+type AddrInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    []byte   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Addrs [][]byte `protobuf:"bytes,2,rep,name=addrs,proto3" json:"addrs,omitempty"`
+}
+
+func (x *AddrInfo) Reset() {
+	*x = AddrInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddrInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddrInfo) ProtoMessage() {}
+
+func (x *AddrInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddrInfo.ProtoReflect.Descriptor instead.
+func (*AddrInfo) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AddrInfo) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *AddrInfo) GetAddrs() [][]byte {
+	if x != nil {
+		return x.Addrs
+	}
+	return nil
+}
+
+// GetProviderRequest is a request for information about a provider.
+type GetProviderRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProviderId []byte `protobuf:"bytes,1,opt,name=provider_id,json=providerId,proto3" json:"provider_id,omitempty"`
+}
+
+func (x *GetProviderRequest) Reset() {
+	*x = GetProviderRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetProviderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProviderRequest) ProtoMessage() {}
+
+func (x *GetProviderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProviderRequest.ProtoReflect.Descriptor instead.
+func (*GetProviderRequest) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetProviderRequest) GetProviderId() []byte {
+	if x != nil {
+		return x.ProviderId
+	}
+	return nil
+}
+
+// GetProviderResponse is the response to a GetProviderRequest.
+type GetProviderResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Provider *ProviderInfo `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+}
+
+func (x *GetProviderResponse) Reset() {
+	*x = GetProviderResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetProviderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProviderResponse) ProtoMessage() {}
+
+func (x *GetProviderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProviderResponse.ProtoReflect.Descriptor instead.
+func (*GetProviderResponse) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetProviderResponse) GetProvider() *ProviderInfo {
+	if x != nil {
+		return x.Provider
+	}
+	return nil
+}
+
+// ListProvidersRequest is a request for information about all providers.
+type ListProvidersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListProvidersRequest) Reset() {
+	*x = ListProvidersRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListProvidersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProvidersRequest) ProtoMessage() {}
+
+func (x *ListProvidersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProvidersRequest.ProtoReflect.Descriptor instead.
+func (*ListProvidersRequest) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{8}
+}
+
+// ListProvidersResponse is the response to a ListProvidersRequest.
+type ListProvidersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Providers []*ProviderInfo `protobuf:"bytes,1,rep,name=providers,proto3" json:"providers,omitempty"`
+}
+
+func (x *ListProvidersResponse) Reset() {
+	*x = ListProvidersResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListProvidersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProvidersResponse) ProtoMessage() {}
+
+func (x *ListProvidersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProvidersResponse.ProtoReflect.Descriptor instead.
+func (*ListProvidersResponse) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListProvidersResponse) GetProviders() []*ProviderInfo {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+// ProviderInfo describes a provider. CIDs are in their binary encoding, and
+// times are in RFC 3339 format.
+type ProviderInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AddrInfo              *AddrInfo          `protobuf:"bytes,1,opt,name=addr_info,json=addrInfo,proto3" json:"addr_info,omitempty"`
+	LastAdvertisement     []byte             `protobuf:"bytes,2,opt,name=last_advertisement,json=lastAdvertisement,proto3" json:"last_advertisement,omitempty"`
+	LastAdvertisementTime string             `protobuf:"bytes,3,opt,name=last_advertisement_time,json=lastAdvertisementTime,proto3" json:"last_advertisement_time,omitempty"`
+	Lag                   int64              `protobuf:"varint,4,opt,name=lag,proto3" json:"lag,omitempty"`
+	Publisher             *AddrInfo          `protobuf:"bytes,5,opt,name=publisher,proto3" json:"publisher,omitempty"`
+	ExtendedProviders     *ExtendedProviders `protobuf:"bytes,6,opt,name=extended_providers,json=extendedProviders,proto3" json:"extended_providers,omitempty"`
+	FrozenAt              []byte             `protobuf:"bytes,7,opt,name=frozen_at,json=frozenAt,proto3" json:"frozen_at,omitempty"`
+	FrozenAtTime          string             `protobuf:"bytes,8,opt,name=frozen_at_time,json=frozenAtTime,proto3" json:"frozen_at_time,omitempty"`
+	Inactive              bool               `protobuf:"varint,9,opt,name=inactive,proto3" json:"inactive,omitempty"`
+	LastError             string             `protobuf:"bytes,10,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	LastErrorTime         string             `protobuf:"bytes,11,opt,name=last_error_time,json=lastErrorTime,proto3" json:"last_error_time,omitempty"`
+}
+
+func (x *ProviderInfo) Reset() {
+	*x = ProviderInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProviderInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProviderInfo) ProtoMessage() {}
+
+func (x *ProviderInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProviderInfo.ProtoReflect.Descriptor instead.
+func (*ProviderInfo) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ProviderInfo) GetAddrInfo() *AddrInfo {
+	if x != nil {
+		return x.AddrInfo
+	}
+	return nil
+}
+
+func (x *ProviderInfo) GetLastAdvertisement() []byte {
+	if x != nil {
+		return x.LastAdvertisement
+	}
+	return nil
+}
+
+func (x *ProviderInfo) GetLastAdvertisementTime() string {
+	if x != nil {
+		return x.LastAdvertisementTime
+	}
+	return ""
+}
+
+func (x *ProviderInfo) GetLag() int64 {
+	if x != nil {
+		return x.Lag
+	}
+	return 0
+}
+
+func (x *ProviderInfo) GetPublisher() *AddrInfo {
+	if x != nil {
+		return x.Publisher
+	}
+	return nil
+}
+
+func (x *ProviderInfo) GetExtendedProviders() *ExtendedProviders {
+	if x != nil {
+		return x.ExtendedProviders
+	}
+	return nil
+}
+
+func (x *ProviderInfo) GetFrozenAt() []byte {
+	if x != nil {
+		return x.FrozenAt
+	}
+	return nil
+}
+
+func (x *ProviderInfo) GetFrozenAtTime() string {
+	if x != nil {
+		return x.FrozenAtTime
+	}
+	return ""
+}
+
+func (x *ProviderInfo) GetInactive() bool {
+	if x != nil {
+		return x.Inactive
+	}
+	return false
+}
+
+func (x *ProviderInfo) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *ProviderInfo) GetLastErrorTime() string {
+	if x != nil {
+		return x.LastErrorTime
+	}
+	return ""
+}
+
+// ExtendedProviders contains the chain-level and context-level extended
+// providers of a provider.
+type ExtendedProviders struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Providers  []*AddrInfo                    `protobuf:"bytes,1,rep,name=providers,proto3" json:"providers,omitempty"`
+	Contextual []*ContextualExtendedProviders `protobuf:"bytes,2,rep,name=contextual,proto3" json:"contextual,omitempty"`
+	Metadatas  [][]byte                       `protobuf:"bytes,3,rep,name=metadatas,proto3" json:"metadatas,omitempty"`
+}
+
+func (x *ExtendedProviders) Reset() {
+	*x = ExtendedProviders{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExtendedProviders) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendedProviders) ProtoMessage() {}
+
+func (x *ExtendedProviders) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendedProviders.ProtoReflect.Descriptor instead.
+func (*ExtendedProviders) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ExtendedProviders) GetProviders() []*AddrInfo {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+func (x *ExtendedProviders) GetContextual() []*ContextualExtendedProviders {
+	if x != nil {
+		return x.Contextual
+	}
+	return nil
+}
+
+func (x *ExtendedProviders) GetMetadatas() [][]byte {
+	if x != nil {
+		return x.Metadatas
+	}
+	return nil
+}
+
+// ContextualExtendedProviders contains the extended providers for a context
+// ID.
+type ContextualExtendedProviders struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Override  bool        `protobuf:"varint,1,opt,name=override,proto3" json:"override,omitempty"`
+	ContextId string      `protobuf:"bytes,2,opt,name=context_id,json=contextId,proto3" json:"context_id,omitempty"`
+	Providers []*AddrInfo `protobuf:"bytes,3,rep,name=providers,proto3" json:"providers,omitempty"`
+	Metadatas [][]byte    `protobuf:"bytes,4,rep,name=metadatas,proto3" json:"metadatas,omitempty"`
+}
+
+func (x *ContextualExtendedProviders) Reset() {
+	*x = ContextualExtendedProviders{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContextualExtendedProviders) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContextualExtendedProviders) ProtoMessage() {}
+
+func (x *ContextualExtendedProviders) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContextualExtendedProviders.ProtoReflect.Descriptor instead.
+func (*ContextualExtendedProviders) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ContextualExtendedProviders) GetOverride() bool {
+	if x != nil {
+		return x.Override
+	}
+	return false
+}
+
+func (x *ContextualExtendedProviders) GetContextId() string {
+	if x != nil {
+		return x.ContextId
+	}
+	return ""
+}
+
+func (x *ContextualExtendedProviders) GetProviders() []*AddrInfo {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+func (x *ContextualExtendedProviders) GetMetadatas() [][]byte {
+	if x != nil {
+		return x.Metadatas
+	}
+	return nil
+}
+
+// GetStatsRequest is a request for indexer statistics.
+type GetStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{13}
+}
+
+// GetStatsResponse is the response to a GetStatsRequest.
+type GetStatsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EntriesEstimate int64 `protobuf:"varint,1,opt,name=entries_estimate,json=entriesEstimate,proto3" json:"entries_estimate,omitempty"`
+	EntriesCount    int64 `protobuf:"varint,2,opt,name=entries_count,json=entriesCount,proto3" json:"entries_count,omitempty"`
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_find_client_p2p_pb_find_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_find_client_p2p_pb_find_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_find_client_p2p_pb_find_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetStatsResponse) GetEntriesEstimate() int64 {
+	if x != nil {
+		return x.EntriesEstimate
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetEntriesCount() int64 {
+	if x != nil {
+		return x.EntriesCount
+	}
+	return 0
+}
+
+var File_find_client_p2p_pb_find_proto protoreflect.FileDescriptor
+
+var file_find_client_p2p_pb_find_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x66, 0x69, 0x6e, 0x64, 0x2f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2f, 0x70, 0x32,
+	0x70, 0x2f, 0x70, 0x62, 0x2f, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0c, 0x69, 0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x22, 0x2f, 0x0a,
+	0x0b, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x0b,
+	0x6d, 0x75, 0x6c, 0x74, 0x69, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0c, 0x52, 0x0b, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0xc2,
+	0x01, 0x0a, 0x0c, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x4a, 0x0a, 0x11, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x68, 0x61, 0x73, 0x68, 0x5f, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x70, 0x6e,
+	0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x68,
+	0x61, 0x73, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x10, 0x6d, 0x75, 0x6c, 0x74, 0x69,
+	0x68, 0x61, 0x73, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x66, 0x0a, 0x1b, 0x65,
+	0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x68, 0x61,
+	0x73, 0x68, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x26, 0x2e, 0x69, 0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e,
+	0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x68, 0x61,
+	0x73, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x19, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70,
+	0x74, 0x65, 0x64, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x68, 0x61, 0x73, 0x68, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x73, 0x22, 0x78, 0x0a, 0x0f, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x68, 0x61, 0x73, 0x68,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x68,
+	0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x6d, 0x75, 0x6c, 0x74, 0x69,
+	0x68, 0x61, 0x73, 0x68, 0x12, 0x47, 0x0a, 0x10, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72,
+	0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c,
+	0x2e, 0x69, 0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x72,
+	0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x0f, 0x70, 0x72,
+	0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x7f, 0x0a,
+	0x0e, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x49, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x32, 0x0a, 0x08, 0x70, 0x72,
+	0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x69,
+	0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x64, 0x64, 0x72,
+	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x22, 0x6a,
+	0x0a, 0x18, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x4d, 0x75, 0x6c, 0x74, 0x69,
+	0x68, 0x61, 0x73, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x75,
+	0x6c, 0x74, 0x69, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x6d,
+	0x75, 0x6c, 0x74, 0x69, 0x68, 0x61, 0x73, 0x68, 0x12, 0x30, 0x0a, 0x14, 0x65, 0x6e, 0x63, 0x72,
+	0x79, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x12, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65,
+	0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x22, 0x30, 0x0a, 0x08, 0x41, 0x64,
+	0x64, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x22, 0x35, 0x0a, 0x12,
+	0x47, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65,
+	0x72, 0x49, 0x64, 0x22, 0x4d, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64,
+	0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x08, 0x70, 0x72,
+	0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69,
+	0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x72, 0x6f, 0x76,
+	0x69, 0x64, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64,
+	0x65, 0x72, 0x22, 0x16, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64,
+	0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x51, 0x0a, 0x15, 0x4c, 0x69,
+	0x73, 0x74, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69,
+	0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x22, 0xe8, 0x03,
+	0x0a, 0x0c, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x33,
+	0x0a, 0x09, 0x61, 0x64, 0x64, 0x72, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x69, 0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62,
+	0x2e, 0x41, 0x64, 0x64, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x61, 0x64, 0x64, 0x72, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x2d, 0x0a, 0x12, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x61, 0x64, 0x76, 0x65,
+	0x72, 0x74, 0x69, 0x73, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x11, 0x6c, 0x61, 0x73, 0x74, 0x41, 0x64, 0x76, 0x65, 0x72, 0x74, 0x69, 0x73, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x12, 0x36, 0x0a, 0x17, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x61, 0x64, 0x76, 0x65, 0x72,
+	0x74, 0x69, 0x73, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x15, 0x6c, 0x61, 0x73, 0x74, 0x41, 0x64, 0x76, 0x65, 0x72, 0x74, 0x69,
+	0x73, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6c, 0x61,
+	0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x6c, 0x61, 0x67, 0x12, 0x34, 0x0a, 0x09,
+	0x70, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x69, 0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x41,
+	0x64, 0x64, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68,
+	0x65, 0x72, 0x12, 0x4e, 0x0a, 0x12, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x5f, 0x70,
+	0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f,
+	0x2e, 0x69, 0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x45, 0x78,
+	0x74, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x52,
+	0x11, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65,
+	0x72, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x72, 0x6f, 0x7a, 0x65, 0x6e, 0x5f, 0x61, 0x74, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x66, 0x72, 0x6f, 0x7a, 0x65, 0x6e, 0x41, 0x74, 0x12,
+	0x24, 0x0a, 0x0e, 0x66, 0x72, 0x6f, 0x7a, 0x65, 0x6e, 0x5f, 0x61, 0x74, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x66, 0x72, 0x6f, 0x7a, 0x65, 0x6e, 0x41,
+	0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x6e, 0x61, 0x63, 0x74, 0x69, 0x76,
+	0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x6e, 0x61, 0x63, 0x74, 0x69, 0x76,
+	0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x12, 0x26, 0x0a, 0x0f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x22, 0xb2, 0x01, 0x0a, 0x11, 0x45, 0x78, 0x74,
+	0x65, 0x6e, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x12, 0x34,
+	0x0a, 0x09, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x69, 0x70, 0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62,
+	0x2e, 0x41, 0x64, 0x64, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x76, 0x69,
+	0x64, 0x65, 0x72, 0x73, 0x12, 0x49, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x75,
+	0x61, 0x6c, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x69, 0x70, 0x6e, 0x69, 0x2e,
+	0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x75,
+	0x61, 0x6c, 0x45, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64,
+	0x65, 0x72, 0x73, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x75, 0x61, 0x6c, 0x12,
+	0x1c, 0x0a, 0x09, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x0c, 0x52, 0x09, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x73, 0x22, 0xac, 0x01,
+	0x0a, 0x1b, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x75, 0x61, 0x6c, 0x45, 0x78, 0x74, 0x65,
+	0x6e, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x12, 0x1a, 0x0a,
+	0x08, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x08, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x78, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x49, 0x64, 0x12, 0x34, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x76,
+	0x69, 0x64, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x69, 0x70,
+	0x6e, 0x69, 0x2e, 0x66, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x12, 0x1c,
+	0x0a, 0x09, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
+	0x0c, 0x52, 0x09, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x73, 0x22, 0x11, 0x0a, 0x0f,
+	0x47, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
+	0x62, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x5f, 0x65,
+	0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x65,
+	0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x45, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x12, 0x23,
+	0x0a, 0x0d, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x69, 0x70, 0x6e, 0x69, 0x2f, 0x67, 0x6f, 0x2d, 0x6c, 0x69, 0x62, 0x69, 0x70, 0x6e,
+	0x69, 0x2f, 0x66, 0x69, 0x6e, 0x64, 0x2f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2f, 0x70, 0x32,
+	0x70, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_find_client_p2p_pb_find_proto_rawDescOnce sync.Once
+	file_find_client_p2p_pb_find_proto_rawDescData = file_find_client_p2p_pb_find_proto_rawDesc
+)
+
+func file_find_client_p2p_pb_find_proto_rawDescGZIP() []byte {
+	file_find_client_p2p_pb_find_proto_rawDescOnce.Do(func() {
+		file_find_client_p2p_pb_find_proto_rawDescData = protoimpl.X.CompressGZIP(file_find_client_p2p_pb_find_proto_rawDescData)
+	})
+	return file_find_client_p2p_pb_find_proto_rawDescData
+}
+
+var file_find_client_p2p_pb_find_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_find_client_p2p_pb_find_proto_goTypes = []interface{}{
+	(*FindRequest)(nil),                 // 0: ipni.find.pb.FindRequest
+	(*FindResponse)(nil),                // 1: ipni.find.pb.FindResponse
+	(*MultihashResult)(nil),             // 2: ipni.find.pb.MultihashResult
+	(*ProviderResult)(nil),              // 3: ipni.find.pb.ProviderResult
+	(*EncryptedMultihashResult)(nil),    // 4: ipni.find.pb.EncryptedMultihashResult
+	(*AddrInfo)(nil),                    // 5: ipni.find.pb.AddrInfo
+	(*GetProviderRequest)(nil),          // 6: ipni.find.pb.GetProviderRequest
+	(*GetProviderResponse)(nil),         // 7: ipni.find.pb.GetProviderResponse
+	(*ListProvidersRequest)(nil),        // 8: ipni.find.pb.ListProvidersRequest
+	(*ListProvidersResponse)(nil),       // 9: ipni.find.pb.ListProvidersResponse
+	(*ProviderInfo)(nil),                // 10: ipni.find.pb.ProviderInfo
+	(*ExtendedProviders)(nil),           // 11: ipni.find.pb.ExtendedProviders
+	(*ContextualExtendedProviders)(nil), // 12: ipni.find.pb.ContextualExtendedProviders
+	(*GetStatsRequest)(nil),             // 13: ipni.find.pb.GetStatsRequest
+	(*GetStatsResponse)(nil),            // 14: ipni.find.pb.GetStatsResponse
+}
+var file_find_client_p2p_pb_find_proto_depIdxs = []int32{
+	2,  // 0: ipni.find.pb.FindResponse.multihash_results:type_name -> ipni.find.pb.MultihashResult
+	4,  // 1: ipni.find.pb.FindResponse.encrypted_multihash_results:type_name -> ipni.find.pb.EncryptedMultihashResult
+	3,  // 2: ipni.find.pb.MultihashResult.provider_results:type_name -> ipni.find.pb.ProviderResult
+	5,  // 3: ipni.find.pb.ProviderResult.provider:type_name -> ipni.find.pb.AddrInfo
+	10, // 4: ipni.find.pb.GetProviderResponse.provider:type_name -> ipni.find.pb.ProviderInfo
+	10, // 5: ipni.find.pb.ListProvidersResponse.providers:type_name -> ipni.find.pb.ProviderInfo
+	5,  // 6: ipni.find.pb.ProviderInfo.addr_info:type_name -> ipni.find.pb.AddrInfo
+	5,  // 7: ipni.find.pb.ProviderInfo.publisher:type_name -> ipni.find.pb.AddrInfo
+	11, // 8: ipni.find.pb.ProviderInfo.extended_providers:type_name -> ipni.find.pb.ExtendedProviders
+	5,  // 9: ipni.find.pb.ExtendedProviders.providers:type_name -> ipni.find.pb.AddrInfo
+	12, // 10: ipni.find.pb.ExtendedProviders.contextual:type_name -> ipni.find.pb.ContextualExtendedProviders
+	5,  // 11: ipni.find.pb.ContextualExtendedProviders.providers:type_name -> ipni.find.pb.AddrInfo
+	12, // [12:12] is the sub-list for method output_type
+	12, // [12:12] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_find_client_p2p_pb_find_proto_init() }
+func file_find_client_p2p_pb_find_proto_init() {
+	if File_find_client_p2p_pb_find_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_find_client_p2p_pb_find_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MultihashResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProviderResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EncryptedMultihashResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddrInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetProviderRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetProviderResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListProvidersRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListProvidersResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProviderInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExtendedProviders); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ContextualExtendedProviders); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetStatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_find_client_p2p_pb_find_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetStatsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_find_client_p2p_pb_find_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_find_client_p2p_pb_find_proto_goTypes,
+		DependencyIndexes: file_find_client_p2p_pb_find_proto_depIdxs,
+		MessageInfos:      file_find_client_p2p_pb_find_proto_msgTypes,
+	}.Build()
+	File_find_client_p2p_pb_find_proto = out.File
+	file_find_client_p2p_pb_find_proto_rawDesc = nil
+	file_find_client_p2p_pb_find_proto_goTypes = nil
+	file_find_client_p2p_pb_find_proto_depIdxs = nil
+}
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/node/bindnode"
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multihash"
+)
+
+var (
+	//go:embed find_response.ipldsch
+	findResponseSchemaBytes []byte
+
+	findResponsePrototype   schema.TypedPrototype
+	providerResultPrototype schema.TypedPrototype
+	streamResultPrototype   schema.TypedPrototype
+)
+
+func init() {
+	typeSystem, err := ipld.LoadSchemaBytes(findResponseSchemaBytes)
+	if err != nil {
+		panic(fmt.Errorf("failed to load schema: %w", err))
+	}
+	findResponsePrototype = bindnode.Prototype((*findResponseCBOR)(nil), typeSystem.TypeByName("FindResponse"))
+	providerResultPrototype = bindnode.Prototype((*providerResultCBOR)(nil), typeSystem.TypeByName("ProviderResult"))
+	streamResultPrototype = bindnode.Prototype((*streamResultCBOR)(nil), typeSystem.TypeByName("StreamResult"))
+}
+
+// The following types are the dag-cbor wire representations of the find
+// response types. Peer IDs, multiaddrs, and multihashes are encoded as bytes.
+
+type findResponseCBOR struct {
+	MultihashResults          []multihashResultCBOR
+	EncryptedMultihashResults []EncryptedMultihashResult
+}
+
+type multihashResultCBOR struct {
+	Multihash       []byte
+	ProviderResults []providerResultCBOR
+}
+
+type providerResultCBOR struct {
+	ContextID []byte
+	Metadata  []byte
+	Provider  *addrInfoCBOR
+}
+
+type streamResultCBOR struct {
+	Multihash          []byte
+	ContextID          []byte
+	Metadata           []byte
+	Provider           *addrInfoCBOR
+	EncryptedValueKeys [][]byte
+}
+
+type addrInfoCBOR struct {
+	ID    []byte
+	Addrs [][]byte
+}
+
+// MarshalFindResponseCBOR serializes a find response as dag-cbor.
+func MarshalFindResponseCBOR(r *FindResponse) ([]byte, error) {
+	fr := findResponseCBOR{
+		EncryptedMultihashResults: r.EncryptedMultihashResults,
+	}
+	if len(r.MultihashResults) != 0 {
+		fr.MultihashResults = make([]multihashResultCBOR, len(r.MultihashResults))
+		for i, mhr := range r.MultihashResults {
+			prs := make([]providerResultCBOR, len(mhr.ProviderResults))
+			for j := range mhr.ProviderResults {
+				prs[j] = toProviderResultCBOR(&mhr.ProviderResults[j])
+			}
+			fr.MultihashResults[i] = multihashResultCBOR{
+				Multihash:       mhr.Multihash,
+				ProviderResults: prs,
+			}
+		}
+	}
+	return encodeCBOR(&fr, findResponsePrototype)
+}
+
+// UnmarshalFindResponseCBOR de-serializes a dag-cbor find response.
+func UnmarshalFindResponseCBOR(b []byte) (*FindResponse, error) {
+	nd, err := decodeCBOR(b, findResponsePrototype)
+	if err != nil {
+		return nil, err
+	}
+	fr := bindnode.Unwrap(nd).(*findResponseCBOR)
+
+	r := &FindResponse{
+		EncryptedMultihashResults: fr.EncryptedMultihashResults,
+	}
+	if len(fr.MultihashResults) != 0 {
+		r.MultihashResults = make([]MultihashResult, len(fr.MultihashResults))
+		for i, mhr := range fr.MultihashResults {
+			mh, err := multihash.Cast(mhr.Multihash)
+			if err != nil {
+				return nil, err
+			}
+			prs := make([]ProviderResult, len(mhr.ProviderResults))
+			for j := range mhr.ProviderResults {
+				if prs[j], err = fromProviderResultCBOR(&mhr.ProviderResults[j]); err != nil {
+					return nil, err
+				}
+			}
+			r.MultihashResults[i] = MultihashResult{
+				Multihash:       mh,
+				ProviderResults: prs,
+			}
+		}
+	}
+	return r, nil
+}
+
+// MarshalProviderResultCBOR serializes a provider result as dag-cbor.
+func MarshalProviderResultCBOR(pr *ProviderResult) ([]byte, error) {
+	prc := toProviderResultCBOR(pr)
+	return encodeCBOR(&prc, providerResultPrototype)
+}
+
+// UnmarshalProviderResultCBOR de-serializes a dag-cbor provider result.
+func UnmarshalProviderResultCBOR(b []byte) (ProviderResult, error) {
+	nd, err := decodeCBOR(b, providerResultPrototype)
+	if err != nil {
+		return ProviderResult{}, err
+	}
+	return fromProviderResultCBOR(bindnode.Unwrap(nd).(*providerResultCBOR))
+}
+
+// MarshalStreamResultCBOR serializes a stream result as dag-cbor.
+func MarshalStreamResultCBOR(sr *StreamResult) ([]byte, error) {
+	prc := toProviderResultCBOR(&sr.ProviderResult)
+	src := streamResultCBOR{
+		Multihash:          sr.Multihash,
+		ContextID:          prc.ContextID,
+		Metadata:           prc.Metadata,
+		Provider:           prc.Provider,
+		EncryptedValueKeys: sr.EncryptedValueKeys,
+	}
+	return encodeCBOR(&src, streamResultPrototype)
+}
+
+// UnmarshalStreamResultCBOR de-serializes a dag-cbor stream result.
+func UnmarshalStreamResultCBOR(b []byte) (StreamResult, error) {
+	nd, err := decodeCBOR(b, streamResultPrototype)
+	if err != nil {
+		return StreamResult{}, err
+	}
+	src := bindnode.Unwrap(nd).(*streamResultCBOR)
+	mh, err := multihash.Cast(src.Multihash)
+	if err != nil {
+		return StreamResult{}, err
+	}
+	pr, err := fromProviderResultCBOR(&providerResultCBOR{
+		ContextID: src.ContextID,
+		Metadata:  src.Metadata,
+		Provider:  src.Provider,
+	})
+	if err != nil {
+		return StreamResult{}, err
+	}
+	return StreamResult{
+		Multihash:          mh,
+		ProviderResult:     pr,
+		EncryptedValueKeys: src.EncryptedValueKeys,
+	}, nil
+}
+
+func encodeCBOR(v interface{}, proto schema.TypedPrototype) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := dagcbor.Encode(bindnode.Wrap(v, proto.Type()).Representation(), &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeCBOR(b []byte, proto schema.TypedPrototype) (ipld.Node, error) {
+	nb := proto.NewBuilder()
+	if err := dagcbor.Decode(nb, bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return nb.Build(), nil
+}
+
+func toProviderResultCBOR(pr *ProviderResult) providerResultCBOR {
+	prc := providerResultCBOR{
+		ContextID: pr.ContextID,
+		Metadata:  pr.Metadata,
+	}
+	if pr.Provider != nil {
+		addrs := make([][]byte, len(pr.Provider.Addrs))
+		for i, a := range pr.Provider.Addrs {
+			addrs[i] = a.Bytes()
+		}
+		prc.Provider = &addrInfoCBOR{
+			ID:    []byte(pr.Provider.ID),
+			Addrs: addrs,
+		}
+	}
+	return prc
+}
+
+func fromProviderResultCBOR(prc *providerResultCBOR) (ProviderResult, error) {
+	pr := ProviderResult{
+		ContextID: prc.ContextID,
+		Metadata:  prc.Metadata,
+	}
+	if prc.Provider != nil {
+		id, err := peer.IDFromBytes(prc.Provider.ID)
+		if err != nil {
+			return ProviderResult{}, err
+		}
+		var addrs []multiaddr.Multiaddr
+		if len(prc.Provider.Addrs) != 0 {
+			addrs = make([]multiaddr.Multiaddr, len(prc.Provider.Addrs))
+			for i, b := range prc.Provider.Addrs {
+				if addrs[i], err = multiaddr.NewMultiaddrBytes(b); err != nil {
+					return ProviderResult{}, err
+				}
+			}
+		}
+		pr.Provider = &peer.AddrInfo{
+			ID:    id,
+			Addrs: addrs,
+		}
+	}
+	return pr, nil
+}
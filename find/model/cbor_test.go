@@ -0,0 +1,79 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalCBOR(t *testing.T) {
+	mhs := test.RandomMultihashes(3)
+	p, err := peer.Decode("12D3KooWKRyzVWW6ChFjQjK4miCty85Niy48tpPV95XdKu1BcvMA")
+	require.NoError(t, err)
+
+	pr := model.ProviderResult{
+		ContextID: []byte("test-context-id"),
+		Metadata:  []byte("test-metadata"),
+		Provider: &peer.AddrInfo{
+			ID:    p,
+			Addrs: test.RandomMultiaddrs(2),
+		},
+	}
+	data, err := model.MarshalProviderResultCBOR(&pr)
+	require.NoError(t, err)
+	gotPR, err := model.UnmarshalProviderResultCBOR(data)
+	require.NoError(t, err)
+	require.Equal(t, pr, gotPR)
+
+	// Provider result with no provider.
+	data, err = model.MarshalProviderResultCBOR(&model.ProviderResult{ContextID: []byte("id")})
+	require.NoError(t, err)
+	gotPR, err = model.UnmarshalProviderResultCBOR(data)
+	require.NoError(t, err)
+	require.Equal(t, []byte("id"), gotPR.ContextID)
+	require.Nil(t, gotPR.Metadata)
+	require.Nil(t, gotPR.Provider)
+
+	resp := &model.FindResponse{
+		MultihashResults: []model.MultihashResult{
+			{
+				Multihash:       mhs[0],
+				ProviderResults: []model.ProviderResult{pr, pr},
+			},
+			{
+				Multihash:       mhs[1],
+				ProviderResults: []model.ProviderResult{pr},
+			},
+		},
+		EncryptedMultihashResults: []model.EncryptedMultihashResult{
+			{
+				Multihash:          mhs[2],
+				EncryptedValueKeys: [][]byte{[]byte("evk1"), []byte("evk2")},
+			},
+		},
+	}
+	data, err = model.MarshalFindResponseCBOR(resp)
+	require.NoError(t, err)
+	gotResp, err := model.UnmarshalFindResponseCBOR(data)
+	require.NoError(t, err)
+	require.Equal(t, resp, gotResp)
+
+	// CBOR is more compact than JSON.
+	jsonData, err := model.MarshalFindResponse(resp)
+	require.NoError(t, err)
+	require.Less(t, len(data), len(jsonData))
+
+	// Empty response.
+	data, err = model.MarshalFindResponseCBOR(&model.FindResponse{})
+	require.NoError(t, err)
+	gotResp, err = model.UnmarshalFindResponseCBOR(data)
+	require.NoError(t, err)
+	require.Empty(t, gotResp.MultihashResults)
+	require.Empty(t, gotResp.EncryptedMultihashResults)
+
+	_, err = model.UnmarshalFindResponseCBOR([]byte("not cbor"))
+	require.Error(t, err)
+}
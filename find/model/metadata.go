@@ -0,0 +1,116 @@
+package model
+
+import (
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/multiformats/go-multicodec"
+)
+
+// DecodedProviderResult is a ProviderResult along with its decoded metadata.
+type DecodedProviderResult struct {
+	ProviderResult
+	// DecodedMetadata is the result of decoding ProviderResult.Metadata.
+	DecodedMetadata metadata.Metadata `json:"-"`
+}
+
+// MetadataFilter returns true if a provider result having the given decoded
+// metadata should be kept.
+type MetadataFilter func(metadata.Metadata) bool
+
+// DecodeMetadata decodes the result's metadata using the given metadata
+// context. If mctx is nil, then metadata.Default is used. Protocols not known
+// to the metadata context are decoded as metadata.Unknown.
+func (pr ProviderResult) DecodeMetadata(mctx metadata.MetadataContext) (metadata.Metadata, error) {
+	if mctx == nil {
+		mctx = metadata.Default
+	}
+	md := mctx.New()
+	if err := md.UnmarshalBinary(pr.Metadata); err != nil {
+		return metadata.Metadata{}, err
+	}
+	return md, nil
+}
+
+// DecodeProviderResult returns the provider result with its decoded metadata.
+func DecodeProviderResult(pr ProviderResult, mctx metadata.MetadataContext) (DecodedProviderResult, error) {
+	md, err := pr.DecodeMetadata(mctx)
+	if err != nil {
+		return DecodedProviderResult{}, err
+	}
+	return DecodedProviderResult{
+		ProviderResult:  pr,
+		DecodedMetadata: md,
+	}, nil
+}
+
+// MatchMetadata returns true if the result's metadata can be decoded and
+// matches all the filters. If there are no filters, then true is returned
+// without decoding the metadata.
+func MatchMetadata(pr ProviderResult, mctx metadata.MetadataContext, filters ...MetadataFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	md, err := pr.DecodeMetadata(mctx)
+	if err != nil {
+		return false
+	}
+	for _, filter := range filters {
+		if !filter(md) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterResults returns the provider results whose metadata matches all the
+// filters. Results with metadata that cannot be decoded are removed. The
+// given slice is modified to hold the filtered results.
+func FilterResults(prs []ProviderResult, mctx metadata.MetadataContext, filters ...MetadataFilter) []ProviderResult {
+	if len(filters) == 0 {
+		return prs
+	}
+	filtered := prs[:0]
+	for _, pr := range prs {
+		if MatchMetadata(pr, mctx, filters...) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// FilterFindResponse applies FilterResults to each MultihashResult in the
+// response. MultihashResults left with no provider results are removed.
+func FilterFindResponse(r *FindResponse, mctx metadata.MetadataContext, filters ...MetadataFilter) {
+	if len(filters) == 0 {
+		return
+	}
+	mhrs := r.MultihashResults[:0]
+	for _, mhr := range r.MultihashResults {
+		mhr.ProviderResults = FilterResults(mhr.ProviderResults, mctx, filters...)
+		if len(mhr.ProviderResults) != 0 {
+			mhrs = append(mhrs, mhr)
+		}
+	}
+	r.MultihashResults = mhrs
+}
+
+// HasProtocol returns a MetadataFilter that matches metadata containing any of
+// the given protocols.
+func HasProtocol(protocols ...multicodec.Code) MetadataFilter {
+	return func(md metadata.Metadata) bool {
+		for _, p := range protocols {
+			if md.Get(p) != nil {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasFastRetrieval returns a MetadataFilter that matches metadata containing
+// GraphsyncFilecoinV1 with FastRetrieval set.
+func HasFastRetrieval() MetadataFilter {
+	return func(md metadata.Metadata) bool {
+		gs, ok := md.Get(multicodec.TransportGraphsyncFilecoinv1).(*metadata.GraphsyncFilecoinV1)
+		return ok && gs.FastRetrieval
+	}
+}
@@ -0,0 +1,82 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multicodec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterResults(t *testing.T) {
+	p, err := peer.Decode("12D3KooWKRyzVWW6ChFjQjK4miCty85Niy48tpPV95XdKu1BcvMA")
+	require.NoError(t, err)
+	pieceCid := test.RandomCids(1)[0]
+
+	makeResult := func(ctxID string, protos ...metadata.Protocol) model.ProviderResult {
+		md := metadata.Default.New(protos...)
+		mdBytes, err := md.MarshalBinary()
+		require.NoError(t, err)
+		return model.ProviderResult{
+			ContextID: []byte(ctxID),
+			Metadata:  mdBytes,
+			Provider:  &peer.AddrInfo{ID: p},
+		}
+	}
+
+	bitswap := makeResult("bitswap", &metadata.Bitswap{})
+	gateway := makeResult("gateway", &metadata.IpfsGatewayHttp{})
+	gsFast := makeResult("gs-fast", &metadata.GraphsyncFilecoinV1{PieceCID: pieceCid, FastRetrieval: true})
+	gsSlow := makeResult("gs-slow", &metadata.GraphsyncFilecoinV1{PieceCID: pieceCid})
+	bad := model.ProviderResult{
+		ContextID: []byte("bad"),
+		Metadata:  []byte{0xff},
+		Provider:  &peer.AddrInfo{ID: p},
+	}
+
+	dpr, err := model.DecodeProviderResult(gsFast, nil)
+	require.NoError(t, err)
+	gs, ok := dpr.DecodedMetadata.Get(multicodec.TransportGraphsyncFilecoinv1).(*metadata.GraphsyncFilecoinV1)
+	require.True(t, ok)
+	require.True(t, gs.FastRetrieval)
+	require.Equal(t, pieceCid, gs.PieceCID)
+
+	_, err = bad.DecodeMetadata(nil)
+	require.Error(t, err)
+
+	all := []model.ProviderResult{bitswap, gateway, gsFast, gsSlow, bad}
+
+	prs := model.FilterResults(append([]model.ProviderResult{}, all...), nil)
+	require.Len(t, prs, len(all))
+
+	prs = model.FilterResults(append([]model.ProviderResult{}, all...), nil, model.HasProtocol(multicodec.TransportIpfsGatewayHttp))
+	require.Len(t, prs, 1)
+	require.True(t, prs[0].Equal(gateway))
+
+	prs = model.FilterResults(append([]model.ProviderResult{}, all...), nil, model.HasFastRetrieval())
+	require.Len(t, prs, 1)
+	require.True(t, prs[0].Equal(gsFast))
+
+	prs = model.FilterResults(append([]model.ProviderResult{}, all...), nil,
+		model.HasProtocol(multicodec.TransportBitswap, multicodec.TransportGraphsyncFilecoinv1))
+	require.Len(t, prs, 3)
+
+	resp := &model.FindResponse{
+		MultihashResults: []model.MultihashResult{
+			{
+				Multihash:       test.RandomMultihashes(1)[0],
+				ProviderResults: []model.ProviderResult{bitswap},
+			},
+			{
+				Multihash:       test.RandomMultihashes(1)[0],
+				ProviderResults: []model.ProviderResult{gsFast, gsSlow},
+			},
+		},
+	}
+	model.FilterFindResponse(resp, nil, model.HasFastRetrieval())
+	require.Len(t, resp.MultihashResults, 1)
+	require.Len(t, resp.MultihashResults[0].ProviderResults, 1)
+}
@@ -46,6 +46,15 @@ type EncryptedMultihashResult struct {
 	EncryptedValueKeys [][]byte            `json:"EncryptedValueKeys,omitempty"`
 }
 
+// StreamResult is a single result in a streamed batch find response. Each
+// result is tagged with the multihash that it is a result for, and contains
+// either a provider result or the encrypted value keys for the multihash.
+type StreamResult struct {
+	Multihash multihash.Multihash
+	ProviderResult
+	EncryptedValueKeys [][]byte `json:"EncryptedValueKeys,omitempty"`
+}
+
 // Equal compares ProviderResult values to determine if they are equal. The
 // provider addresses are omitted from the comparison.
 func (pr ProviderResult) Equal(other ProviderResult) bool {
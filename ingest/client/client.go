@@ -83,6 +83,7 @@ func (c *Client) Announce(ctx context.Context, provider *peer.AddrInfo, root cid
 		return err
 	}
 	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", apierror.MediaTypeProblem)
 
 	resp, err := c.c.Do(req)
 	if err != nil {
@@ -90,15 +91,11 @@ func (c *Client) Announce(ctx context.Context, provider *peer.AddrInfo, root cid
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return apierror.FromResponse(resp.StatusCode, body)
+		return apierror.FromHTTPResponse(resp)
 	}
-	return nil
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
 }
 
 // IndexContent creates an index directly on the indexer. This bypasses the
@@ -116,6 +113,7 @@ func (c *Client) IndexContent(ctx context.Context, providerID peer.ID, privateKe
 		return err
 	}
 	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", apierror.MediaTypeProblem)
 
 	resp, err := c.c.Do(req)
 	if err != nil {
@@ -123,15 +121,11 @@ func (c *Client) IndexContent(ctx context.Context, providerID peer.ID, privateKe
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
 	if resp.StatusCode != http.StatusOK {
-		return apierror.FromResponse(resp.StatusCode, body)
+		return apierror.FromHTTPResponse(resp)
 	}
-	return nil
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
 }
 
 // Register registers a provider directly with an indexer. The primary use is
@@ -148,6 +142,7 @@ func (c *Client) Register(ctx context.Context, providerID peer.ID, privateKey p2
 		return err
 	}
 	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", apierror.MediaTypeProblem)
 
 	resp, err := c.c.Do(req)
 	if err != nil {
@@ -155,13 +150,9 @@ func (c *Client) Register(ctx context.Context, providerID peer.ID, privateKey p2
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
 	if resp.StatusCode != http.StatusOK {
-		return apierror.FromResponse(resp.StatusCode, body)
+		return apierror.FromHTTPResponse(resp)
 	}
-	return nil
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
 }
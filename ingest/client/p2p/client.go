@@ -0,0 +1,152 @@
+// Package p2pingestclient provides an ingest client that sends requests to an
+// indexer over libp2p instead of HTTP.
+//
+// Each request is a p2pclient pb.Message whose type is one of the message
+// types defined in this package. An indexer handles these requests using a
+// p2pserver.Server that registers handlers for the message types on
+// ProtocolID.
+//
+// The request and response data carried in the envelope are the protobuf
+// messages defined in the pb subpackage. Register and index requests carry
+// the signed record envelopes created by ingest/model, which are the same
+// bytes that are sent to the indexer HTTP API, so that an indexer verifies
+// requests from either transport with the same code. Error responses carry a
+// JSON encoded apierror.ErrorMessage, as described in p2pclient.
+package p2pingestclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipni/go-libipni/announce/message"
+	"github.com/ipni/go-libipni/ingest/client"
+	"github.com/ipni/go-libipni/ingest/client/p2p/pb"
+	"github.com/ipni/go-libipni/ingest/model"
+	"github.com/ipni/go-libipni/p2pclient"
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multihash"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtocolID is the libp2p protocol that an indexer handles ingest requests
+// on.
+const ProtocolID = protocol.ID("/ipni/ingest/1.0.0")
+
+// Message types of ingest requests. The response to each request has the same
+// type as the request.
+const (
+	// AnnounceMessageType is an announcement of a new advertisement chain
+	// head. The request data is a pb.AnnounceRequest, and the response data is
+	// a pb.AnnounceResponse.
+	AnnounceMessageType uint32 = iota + 1
+	// RegisterMessageType is a request to register a provider. The request
+	// data is a pb.RegisterRequest, and the response data is a
+	// pb.RegisterResponse.
+	RegisterMessageType
+	// IndexContentMessageType is a request to index a single multihash. The
+	// request data is a pb.IndexContentRequest, and the response data is a
+	// pb.IndexContentResponse.
+	IndexContentMessageType
+)
+
+// Client is a libp2p client for the indexer ingest API.
+type Client struct {
+	p2pc *p2pclient.Client
+}
+
+// Client must implement client.Interface.
+var _ client.Interface = (*Client)(nil)
+
+// New creates a new ingest client that sends requests to the indexer
+// identified by peerID. If host is nil, then one is created.
+func New(p2pHost host.Host, peerID peer.ID, options ...p2pclient.Option) (*Client, error) {
+	p2pc, err := p2pclient.New(p2pHost, peerID, ProtocolID, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		p2pc: p2pc,
+	}, nil
+}
+
+// Connect connects the client to the indexer at the location specified by
+// hostname. The value of hostname is a host or host:port, where the host is a
+// hostname or IP address.
+func (c *Client) Connect(ctx context.Context, hostname string) error {
+	return c.p2pc.Connect(ctx, hostname)
+}
+
+// ConnectAddrs connects the client to the indexer at any of the given
+// multiaddrs.
+func (c *Client) ConnectAddrs(ctx context.Context, maddrs ...multiaddr.Multiaddr) error {
+	return c.p2pc.ConnectAddrs(ctx, maddrs...)
+}
+
+// Close closes the client's streams to the indexer.
+func (c *Client) Close() error {
+	return c.p2pc.Close()
+}
+
+// Announce announces a new root CID directly to the indexer.
+func (c *Client) Announce(ctx context.Context, provider *peer.AddrInfo, root cid.Cid) error {
+	p2paddrs, err := peer.AddrInfoToP2pAddrs(provider)
+	if err != nil {
+		return err
+	}
+	msg := message.Message{
+		Cid: root,
+	}
+	if len(p2paddrs) != 0 {
+		msg.SetAddrs(p2paddrs)
+	}
+
+	return c.call(ctx, AnnounceMessageType, AnnounceToPB(&msg), &pb.AnnounceResponse{})
+}
+
+// IndexContent creates an index directly on the indexer. This bypasses the
+// process of ingesting advertisements. It is used to index special one-off
+// content that is outside of an advertisement chain, and is intentionally
+// limited to indexing a single multihash.
+func (c *Client) IndexContent(ctx context.Context, providerID peer.ID, privateKey p2pcrypto.PrivKey, m multihash.Multihash, contextID []byte, metadata []byte, addrs []string) error {
+	data, err := model.MakeIngestRequest(providerID, privateKey, m, contextID, metadata, addrs)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, IndexContentMessageType, &pb.IndexContentRequest{Envelope: data}, &pb.IndexContentResponse{})
+}
+
+// Register registers a provider directly with an indexer. The primary use is
+// update an indexer with new provider addresses without having to wait until a
+// new advertisement is ingested.
+func (c *Client) Register(ctx context.Context, providerID peer.ID, privateKey p2pcrypto.PrivKey, addrs []string) error {
+	data, err := model.MakeRegisterRequest(providerID, privateKey, addrs)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, RegisterMessageType, &pb.RegisterRequest{Envelope: data}, &pb.RegisterResponse{})
+}
+
+// call sends a request and decodes the response data into rsp, checking that
+// the response has the same type as the request.
+func (c *Client) call(ctx context.Context, msgType uint32, req, rsp proto.Message) error {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	msg, err := c.p2pc.Call(ctx, msgType, data)
+	if err != nil {
+		return err
+	}
+	if msg.GetType() != msgType {
+		return fmt.Errorf("response message type %d does not match request type %d", msg.GetType(), msgType)
+	}
+	if err = proto.Unmarshal(msg.GetData(), rsp); err != nil {
+		return fmt.Errorf("cannot decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package p2pingestclient_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ipni/go-libipni/announce/message"
+	"github.com/ipni/go-libipni/apierror"
+	p2pingestclient "github.com/ipni/go-libipni/ingest/client/p2p"
+	ingestpb "github.com/ipni/go-libipni/ingest/client/p2p/pb"
+	"github.com/ipni/go-libipni/ingest/model"
+	"github.com/ipni/go-libipni/p2pclient/pb"
+	"github.com/ipni/go-libipni/p2pserver"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestClient(t *testing.T) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	defer h.Close()
+	s, err := p2pserver.New(h, p2pingestclient.ProtocolID)
+	require.NoError(t, err)
+	defer s.Close()
+
+	var (
+		announced  *message.Message
+		registered *peer.PeerRecord
+		indexed    *model.IngestRequest
+	)
+	err = s.Handle(p2pingestclient.AnnounceMessageType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		var req ingestpb.AnnounceRequest
+		if err := proto.Unmarshal(msg.Data, &req); err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		var err error
+		if announced, err = p2pingestclient.AnnounceFromPB(&req); err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+	err = s.Handle(p2pingestclient.RegisterMessageType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		var req ingestpb.RegisterRequest
+		if err := proto.Unmarshal(msg.Data, &req); err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		rec, err := model.ReadRegisterRequest(req.Envelope)
+		if err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		registered = rec
+		return nil, nil
+	})
+	require.NoError(t, err)
+	err = s.Handle(p2pingestclient.IndexContentMessageType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		var pbReq ingestpb.IndexContentRequest
+		if err := proto.Unmarshal(msg.Data, &pbReq); err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		req, err := model.ReadIngestRequest(pbReq.Envelope)
+		if err != nil {
+			return nil, apierror.New(err, http.StatusBadRequest)
+		}
+		indexed = req
+		return nil, apierror.New(nil, http.StatusForbidden)
+	})
+	require.NoError(t, err)
+
+	c, err := p2pingestclient.New(nil, h.ID())
+	require.NoError(t, err)
+	defer c.Close()
+	ctx := context.Background()
+	require.NoError(t, c.ConnectAddrs(ctx, h.Addrs()...))
+
+	providerID, privKey, _ := test.RandomIdentity()
+	addrs := test.RandomAddrs(2)
+
+	root := test.RandomCids(1)[0]
+	provider := &peer.AddrInfo{
+		ID:    providerID,
+		Addrs: test.RandomMultiaddrs(1),
+	}
+	require.NoError(t, c.Announce(ctx, provider, root))
+	require.NotNil(t, announced)
+	require.Equal(t, root, announced.Cid)
+	maddrs, err := announced.GetAddrs()
+	require.NoError(t, err)
+	require.Len(t, maddrs, 1)
+
+	require.NoError(t, c.Register(ctx, providerID, privKey, addrs))
+	require.NotNil(t, registered)
+	require.Equal(t, providerID, registered.PeerID)
+	require.Len(t, registered.Addrs, 2)
+
+	mh := test.RandomMultihashes(1)[0]
+	err = c.IndexContent(ctx, providerID, privKey, mh, []byte("ctx-id"), []byte("metadata"), addrs)
+	require.True(t, apierror.IsCode(err, apierror.CodeForbidden))
+	require.NotNil(t, indexed)
+	require.Equal(t, mh, indexed.Multihash)
+	require.Equal(t, providerID, indexed.ProviderID)
+}
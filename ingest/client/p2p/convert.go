@@ -0,0 +1,34 @@
+package p2pingestclient
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipni/go-libipni/announce/message"
+	"github.com/ipni/go-libipni/ingest/client/p2p/pb"
+)
+
+// AnnounceToPB converts an announce message.Message to its protobuf message.
+func AnnounceToPB(msg *message.Message) *pb.AnnounceRequest {
+	return &pb.AnnounceRequest{
+		Cid:       msg.Cid.Bytes(),
+		Addrs:     msg.Addrs,
+		ExtraData: msg.ExtraData,
+		OrigPeer:  msg.OrigPeer,
+	}
+}
+
+// AnnounceFromPB converts an AnnounceRequest protobuf message to an announce
+// message.Message.
+func AnnounceFromPB(req *pb.AnnounceRequest) (*message.Message, error) {
+	c, err := cid.Cast(req.GetCid())
+	if err != nil {
+		return nil, fmt.Errorf("bad announce cid: %w", err)
+	}
+	return &message.Message{
+		Cid:       c,
+		Addrs:     req.GetAddrs(),
+		ExtraData: req.GetExtraData(),
+		OrigPeer:  req.GetOrigPeer(),
+	}, nil
+}
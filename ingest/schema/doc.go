@@ -19,4 +19,7 @@
 // All advertisements update the provider's addresses.  To create an
 // advertisement that only updates a provider's address, create an
 // advertisement to remove content using a context ID that is not used.
+//
+// Before publishing an advertisement, use Lint to find problems that would
+// cause indexers to reject it or that would make its content unretrievable.
 package schema
@@ -0,0 +1,197 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/ipni/go-libipni/mautil"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// Severity is the severity of a lint Finding.
+type Severity int
+
+const (
+	// SeverityInfo is for information that may be useful, but does not
+	// indicate a problem.
+	SeverityInfo Severity = iota
+	// SeverityWarning is for problems that do not cause an indexer to reject
+	// the advertisement, but may make the advertised content unretrievable.
+	SeverityWarning
+	// SeverityError is for problems that cause an indexer to reject the
+	// advertisement or ignore part of it.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Finding describes a problem found by Lint.
+type Finding struct {
+	// Severity is the severity of the problem.
+	Severity Severity
+	// Field is the path to the advertisement field that has the problem, such
+	// as "Addresses[1]" or "ExtendedProvider.Providers[0].Signature".
+	Field string
+	// Message describes the problem.
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Field, f.Message)
+}
+
+// HasErrors returns true if any of the findings has SeverityError.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+type linter struct {
+	findings []Finding
+}
+
+func (l *linter) add(severity Severity, field, format string, args ...any) {
+	l.findings = append(l.findings, Finding{
+		Severity: severity,
+		Field:    field,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Lint checks an advertisement for problems that would cause an indexer to
+// reject it, or that would make its content unretrievable. It checks the
+// limits enforced by Validate, that metadata can be decoded, that addresses
+// are valid public multiaddrs, that signatures are valid, and that extended
+// providers follow the rules described in the advertisement schema.
+//
+// Findings are returned in the order found. Use HasErrors to check whether
+// any of the findings are errors.
+func Lint(ad *Advertisement) []Finding {
+	var l linter
+
+	provID, err := peer.Decode(ad.Provider)
+	if err != nil {
+		l.add(SeverityError, "Provider", "invalid peer id: %s", err)
+	}
+
+	if err = validateContextID(ad.ContextID); err != nil {
+		l.add(SeverityError, "ContextID", "%s", err)
+	}
+
+	if ad.Entries == nil {
+		l.add(SeverityError, "Entries", "missing entries link")
+	}
+
+	if err = validateMetadataLen(ad.Metadata); err != nil {
+		l.add(SeverityError, "Metadata", "%s", err)
+	} else if !ad.IsRm {
+		l.lintMetadata("Metadata", ad.Metadata)
+	}
+
+	if len(ad.Addresses) == 0 && !ad.IsRm && ad.ExtendedProvider == nil {
+		l.add(SeverityWarning, "Addresses", "no addresses, previously advertised addresses are used")
+	}
+	// All advertisements update the provider's addresses.
+	l.lintAddrs("Addresses", ad.Addresses)
+
+	l.lintSignature(ad, provID)
+
+	if ad.ExtendedProvider != nil {
+		l.lintExtendedProvider(ad)
+	}
+
+	return l.findings
+}
+
+func (l *linter) lintMetadata(field string, md []byte) {
+	if len(md) == 0 {
+		l.add(SeverityError, field, "missing metadata")
+		return
+	}
+	_, report, _ := metadata.Decode(metadata.Default, md, metadata.DecodeLenient)
+	for _, d := range report.Diagnostics {
+		severity := SeverityWarning
+		if d.Level == metadata.DiagnosticError {
+			severity = SeverityError
+		}
+		l.add(severity, field, "%s", d)
+	}
+}
+
+func (l *linter) lintAddrs(field string, addrs []string) {
+	for i, addr := range addrs {
+		addrField := fmt.Sprintf("%s[%d]", field, i)
+		maddr, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			l.add(SeverityError, addrField, "invalid multiaddr %q: %s", addr, err)
+			continue
+		}
+		if len(mautil.FilterPublic([]multiaddr.Multiaddr{maddr})) == 0 {
+			l.add(SeverityWarning, addrField, "multiaddr %s is not publicly reachable", addr)
+		}
+	}
+}
+
+func (l *linter) lintSignature(ad *Advertisement, provID peer.ID) {
+	if len(ad.Signature) == 0 {
+		l.add(SeverityError, "Signature", "missing signature")
+		return
+	}
+	signer, err := ad.VerifySignature()
+	if err != nil {
+		l.add(SeverityError, "Signature", "invalid signature: %s", err)
+		return
+	}
+	if provID != "" && signer != provID {
+		l.add(SeverityInfo, "Signature", "signed by %s instead of provider, indexer must allow publisher to sign for provider", signer)
+	}
+}
+
+func (l *linter) lintExtendedProvider(ad *Advertisement) {
+	const field = "ExtendedProvider"
+	xp := ad.ExtendedProvider
+
+	if xp.Override && len(ad.ContextID) == 0 {
+		l.add(SeverityError, field+".Override", "override set on advertisement without context id, extended providers are ignored")
+	}
+	if len(xp.Providers) == 0 {
+		l.add(SeverityWarning, field+".Providers", "no extended providers")
+		return
+	}
+
+	var seenProvider bool
+	for i, p := range xp.Providers {
+		pField := fmt.Sprintf("%s.Providers[%d]", field, i)
+		if _, err := peer.Decode(p.ID); err != nil {
+			l.add(SeverityError, pField+".ID", "invalid peer id: %s", err)
+		}
+		if p.ID == ad.Provider {
+			seenProvider = true
+		}
+		if len(p.Signature) == 0 {
+			l.add(SeverityError, pField+".Signature", "missing signature")
+		}
+		if len(p.Metadata) != 0 {
+			l.lintMetadata(pField+".Metadata", p.Metadata)
+		}
+		l.lintAddrs(pField+".Addresses", p.Addresses)
+	}
+	if !seenProvider {
+		l.add(SeverityError, field+".Providers", "advertisement provider %s missing from extended providers", ad.Provider)
+	}
+}
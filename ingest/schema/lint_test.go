@@ -0,0 +1,84 @@
+package schema_test
+
+import (
+	"bytes"
+	"testing"
+
+	stischema "github.com/ipni/go-libipni/ingest/schema"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	provID, provKey, _ := test.RandomIdentity()
+	xpID, _, _ := test.RandomIdentity()
+
+	md := metadata.Default.New(&metadata.Bitswap{})
+	mdBytes, err := md.MarshalBinary()
+	require.NoError(t, err)
+
+	ad := &stischema.Advertisement{
+		Provider:  provID.String(),
+		Addresses: []string{"/dns4/example.com/tcp/24001"},
+		Entries:   stischema.NoEntries,
+		ContextID: []byte("ctx"),
+		Metadata:  mdBytes,
+	}
+	require.NoError(t, ad.Sign(provKey))
+	findings := stischema.Lint(ad)
+	require.Empty(t, findings)
+	require.False(t, stischema.HasErrors(findings))
+
+	// Bad addresses and oversized context ID.
+	ad.Addresses = []string{"/ip4/127.0.0.1/tcp/24001", "not-a-multiaddr"}
+	ad.ContextID = bytes.Repeat([]byte{1}, stischema.MaxContextIDLen+1)
+	require.NoError(t, ad.Sign(provKey))
+	findings = stischema.Lint(ad)
+	requireFinding(t, findings, stischema.SeverityWarning, "Addresses[0]")
+	requireFinding(t, findings, stischema.SeverityError, "Addresses[1]")
+	requireFinding(t, findings, stischema.SeverityError, "ContextID")
+	require.True(t, stischema.HasErrors(findings))
+	// Lint reports the same problem that Validate does.
+	require.ErrorContains(t, ad.Validate(), "context id too long")
+
+	// Undecodable metadata and invalid signature.
+	ad.Addresses = nil
+	ad.ContextID = nil
+	ad.Metadata = []byte{0xff}
+	findings = stischema.Lint(ad)
+	requireFinding(t, findings, stischema.SeverityError, "Metadata")
+	requireFinding(t, findings, stischema.SeverityError, "Signature")
+	requireFinding(t, findings, stischema.SeverityWarning, "Addresses")
+
+	// Extended providers without signatures and override without context ID.
+	ad.Metadata = mdBytes
+	ad.ExtendedProvider = &stischema.ExtendedProvider{
+		Providers: []stischema.Provider{
+			{ID: xpID.String(), Addresses: []string{"/dns4/xp.example.com/tcp/24001"}},
+		},
+		Override: true,
+	}
+	// Signing fails since the provider is not in the extended providers, but
+	// the signatures are still set.
+	err = ad.SignWithExtendedProviders(provKey, func(string) (crypto.PrivKey, error) {
+		return provKey, nil
+	})
+	require.Error(t, err)
+	ad.ExtendedProvider.Providers[0].Signature = nil
+	findings = stischema.Lint(ad)
+	requireFinding(t, findings, stischema.SeverityError, "ExtendedProvider.Override")
+	requireFinding(t, findings, stischema.SeverityError, "ExtendedProvider.Providers[0].Signature")
+	requireFinding(t, findings, stischema.SeverityError, "ExtendedProvider.Providers")
+}
+
+func requireFinding(t *testing.T, findings []stischema.Finding, severity stischema.Severity, field string) {
+	t.Helper()
+	for _, f := range findings {
+		if f.Field == field && f.Severity == severity {
+			return
+		}
+	}
+	require.Failf(t, "finding not found", "no %s finding for %s in %v", severity, field, findings)
+}
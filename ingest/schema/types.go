@@ -83,14 +83,27 @@ func UnwrapAdvertisement(node ipld.Node) (*Advertisement, error) {
 }
 
 func (a Advertisement) Validate() error {
-	if len(a.ContextID) > MaxContextIDLen {
-		return errors.New("context id too long")
+	if err := validateContextID(a.ContextID); err != nil {
+		return err
 	}
+	return validateMetadataLen(a.Metadata)
+}
 
-	if len(a.Metadata) > MaxMetadataLen {
-		return errors.New("metadata too long")
+// validateContextID checks the context ID limits enforced by Validate, which
+// Lint also reports.
+func validateContextID(contextID []byte) error {
+	if len(contextID) > MaxContextIDLen {
+		return fmt.Errorf("context id too long: length %d exceeds maximum %d", len(contextID), MaxContextIDLen)
 	}
+	return nil
+}
 
+// validateMetadataLen checks the metadata limits enforced by Validate, which
+// Lint also reports.
+func validateMetadataLen(md []byte) error {
+	if len(md) > MaxMetadataLen {
+		return fmt.Errorf("metadata too long: length %d exceeds maximum %d", len(md), MaxMetadataLen)
+	}
 	return nil
 }
 
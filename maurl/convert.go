@@ -2,14 +2,24 @@ package maurl
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/multiformats/go-multiaddr"
-	manet "github.com/multiformats/go-multiaddr/net"
 )
 
+// ErrLossy is returned, wrapped in a more descriptive error, when a conversion
+// cannot be done without losing information. Use errors.Is to check for it.
+var ErrLossy = errors.New("conversion loses information")
+
+// unixHost is the URL host used to indicate that the URL path begins with the
+// path of a unix domain socket, as in "http://unix:/path/to/socket:/url/path".
+const unixHost = "unix:"
+
 // register an 'httpath' component:
 var transcodePath = multiaddr.NewTranscoderFromFunctions(pathStB, pathBtS, pathVal)
 
@@ -40,110 +50,299 @@ var protoHTTPath = multiaddr.Protocol{
 	Transcoder: transcodePath,
 }
 
-// ToURL converts to a URL when given a multiaddr of the one of the forms:
+// ToURL converts a multiaddr to a URL. The multiaddr has the form:
+//
+//	<host>[/tcp/<port>][/tls[/sni/<name>]][/<http|https|ws|wss>][/httpath/<path>...][/p2p/<id>]
+//
+// where host is one of /ip4/<addr>, /ip6/<addr>, /ip6zone/<zone>/ip6/<addr>,
+// /dns/<name>, /dns4/<name>, /dns6/<name>, or /unix/<path>. For example:
+//
+//	/dns/thing.com/tcp/443/tls/sni/thing.com/http/httpath/urlescape<path/to/root>
+//	/ip4/192.168.0.1/tcp/80/http
+//	/unix/path/to/socket/http
+//
+// The /tls/http and /https forms are both converted to the "https" scheme, and
+// /tls/ws and /wss to "wss". If there is no http or ws component, then the
+// scheme is "http", or "https" if there is a tls component. The URL has a port
+// only if the multiaddr has a tcp component, so a multiaddr without one uses
+// the default port for the scheme. Multiple httpath components are joined to
+// form a multi-segment path. A trailing /p2p component is ignored, since the
+// peer ID is not part of the URL.
+//
+// A unix domain socket is converted to a URL of the form
+// "http://unix:/path/to/socket:/url/path". The string form of a unix multiaddr
+// includes everything after /unix as the socket path, so the socket path ends
+// before the first component that is tls, http, https, ws, or wss.
 //
-//	/dns/thing.com/http/urlescape<path/to/root>
-//	/ip/192.168.0.1/tcp/80/http
+// An error that wraps ErrLossy is returned if the multiaddr has an SNI name
+// that differs from the host, since the URL cannot represent it. An error is
+// also returned for multiaddrs that do not use TCP or a unix socket.
 func ToURL(ma multiaddr.Multiaddr) (*url.URL, error) {
-	// host should be either the dns name or the IP
-	_, host, err := manet.DialArgs(ma)
+	comps, err := splitComponents(ma)
 	if err != nil {
 		return nil, err
 	}
-	if ip := net.ParseIP(host); ip != nil {
-		if !ip.To4().Equal(ip) {
-			// raw v6 IPs need `[ip]` encapsulation.
-			host = fmt.Sprintf("[%s]", host)
+	if len(comps) == 0 {
+		return nil, errors.New("empty multiaddr")
+	}
+
+	var host, sni, zone string
+	var unix bool
+
+	if comps[0].Protocol().Code == multiaddr.P_IP6ZONE {
+		zone = comps[0].Value()
+		comps = comps[1:]
+		if len(comps) == 0 || comps[0].Protocol().Code != multiaddr.P_IP6 {
+			return nil, fmt.Errorf("ip6zone not followed by ip6 in %s", ma)
 		}
 	}
+	switch c := comps[0]; c.Protocol().Code {
+	case multiaddr.P_IP4, multiaddr.P_DNS, multiaddr.P_DNS4, multiaddr.P_DNS6:
+		host = c.Value()
+	case multiaddr.P_IP6:
+		host = c.Value()
+		if zone != "" {
+			host += "%" + zone
+		}
+		host = "[" + host + "]"
+	case multiaddr.P_UNIX:
+		host = c.Value()
+		unix = true
+	default:
+		return nil, fmt.Errorf("cannot convert %s to url: unsupported host protocol %s", ma, c.Protocol().Name)
+	}
+	comps = comps[1:]
+
+	var port string
+	if !unix && len(comps) != 0 && comps[0].Protocol().Code == multiaddr.P_TCP {
+		port = comps[0].Value()
+		comps = comps[1:]
+	}
 
-	protos := ma.Protocols()
-	pm := make(map[int]string, len(protos))
-	for _, p := range protos {
-		v, err := ma.ValueForProtocol(p.Code)
-		if err == nil {
-			pm[p.Code] = v
+	var secure bool
+	if len(comps) != 0 && comps[0].Protocol().Code == multiaddr.P_TLS {
+		secure = true
+		comps = comps[1:]
+		if len(comps) != 0 && comps[0].Protocol().Code == multiaddr.P_SNI {
+			sni = comps[0].Value()
+			comps = comps[1:]
 		}
 	}
 
 	scheme := "http"
-	if _, ok := pm[multiaddr.P_HTTPS]; ok {
-		scheme = "https"
-	} else if _, ok = pm[multiaddr.P_HTTP]; ok {
-		// /tls/http == /https
-		if _, ok = pm[multiaddr.P_TLS]; ok {
-			scheme = "https"
-		}
-	} else if _, ok = pm[multiaddr.P_WSS]; ok {
-		scheme = "wss"
-	} else if _, ok = pm[multiaddr.P_WS]; ok {
-		scheme = "ws"
-		// /tls/ws == /wss
-		if _, ok = pm[multiaddr.P_TLS]; ok {
-			scheme = "wss"
-		}
-	}
-
-	path := ""
-	if pb, ok := pm[protoHTTPath.Code]; ok {
-		path, err = url.PathUnescape(pb)
+	if len(comps) != 0 {
+		switch comps[0].Protocol().Code {
+		case multiaddr.P_HTTP:
+			comps = comps[1:]
+		case multiaddr.P_HTTPS:
+			secure = true
+			comps = comps[1:]
+		case multiaddr.P_WS:
+			scheme = "ws"
+			comps = comps[1:]
+		case multiaddr.P_WSS:
+			scheme = "ws"
+			secure = true
+			comps = comps[1:]
+		}
+	}
+	if secure {
+		scheme += "s"
+	}
+
+	var path strings.Builder
+	for len(comps) != 0 && comps[0].Protocol().Code == protoHTTPath.Code {
+		seg, err := url.PathUnescape(comps[0].Value())
 		if err != nil {
-			path = ""
+			return nil, fmt.Errorf("cannot unescape httpath in %s: %w", ma, err)
+		}
+		path.WriteByte('/')
+		path.WriteString(strings.TrimPrefix(seg, "/"))
+		comps = comps[1:]
+	}
+
+	if len(comps) == 1 && comps[0].Protocol().Code == multiaddr.P_P2P {
+		comps = nil
+	}
+	if len(comps) != 0 {
+		return nil, fmt.Errorf("cannot convert %s to url: unsupported protocol %s", ma, comps[0].Protocol().Name)
+	}
+
+	if sni != "" && sni != strings.Trim(host, "[]") {
+		return nil, fmt.Errorf("%w: url cannot contain sni %q that differs from host %q", ErrLossy, sni, host)
+	}
+
+	if unix {
+		if strings.Contains(host, ":") {
+			return nil, fmt.Errorf("%w: unix socket path %q contains a colon", ErrLossy, host)
 		}
+		return &url.URL{
+			Scheme: scheme,
+			Host:   unixHost,
+			Path:   host + ":" + path.String(),
+		}, nil
 	}
 
-	out := url.URL{
+	if port != "" {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+	return &url.URL{
 		Scheme: scheme,
 		Host:   host,
-		Path:   path,
-	}
-	return &out, nil
+		Path:   path.String(),
+	}, nil
 }
 
-// FromURL takes a URL and converts it into a multiaddr.
+// FromURL converts a URL into a multiaddr. The URL scheme must be one of
+// http, https, ws, or wss.
 //
-// converts scheme://host:port/path -> /ip/host/tcp/port/scheme/urlescape{path}
+// converts scheme://host:port/path -> /ip4/host/tcp/port/scheme/httpath/urlescape{path}
+//
+// A host name is converted to /dns, since a URL does not specify an address
+// family, and an IP address is converted to /ip4 or /ip6, with /ip6zone if the
+// address has a zone. The multiaddr has a tcp component only if the URL has a
+// port, so that ToURL converts it back to the same URL. A URL of the form
+// "http://unix:/path/to/socket:/url/path" is converted to a /unix multiaddr.
+//
+// The URL query and fragment are not represented in the multiaddr and are
+// dropped. An error that wraps ErrLossy is returned if the URL contains user
+// info or a path that is not in its default encoding.
 func FromURL(u *url.URL) (multiaddr.Multiaddr, error) {
-	h := u.Hostname()
-	var addr *multiaddr.Multiaddr
-	if n := net.ParseIP(h); n != nil {
-		ipAddr, err := manet.FromIP(n)
+	switch {
+	case u.Opaque != "":
+		return nil, fmt.Errorf("%w: multiaddr cannot contain opaque url data", ErrLossy)
+	case u.User != nil:
+		return nil, fmt.Errorf("%w: multiaddr cannot contain url user info", ErrLossy)
+	case u.RawPath != "":
+		return nil, fmt.Errorf("%w: multiaddr cannot contain url path encoding %q", ErrLossy, u.RawPath)
+	}
+
+	var appProto int
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		appProto = multiaddr.P_HTTP
+	case "https":
+		appProto = multiaddr.P_HTTPS
+	case "ws":
+		appProto = multiaddr.P_WS
+	case "wss":
+		appProto = multiaddr.P_WSS
+	default:
+		return nil, fmt.Errorf("cannot convert url to multiaddr: unsupported scheme %q", u.Scheme)
+	}
+
+	var comps []multiaddr.Multiaddr
+	add := func(code int, value string) error {
+		c, err := multiaddr.NewComponent(multiaddr.ProtocolWithCode(code).Name, value)
 		if err != nil {
+			return err
+		}
+		comps = append(comps, c)
+		return nil
+	}
+
+	path := u.Path
+	if u.Host == unixHost {
+		sock, urlPath, ok := strings.Cut(u.Path, ":")
+		if !ok || sock == "" {
+			return nil, errors.New("unix url missing socket path")
+		}
+		if err := add(multiaddr.P_UNIX, sock); err != nil {
 			return nil, err
 		}
-		addr = &ipAddr
+		path = urlPath
 	} else {
-		// domain name
-		ma, err := multiaddr.NewComponent(multiaddr.ProtocolWithCode(multiaddr.P_DNS).Name, h)
-		if err != nil {
-			return nil, err
+		h := u.Hostname()
+		if h == "" {
+			return nil, errors.New("url missing host")
+		}
+		ipStr, zone, _ := strings.Cut(h, "%")
+		if ip := net.ParseIP(ipStr); ip != nil {
+			if ip4 := ip.To4(); ip4 != nil {
+				if zone != "" {
+					return nil, fmt.Errorf("ipv4 address %s cannot have zone", ipStr)
+				}
+				if err := add(multiaddr.P_IP4, ip4.String()); err != nil {
+					return nil, err
+				}
+			} else {
+				if zone != "" {
+					if err := add(multiaddr.P_IP6ZONE, zone); err != nil {
+						return nil, err
+					}
+				}
+				if err := add(multiaddr.P_IP6, ip.String()); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			if err := add(multiaddr.P_DNS, h); err != nil {
+				return nil, err
+			}
+		}
+
+		if port := u.Port(); port != "" {
+			if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+				return nil, fmt.Errorf("invalid url port %q", port)
+			}
+			if err := add(multiaddr.P_TCP, port); err != nil {
+				return nil, err
+			}
 		}
-		mab := multiaddr.Cast(ma.Bytes())
-		addr = &mab
 	}
-	pv := u.Port()
-	if pv != "" {
-		port, err := multiaddr.NewComponent(multiaddr.ProtocolWithCode(multiaddr.P_TCP).Name, pv)
+
+	if err := add(appProto, ""); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		httpath, err := multiaddr.NewComponent(protoHTTPath.Name, url.PathEscape(path))
 		if err != nil {
 			return nil, err
 		}
-		wport := multiaddr.Join(*addr, port)
-		addr = &wport
+		comps = append(comps, httpath)
 	}
 
-	http, err := multiaddr.NewComponent(u.Scheme, "")
-	if err != nil {
-		return nil, err
+	return multiaddr.Join(comps...), nil
+}
+
+// splitComponents splits a multiaddr into its components. The string form of
+// a /unix multiaddr treats the remainder of the multiaddr as the socket path,
+// so components that follow the socket path are split out of the path.
+func splitComponents(ma multiaddr.Multiaddr) ([]multiaddr.Component, error) {
+	var comps []multiaddr.Component
+	multiaddr.ForEach(ma, func(c multiaddr.Component) bool {
+		comps = append(comps, c)
+		return true
+	})
+	if len(comps) != 1 || comps[0].Protocol().Code != multiaddr.P_UNIX {
+		return comps, nil
 	}
 
-	joint := multiaddr.Join(*addr, http)
-	if u.Path != "" {
-		httpath, err := multiaddr.NewComponent(protoHTTPath.Name, url.PathEscape(u.Path))
+	sock := comps[0].Value()
+	for i := 1; i < len(sock); i++ {
+		if sock[i] != '/' {
+			continue
+		}
+		rest, err := multiaddr.NewMultiaddr(sock[i:])
+		if err != nil {
+			continue
+		}
+		switch rest.Protocols()[0].Code {
+		case multiaddr.P_TLS, multiaddr.P_HTTP, multiaddr.P_HTTPS, multiaddr.P_WS, multiaddr.P_WSS:
+		default:
+			continue
+		}
+		unix, err := multiaddr.NewComponent(multiaddr.ProtocolWithCode(multiaddr.P_UNIX).Name, sock[:i])
 		if err != nil {
 			return nil, err
 		}
-		joint = multiaddr.Join(joint, httpath)
+		comps = []multiaddr.Component{*unix}
+		multiaddr.ForEach(rest, func(c multiaddr.Component) bool {
+			comps = append(comps, c)
+			return true
+		})
+		break
 	}
-
-	return joint, nil
+	return comps, nil
 }
@@ -1,10 +1,20 @@
 // Package maurl converts between multiaddrs and URLs.
 //
-// Importing this package registers a new multiaddr protocol named "httppath"
+// Importing this package registers a new multiaddr protocol named "httpath"
 // that allows the path part of a URL to be encoded into a multiaddr as a
 // url.PathEscape encoded string.
 //
 // Representing a URL as a mutliaddr may be necessary when announcing content
 // advertisement publishers that publish via HTTP, or when advertising content
 // providers that have HTTP addresses.
+//
+// Conversions are round-trippable: a URL converted to a multiaddr by FromURL
+// is converted back to the same URL by ToURL, except for the URL query and
+// fragment, which are dropped. FromURL encodes a secure URL as /https or /wss,
+// and adds a tcp component only if the URL has a port, as earlier versions
+// did. ToURL also accepts equivalent multiaddr forms, such as /tls/http and
+// /tls/sni/<host>/http, or /dns4 and /dns, which convert to the same URL, and
+// ignores a trailing /p2p component. When a conversion would lose information
+// that matters for reaching the server, such as URL user info or an SNI name
+// that differs from the host, an error wrapping ErrLossy is returned.
 package maurl
@@ -0,0 +1,300 @@
+package mautil
+
+import (
+	"sort"
+
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Transport identifies how a multiaddr is dialed.
+type Transport int
+
+const (
+	// TransportUnknown is a multiaddr that does not use a known transport.
+	TransportUnknown Transport = iota
+	// TransportHTTP is plain HTTP.
+	TransportHTTP
+	// TransportHTTPS is HTTP over TLS.
+	TransportHTTPS
+	// TransportTCP is libp2p over TCP.
+	TransportTCP
+	// TransportQUIC is libp2p over QUIC.
+	TransportQUIC
+	// TransportWebTransport is libp2p over WebTransport.
+	TransportWebTransport
+	// TransportWebSocket is libp2p over WebSocket.
+	TransportWebSocket
+)
+
+func (t Transport) String() string {
+	switch t {
+	case TransportHTTP:
+		return "http"
+	case TransportHTTPS:
+		return "https"
+	case TransportTCP:
+		return "libp2p-tcp"
+	case TransportQUIC:
+		return "quic"
+	case TransportWebTransport:
+		return "webtransport"
+	case TransportWebSocket:
+		return "websocket"
+	}
+	return "unknown"
+}
+
+// Reachability describes from where a multiaddr can be reached.
+type Reachability int
+
+const (
+	// ReachUnknown is a multiaddr whose reachability cannot be determined.
+	ReachUnknown Reachability = iota
+	// ReachPublic is a multiaddr that is reachable from the internet.
+	ReachPublic
+	// ReachPrivate is a multiaddr that is only reachable on a private
+	// network. This includes link-local and unspecified addresses.
+	ReachPrivate
+	// ReachLoopback is a multiaddr that is only reachable from the same host.
+	// This includes unix domain sockets.
+	ReachLoopback
+	// ReachRelay is a multiaddr that is reached through a libp2p relay.
+	ReachRelay
+)
+
+func (r Reachability) String() string {
+	switch r {
+	case ReachPublic:
+		return "public"
+	case ReachPrivate:
+		return "private"
+	case ReachLoopback:
+		return "loopback"
+	case ReachRelay:
+		return "relay"
+	}
+	return "unknown"
+}
+
+// IPFamily is the IP address family of a multiaddr.
+type IPFamily int
+
+const (
+	// FamilyNone is a multiaddr that does not use IP, such as a unix socket.
+	FamilyNone IPFamily = iota
+	// FamilyIPv4 is an IPv4 address or a /dns4 name.
+	FamilyIPv4
+	// FamilyIPv6 is an IPv6 address or a /dns6 name.
+	FamilyIPv6
+	// FamilyAny is a /dns or /dnsaddr name that may resolve to either family.
+	FamilyAny
+)
+
+func (f IPFamily) String() string {
+	switch f {
+	case FamilyIPv4:
+		return "ip4"
+	case FamilyIPv6:
+		return "ip6"
+	case FamilyAny:
+		return "any"
+	}
+	return "none"
+}
+
+// AddrClass is the classification of a multiaddr.
+type AddrClass struct {
+	Transport    Transport
+	Reachability Reachability
+	Family       IPFamily
+}
+
+// Classify determines the transport, reachability, and IP family of a
+// multiaddr.
+func Classify(maddr multiaddr.Multiaddr) AddrClass {
+	var class AddrClass
+	if maddr == nil {
+		return class
+	}
+
+	var hasTLS, hasTCP bool
+	multiaddr.ForEach(maddr, func(c multiaddr.Component) bool {
+		switch c.Protocol().Code {
+		case multiaddr.P_CIRCUIT:
+			class.Reachability = ReachRelay
+		case multiaddr.P_TLS:
+			hasTLS = true
+		case multiaddr.P_TCP:
+			hasTCP = true
+		case multiaddr.P_HTTPS:
+			setTransport(&class, TransportHTTPS)
+		case multiaddr.P_HTTP:
+			if hasTLS {
+				setTransport(&class, TransportHTTPS)
+			} else {
+				setTransport(&class, TransportHTTP)
+			}
+		case multiaddr.P_QUIC, multiaddr.P_QUIC_V1:
+			setTransport(&class, TransportQUIC)
+		case multiaddr.P_WEBTRANSPORT:
+			// WebTransport runs over QUIC, so it replaces the QUIC transport.
+			class.Transport = TransportWebTransport
+		case multiaddr.P_WS, multiaddr.P_WSS:
+			setTransport(&class, TransportWebSocket)
+		}
+		return true
+	})
+	if class.Transport == TransportUnknown && hasTCP {
+		class.Transport = TransportTCP
+	}
+
+	first, _ := multiaddr.SplitFirst(maddr)
+	if first == nil {
+		return class
+	}
+	switch first.Protocol().Code {
+	case multiaddr.P_IP4:
+		class.Family = FamilyIPv4
+	case multiaddr.P_IP6, multiaddr.P_IP6ZONE:
+		class.Family = FamilyIPv6
+	case multiaddr.P_DNS4:
+		class.Family = FamilyIPv4
+	case multiaddr.P_DNS6:
+		class.Family = FamilyIPv6
+	case multiaddr.P_DNS, multiaddr.P_DNSADDR:
+		class.Family = FamilyAny
+	}
+
+	if class.Reachability == ReachRelay {
+		return class
+	}
+	switch first.Protocol().Code {
+	case multiaddr.P_IP4, multiaddr.P_IP6, multiaddr.P_IP6ZONE:
+		switch {
+		case manet.IsIPLoopback(maddr):
+			class.Reachability = ReachLoopback
+		case manet.IsPublicAddr(maddr) && !manet.IsIPUnspecified(maddr):
+			class.Reachability = ReachPublic
+		default:
+			class.Reachability = ReachPrivate
+		}
+	case multiaddr.P_DNS, multiaddr.P_DNS4, multiaddr.P_DNS6, multiaddr.P_DNSADDR:
+		if first.Value() == "localhost" {
+			class.Reachability = ReachLoopback
+		} else {
+			class.Reachability = ReachPublic
+		}
+	case multiaddr.P_UNIX:
+		class.Reachability = ReachLoopback
+	}
+	return class
+}
+
+// setTransport sets the transport if it is not already set by a preceding
+// component.
+func setTransport(class *AddrClass, t Transport) {
+	if class.Transport == TransportUnknown {
+		class.Transport = t
+	}
+}
+
+var (
+	defaultReachabilityOrder = []Reachability{ReachPublic, ReachPrivate, ReachLoopback, ReachRelay}
+	defaultTransportOrder    = []Transport{TransportHTTPS, TransportHTTP, TransportQUIC, TransportWebTransport, TransportTCP, TransportWebSocket}
+	defaultFamilyOrder       = []IPFamily{FamilyAny, FamilyIPv4, FamilyIPv6}
+)
+
+type rankConfig struct {
+	reachability []Reachability
+	transports   []Transport
+	families     []IPFamily
+}
+
+// RankOption configures how Rank orders multiaddrs.
+type RankOption func(*rankConfig)
+
+// WithReachabilityOrder sets the order of preference for reachability. The
+// default order is public, private, loopback, relay.
+func WithReachabilityOrder(order ...Reachability) RankOption {
+	return func(c *rankConfig) {
+		c.reachability = order
+	}
+}
+
+// WithTransportOrder sets the order of preference for transports. The default
+// order is https, http, quic, webtransport, libp2p-tcp, websocket.
+func WithTransportOrder(order ...Transport) RankOption {
+	return func(c *rankConfig) {
+		c.transports = order
+	}
+}
+
+// WithFamilyOrder sets the order of preference for IP families. The default
+// order is any (DNS names), IPv4, IPv6.
+func WithFamilyOrder(order ...IPFamily) RankOption {
+	return func(c *rankConfig) {
+		c.families = order
+	}
+}
+
+// Rank returns a new slice containing the multiaddrs ordered from most to
+// least preferred. Multiaddrs are ordered first by reachability, then by
+// transport, then by IP family. Values not present in an order of preference
+// are ranked after those that are. Multiaddrs that rank equally keep their
+// original relative order.
+func Rank(maddrs []multiaddr.Multiaddr, options ...RankOption) []multiaddr.Multiaddr {
+	if len(maddrs) == 0 {
+		return nil
+	}
+	cfg := rankConfig{
+		reachability: defaultReachabilityOrder,
+		transports:   defaultTransportOrder,
+		families:     defaultFamilyOrder,
+	}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	type ranked struct {
+		maddr multiaddr.Multiaddr
+		key   [3]int
+	}
+	rs := make([]ranked, len(maddrs))
+	for i, maddr := range maddrs {
+		class := Classify(maddr)
+		rs[i] = ranked{
+			maddr: maddr,
+			key: [3]int{
+				indexOf(cfg.reachability, class.Reachability),
+				indexOf(cfg.transports, class.Transport),
+				indexOf(cfg.families, class.Family),
+			},
+		}
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		a, b := rs[i].key, rs[j].key
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+
+	out := make([]multiaddr.Multiaddr, len(rs))
+	for i := range rs {
+		out[i] = rs[i].maddr
+	}
+	return out
+}
+
+// indexOf returns the index of v in order, or len(order) if not present.
+func indexOf[T comparable](order []T, v T) int {
+	for i := range order {
+		if order[i] == v {
+			return i
+		}
+	}
+	return len(order)
+}
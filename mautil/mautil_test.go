@@ -67,3 +67,54 @@ func TestFindHTTPAddrs(t *testing.T) {
 	filtered = mautil.FilterPublic(nil)
 	require.Nil(t, filtered)
 }
+
+func TestClassify(t *testing.T) {
+	samples := map[string]mautil.AddrClass{
+		"/ip4/11.0.0.1/tcp/80/http":                   {mautil.TransportHTTP, mautil.ReachPublic, mautil.FamilyIPv4},
+		"/dns4/example.net/tcp/443/https":             {mautil.TransportHTTPS, mautil.ReachPublic, mautil.FamilyIPv4},
+		"/dns/example.net/tcp/443/tls/sni/x.net/http": {mautil.TransportHTTPS, mautil.ReachPublic, mautil.FamilyAny},
+		"/ip4/192.168.1.1/tcp/4001":                   {mautil.TransportTCP, mautil.ReachPrivate, mautil.FamilyIPv4},
+		"/ip6/::1/udp/4001/quic-v1":                   {mautil.TransportQUIC, mautil.ReachLoopback, mautil.FamilyIPv6},
+		"/ip6/2604:1380::1/udp/4001/quic-v1/webtransport": {
+			mautil.TransportWebTransport, mautil.ReachPublic, mautil.FamilyIPv6},
+		"/dns6/localhost/tcp/8080/ws": {mautil.TransportWebSocket, mautil.ReachLoopback, mautil.FamilyIPv6},
+		"/ip4/11.0.0.1/tcp/4001/p2p/12D3KooWHHzSeKaY8xuZVzkLbKFfvNgPPeKhFBGrMbNzbm5akpqu/p2p-circuit": {
+			mautil.TransportTCP, mautil.ReachRelay, mautil.FamilyIPv4},
+		"/ip4/0.0.0.0/tcp/3105": {mautil.TransportTCP, mautil.ReachPrivate, mautil.FamilyIPv4},
+		"/unix/tmp/sock":        {mautil.TransportUnknown, mautil.ReachLoopback, mautil.FamilyNone},
+	}
+	for s, expect := range samples {
+		maddr, err := multiaddr.NewMultiaddr(s)
+		require.NoError(t, err)
+		require.Equal(t, expect, mautil.Classify(maddr), s)
+	}
+	require.Equal(t, mautil.AddrClass{}, mautil.Classify(nil))
+}
+
+func TestRank(t *testing.T) {
+	addrs := []string{
+		"/ip4/127.0.0.1/tcp/80/http",
+		"/ip4/11.0.0.1/tcp/4001/p2p/12D3KooWHHzSeKaY8xuZVzkLbKFfvNgPPeKhFBGrMbNzbm5akpqu/p2p-circuit",
+		"/ip6/2604:1380::1/tcp/80/http",
+		"/ip4/192.168.1.1/tcp/443/https",
+		"/ip4/11.0.0.1/tcp/80/http",
+		"/dns/example.net/tcp/443/https",
+		"/ip4/11.0.0.2/tcp/80/http",
+	}
+	maddrs, err := mautil.StringsToMultiaddrs(addrs)
+	require.NoError(t, err)
+
+	ranked := mautil.Rank(maddrs)
+	expected := []multiaddr.Multiaddr{maddrs[5], maddrs[4], maddrs[6], maddrs[2], maddrs[3], maddrs[0], maddrs[1]}
+	require.Equal(t, expected, ranked)
+	// Original slice not modified.
+	require.Equal(t, "/ip4/127.0.0.1/tcp/80/http", maddrs[0].String())
+
+	ranked = mautil.Rank(maddrs,
+		mautil.WithReachabilityOrder(mautil.ReachLoopback, mautil.ReachPrivate),
+		mautil.WithFamilyOrder(mautil.FamilyIPv6))
+	expected = []multiaddr.Multiaddr{maddrs[0], maddrs[3], maddrs[5], maddrs[2], maddrs[4], maddrs[6], maddrs[1]}
+	require.Equal(t, expected, ranked)
+
+	require.Nil(t, mautil.Rank(nil))
+}
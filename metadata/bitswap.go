@@ -2,6 +2,7 @@ package metadata
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 
@@ -50,3 +51,13 @@ func (b Bitswap) ReadFrom(r io.Reader) (n int64, err error) {
 	}
 	return bRead, nil
 }
+
+// MarshalJSON implements json.Marshaler.
+func (b Bitswap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(payloadJSON{Protocol: protocolName(b.ID())})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b Bitswap) UnmarshalJSON(data []byte) error {
+	return checkProtocolJSON(data, multicodec.TransportBitswap)
+}
@@ -0,0 +1,229 @@
+package metadata
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-varint"
+)
+
+// DecodeMode determines how Decode handles metadata that is not well-formed.
+type DecodeMode int
+
+const (
+	// DecodeLenient decodes as much of the metadata as possible. Unknown
+	// protocols are kept as Unknown, and duplicate or unsorted protocols,
+	// oversized metadata, and undecodable trailing bytes are reported as
+	// warnings. Decoding only fails if no protocol can be decoded.
+	DecodeLenient DecodeMode = iota
+	// DecodeStrict rejects metadata that contains unknown, duplicate, or
+	// unsorted protocols, that is larger than MaxMetadataSize, or that has
+	// trailing bytes that are not part of any protocol.
+	DecodeStrict
+)
+
+// DiagnosticLevel is the severity of a Diagnostic.
+type DiagnosticLevel int
+
+const (
+	// DiagnosticWarning is a problem that did not prevent decoding.
+	DiagnosticWarning DiagnosticLevel = iota
+	// DiagnosticError is a problem that makes the metadata invalid.
+	DiagnosticError
+)
+
+func (l DiagnosticLevel) String() string {
+	switch l {
+	case DiagnosticWarning:
+		return "warning"
+	case DiagnosticError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Diagnostic describes a problem found while decoding metadata.
+type Diagnostic struct {
+	// Level is the severity of the problem.
+	Level DiagnosticLevel
+	// Offset is the byte offset in the metadata where the problem was found.
+	Offset int
+	// Protocol identifies the protocol that the problem pertains to. This is
+	// zero if the problem is not specific to a protocol.
+	Protocol multicodec.Code
+	// Message describes the problem.
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	if d.Protocol == 0 {
+		return fmt.Sprintf("%s at offset %d: %s", d.Level, d.Offset, d.Message)
+	}
+	return fmt.Sprintf("%s at offset %d: %s: %s", d.Level, d.Offset, protocolName(d.Protocol), d.Message)
+}
+
+// DecodeReport contains the diagnostics from decoding metadata.
+type DecodeReport struct {
+	Diagnostics []Diagnostic
+}
+
+// HasErrors returns true if the report contains any errors.
+func (r DecodeReport) HasErrors() bool {
+	for _, d := range r.Diagnostics {
+		if d.Level == DiagnosticError {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors returns the diagnostics that are errors.
+func (r DecodeReport) Errors() []Diagnostic {
+	return r.filter(DiagnosticError)
+}
+
+// Warnings returns the diagnostics that are warnings.
+func (r DecodeReport) Warnings() []Diagnostic {
+	return r.filter(DiagnosticWarning)
+}
+
+// Err returns an ErrInvalidMetadata describing the errors in the report, or
+// nil if there are no errors.
+func (r DecodeReport) Err() error {
+	errs := r.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, d := range errs {
+		msgs[i] = d.String()
+	}
+	return ErrInvalidMetadata{
+		Message:     strings.Join(msgs, "; "),
+		Diagnostics: errs,
+	}
+}
+
+func (r DecodeReport) filter(level DiagnosticLevel) []Diagnostic {
+	var out []Diagnostic
+	for _, d := range r.Diagnostics {
+		if d.Level == level {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// decoder holds the state of a single Decode call.
+type decoder struct {
+	mode   DecodeMode
+	report DecodeReport
+}
+
+// problem records a diagnostic that is an error in strict mode and a warning
+// in lenient mode.
+func (d *decoder) problem(offset int, id multicodec.Code, format string, args ...any) {
+	level := DiagnosticWarning
+	if d.mode == DecodeStrict {
+		level = DiagnosticError
+	}
+	d.add(level, offset, id, format, args...)
+}
+
+func (d *decoder) add(level DiagnosticLevel, offset int, id multicodec.Code, format string, args ...any) {
+	d.report.Diagnostics = append(d.report.Diagnostics, Diagnostic{
+		Level:    level,
+		Offset:   offset,
+		Protocol: id,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Decoder is an optional interface implemented by a MetadataContext that can
+// decode metadata with diagnostics. The MetadataContexts created by this
+// package implement Decoder.
+type Decoder interface {
+	// Decode decodes binary metadata in strict or lenient mode, and returns
+	// a report of problems found with byte offsets.
+	Decode(data []byte, mode DecodeMode) (Metadata, DecodeReport, error)
+}
+
+// Decode decodes binary metadata according to the decode mode, and returns a
+// report of any problems found. An error is returned if the report contains
+// any errors. In strict mode any problem is an error. In lenient mode, the
+// decoded protocols are returned along with warnings, and an error is only
+// returned if no protocols could be decoded.
+//
+// The protocols known to mc are decoded, and other protocols are unknown. If
+// mc is nil, then Default is used. If mc does not implement Decoder, then only
+// the protocols built into this package are known.
+func Decode(mc MetadataContext, data []byte, mode DecodeMode) (Metadata, DecodeReport, error) {
+	if mc == nil {
+		mc = Default
+	}
+	if dec, ok := mc.(Decoder); ok {
+		return dec.Decode(data, mode)
+	}
+	return builtinContext.Decode(data, mode)
+}
+
+// Decode implements Decoder.
+func (mc *metadataContext) Decode(data []byte, mode DecodeMode) (Metadata, DecodeReport, error) {
+	d := decoder{mode: mode}
+
+	if len(data) > MaxMetadataSize {
+		d.problem(MaxMetadataSize, 0, "metadata size %d exceeds maximum %d", len(data), MaxMetadataSize)
+	}
+
+	var protocols []Protocol
+	seen := make(map[multicodec.Code]struct{})
+	var lastID multicodec.Code
+	var offset int
+
+	for offset < len(data) {
+		rest := data[offset:]
+		v, _, err := varint.FromUvarint(rest)
+		if err != nil {
+			d.problem(offset, 0, "%d trailing bytes are not a protocol: %s", len(rest), err)
+			break
+		}
+		id := multicodec.Code(v)
+
+		var t Protocol
+		if factory, ok := mc.protocols[id]; ok {
+			t = factory()
+		} else {
+			d.problem(offset, id, "unknown protocol")
+			t = &Unknown{}
+		}
+
+		n, err := t.ReadFrom(bytes.NewReader(rest))
+		if err != nil {
+			d.problem(offset, id, "cannot decode %d trailing bytes: %s", len(rest), err)
+			break
+		}
+
+		if _, ok := seen[id]; ok {
+			d.problem(offset, id, "duplicate protocol")
+		}
+		seen[id] = struct{}{}
+		if id < lastID {
+			d.problem(offset, id, "protocol not sorted by id, follows %s", protocolName(lastID))
+		}
+		lastID = id
+
+		protocols = append(protocols, t)
+		offset += int(n)
+	}
+
+	if len(protocols) == 0 {
+		d.add(DiagnosticError, 0, 0, "at least one transport must be specified")
+	}
+
+	if err := d.report.Err(); err != nil {
+		return Metadata{}, d.report, err
+	}
+	return mc.New(protocols...), d.report, nil
+}
@@ -0,0 +1,138 @@
+package metadata_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/ipni/go-libipni/test"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-varint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	bitswapBytes := varint.ToUvarint(uint64(multicodec.TransportBitswap))
+	gsMeta := &metadata.GraphsyncFilecoinV1{PieceCID: test.RandomCids(1)[0]}
+	gsBytes, err := gsMeta.MarshalBinary()
+	require.NoError(t, err)
+	unknownBytes := makeUnknownData(multicodec.Libp2pRelayRsvp)
+
+	concat := func(bs ...[]byte) []byte {
+		return bytes.Join(bs, nil)
+	}
+
+	tests := []struct {
+		name          string
+		data          []byte
+		wantProtocols []multicodec.Code
+		wantOffset    int
+		wantLenient   bool
+	}{
+		{
+			name:          "valid",
+			data:          concat(bitswapBytes, gsBytes),
+			wantProtocols: []multicodec.Code{multicodec.TransportBitswap, multicodec.TransportGraphsyncFilecoinv1},
+			wantOffset:    -1,
+		},
+		{
+			name:          "unknown protocol",
+			data:          concat(unknownBytes, bitswapBytes),
+			wantProtocols: []multicodec.Code{multicodec.Libp2pRelayRsvp, multicodec.TransportBitswap},
+			wantOffset:    0,
+			wantLenient:   true,
+		},
+		{
+			name:          "duplicate protocol",
+			data:          concat(bitswapBytes, bitswapBytes),
+			wantProtocols: []multicodec.Code{multicodec.TransportBitswap, multicodec.TransportBitswap},
+			wantOffset:    len(bitswapBytes),
+			wantLenient:   true,
+		},
+		{
+			name:          "unsorted protocols",
+			data:          concat(gsBytes, bitswapBytes),
+			wantProtocols: []multicodec.Code{multicodec.TransportBitswap, multicodec.TransportGraphsyncFilecoinv1},
+			wantOffset:    len(gsBytes),
+			wantLenient:   true,
+		},
+		{
+			name:          "trailing bytes",
+			data:          concat(bitswapBytes, []byte{0xff}),
+			wantProtocols: []multicodec.Code{multicodec.TransportBitswap},
+			wantOffset:    len(bitswapBytes),
+			wantLenient:   true,
+		},
+		{
+			name:       "empty",
+			wantOffset: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			md, report, err := metadata.Decode(metadata.Default, tc.data, metadata.DecodeStrict)
+			if tc.wantOffset == -1 {
+				require.NoError(t, err)
+				require.Empty(t, report.Diagnostics)
+				require.Equal(t, tc.wantProtocols, md.Protocols())
+				return
+			}
+			require.Error(t, err)
+			var invalidErr metadata.ErrInvalidMetadata
+			require.True(t, errors.As(err, &invalidErr))
+			require.NotEmpty(t, invalidErr.Diagnostics)
+			require.True(t, report.HasErrors())
+			require.Equal(t, tc.wantOffset, report.Errors()[0].Offset)
+
+			md, report, err = metadata.Decode(metadata.Default, tc.data, metadata.DecodeLenient)
+			if !tc.wantLenient {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.False(t, report.HasErrors())
+			require.Len(t, report.Warnings(), 1)
+			require.Equal(t, tc.wantOffset, report.Warnings()[0].Offset)
+			require.Equal(t, tc.wantProtocols, md.Protocols())
+		})
+	}
+}
+
+func TestDecodeTooLong(t *testing.T) {
+	md := metadata.Default.New(&metadata.Unknown{
+		Code:    multicodec.Libp2pRelayRsvp,
+		Payload: makeUnknownData(multicodec.Libp2pRelayRsvp),
+	})
+	data, err := md.MarshalBinary()
+	require.NoError(t, err)
+	for len(data) <= metadata.MaxMetadataSize {
+		data = append(data, varint.ToUvarint(uint64(multicodec.TransportBitswap))...)
+	}
+
+	_, report, err := metadata.Decode(metadata.Default, data, metadata.DecodeStrict)
+	require.ErrorContains(t, err, "exceeds maximum")
+	require.Equal(t, metadata.MaxMetadataSize, report.Errors()[0].Offset)
+
+	_, report, err = metadata.Decode(metadata.Default, data, metadata.DecodeLenient)
+	require.NoError(t, err)
+	require.NotEmpty(t, report.Warnings())
+}
+
+func TestDecodeReplacedDefault(t *testing.T) {
+	md := metadata.Default.New(&metadata.Bitswap{})
+	data, err := md.MarshalBinary()
+	require.NoError(t, err)
+
+	// A MetadataContext that does not implement Decoder uses the built-in
+	// protocols.
+	decoded, report, err := metadata.Decode(wrappedContext{metadata.Default}, data, metadata.DecodeStrict)
+	require.NoError(t, err)
+	require.Empty(t, report.Diagnostics)
+	require.True(t, md.Equal(decoded))
+
+	decoded, _, err = metadata.Decode(nil, data, metadata.DecodeStrict)
+	require.NoError(t, err)
+	require.True(t, md.Equal(decoded))
+}
@@ -4,6 +4,16 @@
 // multihashes advertised by a provider. It is represented as an array of bytes in the indexer
 // protocol, starting with a varint ProtocolID that defines how to decode the remaining bytes.
 //
-// Two metadata types are currently represented here: Bitswap and
-// GraphsyncFilecoinV1.
+// The metadata types currently represented here are: Bitswap,
+// GraphsyncFilecoinV1, IpfsGatewayHttp, and HTTPRetrievalV1.
+//
+// Metadata can also be encoded as JSON, as an array of objects that each
+// identify a protocol by name, for display and logging. Custom protocols can
+// provide their own JSON representation by implementing json.Marshaler and
+// json.Unmarshaler.
+//
+// Decode decodes binary metadata in either strict mode, which
+// rejects unknown, duplicate, or unsorted protocols and trailing bytes, or
+// lenient mode, which keeps what can be decoded and reports warnings. Both
+// return a DecodeReport that locates each problem by byte offset.
 package metadata
@@ -3,6 +3,7 @@ package metadata
 import (
 	"bytes"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"io"
 
@@ -75,7 +76,12 @@ func (dtm *GraphsyncFilecoinV1) ReadFrom(r io.Reader) (n int64, err error) {
 	}
 
 	nb := graphSyncFilecoinV1Prototype.NewBuilder()
-	err = dagcbor.Decode(nb, cr)
+	// Stop reading at the end of the CBOR object, since metadata for other
+	// protocols may follow.
+	err = dagcbor.DecodeOptions{
+		AllowLinks:         true,
+		DontParseBeyondEnd: true,
+	}.Decode(nb, cr)
 	if err != nil {
 		return cr.readCount, err
 	}
@@ -86,3 +92,39 @@ func (dtm *GraphsyncFilecoinV1) ReadFrom(r io.Reader) (n int64, err error) {
 	dtm.PieceCID = gm.PieceCID
 	return cr.readCount, nil
 }
+
+type graphsyncFilecoinV1JSON struct {
+	Protocol      string `json:"protocol"`
+	PieceCID      string `json:"pieceCID"`
+	VerifiedDeal  bool   `json:"verifiedDeal"`
+	FastRetrieval bool   `json:"fastRetrieval"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (dtm *GraphsyncFilecoinV1) MarshalJSON() ([]byte, error) {
+	return json.Marshal(graphsyncFilecoinV1JSON{
+		Protocol:      protocolName(dtm.ID()),
+		PieceCID:      dtm.PieceCID.String(),
+		VerifiedDeal:  dtm.VerifiedDeal,
+		FastRetrieval: dtm.FastRetrieval,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (dtm *GraphsyncFilecoinV1) UnmarshalJSON(data []byte) error {
+	if err := checkProtocolJSON(data, multicodec.TransportGraphsyncFilecoinv1); err != nil {
+		return err
+	}
+	var gj graphsyncFilecoinV1JSON
+	if err := json.Unmarshal(data, &gj); err != nil {
+		return err
+	}
+	pieceCID, err := cid.Decode(gj.PieceCID)
+	if err != nil {
+		return fmt.Errorf("invalid piece cid: %w", err)
+	}
+	dtm.PieceCID = pieceCID
+	dtm.VerifiedDeal = gj.VerifiedDeal
+	dtm.FastRetrieval = gj.FastRetrieval
+	return nil
+}
@@ -1,9 +1,10 @@
 package metadata
 
-import "github.com/multiformats/go-multicodec"
-
+// HTTPV1 returns the metadata protocol for retrieval over HTTP without any
+// parameters.
+//
+// Deprecated: Use &HTTPRetrievalV1{}, which this returns, and set any
+// retrieval parameters.
 func HTTPV1() Protocol {
-	return &Unknown{
-		Code: multicodec.Http,
-	}
+	return &HTTPRetrievalV1{}
 }
@@ -0,0 +1,225 @@
+package metadata
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/node/bindnode"
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-varint"
+)
+
+const (
+	// HTTPFormatCAR is the HTTPRetrievalV1 format for CAR responses.
+	HTTPFormatCAR = "car"
+	// HTTPFormatRaw is the HTTPRetrievalV1 format for raw block responses.
+	HTTPFormatRaw = "raw"
+
+	// HTTPAuthBearer is the HTTPRetrievalV1 auth hint for bearer token
+	// authorization.
+	HTTPAuthBearer = "bearer"
+
+	// httpCIDPlaceholder is replaced by a CID in a path template.
+	httpCIDPlaceholder = "{cid}"
+)
+
+var (
+	_ Protocol = (*HTTPRetrievalV1)(nil)
+
+	//go:embed http_retrieval_v1.ipldsch
+	httpRetrievalV1SchemaBytes []byte
+	httpRetrievalV1Prototype   schema.TypedPrototype
+)
+
+func init() {
+	typeSystem, err := ipld.LoadSchemaBytes(httpRetrievalV1SchemaBytes)
+	if err != nil {
+		panic(fmt.Errorf("failed to load schema: %w", err))
+	}
+	t := typeSystem.TypeByName("HTTPRetrievalV1")
+	httpRetrievalV1Prototype = bindnode.Prototype((*httpRetrievalV1Node)(nil), t)
+}
+
+// HTTPRetrievalV1 represents the indexing metadata for retrieval over HTTP,
+// identified by multicodec.Http, with parameters that describe how to make
+// retrieval requests.
+//
+// The parameters are encoded as a dag-cbor map following the protocol ID, and
+// parameters that have their default value are left out of the map. A
+// zero-value HTTPRetrievalV1, which is also what HTTPV1 returns, is encoded as
+// an empty map. The protocol ID alone, with no map, is only decoded as a
+// zero-value HTTPRetrievalV1 if it is at the end of the metadata.
+//
+// Default decodes multicodec.Http metadata as HTTPRetrievalV1. To decode it as
+// Unknown instead, derive a MetadataContext with WithProtocol.
+type HTTPRetrievalV1 struct {
+	// PathTemplate is the URL path used to retrieve content, in which "{cid}"
+	// is replaced by the CID of the content. If empty, then "/ipfs/{cid}" is
+	// used.
+	PathTemplate string
+	// Formats lists the supported response formats, such as HTTPFormatCAR and
+	// HTTPFormatRaw.
+	Formats []string
+	// ByteRanges indicates whether byte range requests are supported.
+	ByteRanges bool
+	// Auth is a hint about the authorization required for retrieval requests,
+	// such as HTTPAuthBearer. If empty, then no authorization is required.
+	Auth string
+}
+
+// httpRetrievalV1Node is the IPLD representation of HTTPRetrievalV1, in
+// which a nil field is absent from the encoded map.
+type httpRetrievalV1Node struct {
+	PathTemplate *string
+	Formats      *[]string
+	ByteRanges   *bool
+	Auth         *string
+}
+
+func (h *HTTPRetrievalV1) ID() multicodec.Code {
+	return multicodec.Http
+}
+
+// Path returns the URL path for retrieving the content identified by c.
+func (h *HTTPRetrievalV1) Path(c cid.Cid) string {
+	tmpl := h.PathTemplate
+	if tmpl == "" {
+		tmpl = "/ipfs/" + httpCIDPlaceholder
+	}
+	return strings.ReplaceAll(tmpl, httpCIDPlaceholder, c.String())
+}
+
+// SupportsFormat returns true if the given response format is supported.
+func (h *HTTPRetrievalV1) SupportsFormat(format string) bool {
+	for _, f := range h.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (h *HTTPRetrievalV1) MarshalBinary() ([]byte, error) {
+	var hn httpRetrievalV1Node
+	if h.PathTemplate != "" {
+		hn.PathTemplate = &h.PathTemplate
+	}
+	if len(h.Formats) != 0 {
+		hn.Formats = &h.Formats
+	}
+	if h.ByteRanges {
+		hn.ByteRanges = &h.ByteRanges
+	}
+	if h.Auth != "" {
+		hn.Auth = &h.Auth
+	}
+
+	buf := bytes.NewBuffer(varint.ToUvarint(uint64(h.ID())))
+	nd := bindnode.Wrap(&hn, httpRetrievalV1Prototype.Type())
+	// Encode the representation so that absent fields are left out.
+	if err := dagcbor.Encode(nd.Representation(), buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (h *HTTPRetrievalV1) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	_, err := h.ReadFrom(r)
+	return err
+}
+
+func (h *HTTPRetrievalV1) ReadFrom(r io.Reader) (n int64, err error) {
+	cr := &countingReader{r: r}
+	v, err := varint.ReadUvarint(cr)
+	if err != nil {
+		return cr.readCount, err
+	}
+	id := multicodec.Code(v)
+	if id != multicodec.Http {
+		return cr.readCount, fmt.Errorf("transport id does not match %s: %s", multicodec.Http, id)
+	}
+
+	// The protocol ID at the end of the data, without any parameters, is
+	// decoded as the zero value.
+	b, err := cr.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			*h = HTTPRetrievalV1{}
+			return cr.readCount, nil
+		}
+		return cr.readCount, err
+	}
+
+	nb := httpRetrievalV1Prototype.Representation().NewBuilder()
+	// Stop reading at the end of the CBOR object, since metadata for other
+	// protocols may follow.
+	err = dagcbor.DecodeOptions{
+		AllowLinks:         true,
+		DontParseBeyondEnd: true,
+	}.Decode(nb, io.MultiReader(bytes.NewReader([]byte{b}), cr))
+	if err != nil {
+		return cr.readCount, err
+	}
+	nd := nb.Build()
+	hn := bindnode.Unwrap(nd).(*httpRetrievalV1Node)
+	*h = HTTPRetrievalV1{}
+	if hn.PathTemplate != nil {
+		h.PathTemplate = *hn.PathTemplate
+	}
+	if hn.Formats != nil {
+		h.Formats = *hn.Formats
+	}
+	if hn.ByteRanges != nil {
+		h.ByteRanges = *hn.ByteRanges
+	}
+	if hn.Auth != nil {
+		h.Auth = *hn.Auth
+	}
+	return cr.readCount, nil
+}
+
+type httpRetrievalV1JSON struct {
+	Protocol     string   `json:"protocol"`
+	PathTemplate string   `json:"pathTemplate,omitempty"`
+	Formats      []string `json:"formats,omitempty"`
+	ByteRanges   bool     `json:"byteRanges,omitempty"`
+	Auth         string   `json:"auth,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h *HTTPRetrievalV1) MarshalJSON() ([]byte, error) {
+	return json.Marshal(httpRetrievalV1JSON{
+		Protocol:     protocolName(h.ID()),
+		PathTemplate: h.PathTemplate,
+		Formats:      h.Formats,
+		ByteRanges:   h.ByteRanges,
+		Auth:         h.Auth,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *HTTPRetrievalV1) UnmarshalJSON(data []byte) error {
+	if err := checkProtocolJSON(data, multicodec.Http); err != nil {
+		return err
+	}
+	var hj httpRetrievalV1JSON
+	if err := json.Unmarshal(data, &hj); err != nil {
+		return err
+	}
+	h.PathTemplate = hj.PathTemplate
+	h.Formats = hj.Formats
+	h.ByteRanges = hj.ByteRanges
+	h.Auth = hj.Auth
+	return nil
+}
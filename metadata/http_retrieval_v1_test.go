@@ -0,0 +1,141 @@
+package metadata_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/ipni/go-libipni/test"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-varint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTripHTTPRetrievalV1(t *testing.T) {
+	httpDatas := []*metadata.HTTPRetrievalV1{
+		{},
+		{
+			PathTemplate: "/piece/{cid}",
+			Formats:      []string{metadata.HTTPFormatCAR},
+		},
+		{
+			PathTemplate: "/ipfs/{cid}",
+			Formats:      []string{metadata.HTTPFormatCAR, metadata.HTTPFormatRaw},
+			ByteRanges:   true,
+			Auth:         metadata.HTTPAuthBearer,
+		},
+	}
+	for _, src := range httpDatas {
+		require.Equal(t, multicodec.Http, src.ID())
+
+		asBytes, err := src.MarshalBinary()
+		require.NoError(t, err)
+
+		dst := &metadata.HTTPRetrievalV1{}
+		err = dst.UnmarshalBinary(asBytes)
+		require.NoError(t, err)
+		require.Equal(t, src.PathTemplate, dst.PathTemplate)
+		require.Equal(t, len(src.Formats), len(dst.Formats))
+		require.Equal(t, src.ByteRanges, dst.ByteRanges)
+		require.Equal(t, src.Auth, dst.Auth)
+	}
+}
+
+func TestHTTPRetrievalV1Metadata(t *testing.T) {
+	c := test.RandomCids(1)[0]
+	httpMeta := &metadata.HTTPRetrievalV1{
+		PathTemplate: "/piece/{cid}?format=car",
+		Formats:      []string{metadata.HTTPFormatCAR},
+		ByteRanges:   true,
+	}
+	require.Equal(t, "/piece/"+c.String()+"?format=car", httpMeta.Path(c))
+	require.True(t, httpMeta.SupportsFormat(metadata.HTTPFormatCAR))
+	require.False(t, httpMeta.SupportsFormat(metadata.HTTPFormatRaw))
+	require.Equal(t, "/ipfs/"+c.String(), (&metadata.HTTPRetrievalV1{}).Path(c))
+
+	md := metadata.Default.New(httpMeta, &metadata.Bitswap{}, &metadata.GraphsyncFilecoinV1{PieceCID: c})
+	mdBytes, err := md.MarshalBinary()
+	require.NoError(t, err)
+
+	decoded := metadata.Default.New()
+	require.NoError(t, decoded.UnmarshalBinary(mdBytes))
+	require.True(t, md.Equal(decoded))
+	p := decoded.Get(multicodec.Http)
+	require.IsType(t, &metadata.HTTPRetrievalV1{}, p)
+	require.True(t, p.(*metadata.HTTPRetrievalV1).ByteRanges)
+
+	// Read from stream that has more data after the protocol.
+	httpBytes, err := httpMeta.MarshalBinary()
+	require.NoError(t, err)
+	r := bytes.NewReader(append(httpBytes, 0xff, 0xff))
+	dst := &metadata.HTTPRetrievalV1{}
+	n, err := dst.ReadFrom(r)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(httpBytes)), n)
+	require.Equal(t, 2, r.Len())
+	require.Equal(t, httpMeta.PathTemplate, dst.PathTemplate)
+}
+
+func TestHTTPRetrievalV1Metadata_FromIndexerMetadataErr(t *testing.T) {
+	dst := &metadata.HTTPRetrievalV1{}
+	err := dst.UnmarshalBinary(varint.ToUvarint(uint64(multicodec.TransportBitswap)))
+	require.ErrorContains(t, err, "transport id does not match")
+}
+
+func TestHTTPRetrievalV1NoParameters(t *testing.T) {
+	httpBytes := varint.ToUvarint(uint64(multicodec.Http))
+	emptyBytes := append(httpBytes, 0xa0)
+
+	// HTTPV1 and a zero value are encoded as the protocol ID and an empty map.
+	b, err := metadata.HTTPV1().MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, emptyBytes, b)
+	b, err = (&metadata.HTTPRetrievalV1{}).MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, emptyBytes, b)
+
+	// Parameters that have their default value are not encoded.
+	b, err = (&metadata.HTTPRetrievalV1{Formats: []string{}}).MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, emptyBytes, b)
+
+	// No parameters followed by other protocols whose encoding starts with a
+	// byte that looks like a CBOR map.
+	md := metadata.Default.New(metadata.HTTPV1(), &metadata.IpfsGatewayHttp{}, &metadata.Bitswap{})
+	data, err := md.MarshalBinary()
+	require.NoError(t, err)
+	decoded := metadata.Default.New()
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, []multicodec.Code{multicodec.Http, multicodec.TransportBitswap, multicodec.TransportIpfsGatewayHttp}, decoded.Protocols())
+	require.Equal(t, &metadata.HTTPRetrievalV1{}, decoded.Get(multicodec.Http))
+	require.True(t, md.Equal(decoded))
+
+	// The protocol ID alone at the end of the metadata.
+	decoded = metadata.Default.New()
+	require.NoError(t, decoded.UnmarshalBinary(httpBytes))
+	require.Equal(t, []multicodec.Code{multicodec.Http}, decoded.Protocols())
+	require.Equal(t, &metadata.HTTPRetrievalV1{}, decoded.Get(multicodec.Http))
+
+	// Reader that cannot unread.
+	dst := &metadata.HTTPRetrievalV1{Auth: metadata.HTTPAuthBearer}
+	n, err := dst.ReadFrom(iotest.OneByteReader(bytes.NewReader(httpBytes)))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(httpBytes)), n)
+	require.Equal(t, &metadata.HTTPRetrievalV1{}, dst)
+
+	src := &metadata.HTTPRetrievalV1{ByteRanges: true}
+	b, err = src.MarshalBinary()
+	require.NoError(t, err)
+	r := bytes.NewReader(append(b, emptyBytes...))
+	n, err = dst.ReadFrom(iotest.OneByteReader(r))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(b)), n)
+	require.True(t, dst.ByteRanges)
+	require.Equal(t, len(emptyBytes), r.Len())
+
+	// The protocol ID alone followed by other data is not valid.
+	data = append(httpBytes, varint.ToUvarint(uint64(multicodec.TransportBitswap))...)
+	decoded = metadata.Default.New()
+	require.Error(t, decoded.UnmarshalBinary(data))
+}
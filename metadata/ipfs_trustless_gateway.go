@@ -2,6 +2,7 @@ package metadata
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 
@@ -52,3 +53,13 @@ func (b IpfsGatewayHttp) ReadFrom(r io.Reader) (n int64, err error) {
 	}
 	return bRead, nil
 }
+
+// MarshalJSON implements json.Marshaler.
+func (b IpfsGatewayHttp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(payloadJSON{Protocol: protocolName(b.ID())})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b IpfsGatewayHttp) UnmarshalJSON(data []byte) error {
+	return checkProtocolJSON(data, multicodec.TransportIpfsGatewayHttp)
+}
@@ -0,0 +1,175 @@
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/multiformats/go-multicodec"
+)
+
+// protocolKey is the JSON field that identifies the protocol of a JSON
+// encoded Protocol.
+const protocolKey = "protocol"
+
+var (
+	_ json.Marshaler   = Metadata{}
+	_ json.Unmarshaler = (*Metadata)(nil)
+)
+
+// payloadJSON is the JSON representation of a protocol that does not have its
+// own JSON representation. The payload is the protocol's binary encoding.
+type payloadJSON struct {
+	Protocol string `json:"protocol"`
+	Payload  []byte `json:"payload,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Metadata is encoded as an array of
+// JSON objects, one for each protocol, each having a "protocol" field that
+// contains the protocol name.
+//
+// A Protocol may provide its own JSON representation by implementing
+// json.Marshaler and json.Unmarshaler. Otherwise, the protocol is encoded as
+// its name and base64-encoded binary payload.
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	out := make([]json.RawMessage, len(m.protocols))
+	for i, p := range m.protocols {
+		data, err := marshalProtocolJSON(p)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode %s metadata as json: %w", protocolName(p.ID()), err)
+		}
+		out[i] = data
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The metadata context that the
+// Metadata was created with determines how each protocol is decoded. If the
+// Metadata was not created from a context, then Default is used, unless
+// Default has been replaced by a different MetadataContext implementation, in
+// which case the built-in protocols are used.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	if m.mc == nil {
+		mc, ok := Default.(*metadataContext)
+		if !ok {
+			mc = builtinContext
+		}
+		m.mc = mc
+	}
+	protocols := make([]Protocol, 0, len(raws))
+	for _, raw := range raws {
+		p, err := m.mc.unmarshalProtocolJSON(raw)
+		if err != nil {
+			return err
+		}
+		protocols = append(protocols, p)
+	}
+	m.protocols = protocols
+	return m.Validate()
+}
+
+func marshalProtocolJSON(p Protocol) ([]byte, error) {
+	jm, ok := p.(json.Marshaler)
+	if !ok {
+		payload, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(payloadJSON{
+			Protocol: protocolName(p.ID()),
+			Payload:  payload,
+		})
+	}
+
+	data, err := jm.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	// Add protocol name if not supplied by the protocol's JSON.
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.New("protocol json is not an object")
+	}
+	if _, ok = fields[protocolKey]; ok {
+		return data, nil
+	}
+	fields[protocolKey], err = json.Marshal(protocolName(p.ID()))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+func (mc *metadataContext) unmarshalProtocolJSON(data []byte) (Protocol, error) {
+	var pj payloadJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return nil, err
+	}
+	id, err := parseProtocolName(pj.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	p := mc.newTransport(id)
+	if ju, ok := p.(json.Unmarshaler); ok {
+		if err = ju.UnmarshalJSON(data); err != nil {
+			return nil, fmt.Errorf("cannot decode %s metadata from json: %w", pj.Protocol, err)
+		}
+		return p, nil
+	}
+	if err = p.UnmarshalBinary(pj.Payload); err != nil {
+		return nil, fmt.Errorf("cannot decode %s metadata payload: %w", pj.Protocol, err)
+	}
+	return p, nil
+}
+
+// protocolName returns the multicodec name of the protocol, or the protocol
+// code in hexadecimal if the code does not have a name.
+func protocolName(id multicodec.Code) string {
+	name := id.String()
+	if strings.HasPrefix(name, "Code(") {
+		return "0x" + strconv.FormatUint(uint64(id), 16)
+	}
+	return name
+}
+
+// parseProtocolName returns the protocol code for a protocol name or numeric
+// protocol code.
+func parseProtocolName(name string) (multicodec.Code, error) {
+	if name == "" {
+		return 0, errors.New("missing protocol")
+	}
+	if n, err := strconv.ParseUint(name, 0, 64); err == nil {
+		return multicodec.Code(n), nil
+	}
+	var id multicodec.Code
+	if err := id.Set(name); err != nil {
+		return 0, fmt.Errorf("unknown protocol %q", name)
+	}
+	return id, nil
+}
+
+// checkProtocolJSON checks that the protocol named in a protocol's JSON
+// matches the expected protocol.
+func checkProtocolJSON(data []byte, want multicodec.Code) error {
+	var pj payloadJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return err
+	}
+	if pj.Protocol == "" {
+		return nil
+	}
+	id, err := parseProtocolName(pj.Protocol)
+	if err != nil {
+		return err
+	}
+	if id != want {
+		return fmt.Errorf("transport id does not match %s: %s", want, id)
+	}
+	return nil
+}
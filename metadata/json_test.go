@@ -0,0 +1,125 @@
+package metadata_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/ipni/go-libipni/test"
+	"github.com/multiformats/go-multicodec"
+	"github.com/stretchr/testify/require"
+)
+
+// customProtocol is a protocol that provides its own JSON representation.
+type customProtocol struct {
+	metadata.Unknown
+	Name string
+}
+
+func (c *customProtocol) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name string `json:"name"`
+	}{c.Name})
+}
+
+func (c *customProtocol) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	c.Name = v.Name
+	c.Code = multicodec.Libp2pRelayRsvp
+	return nil
+}
+
+func TestMetadataJSON(t *testing.T) {
+	pieceCID := test.RandomCids(1)[0]
+	md := metadata.Default.New(
+		&metadata.Bitswap{},
+		&metadata.GraphsyncFilecoinV1{
+			PieceCID:      pieceCID,
+			VerifiedDeal:  true,
+			FastRetrieval: true,
+		},
+		&metadata.IpfsGatewayHttp{},
+		&metadata.HTTPRetrievalV1{
+			PathTemplate: "/piece/{cid}",
+			Formats:      []string{metadata.HTTPFormatCAR},
+		},
+		&metadata.Unknown{
+			Code:    multicodec.Libp2pRelayRsvp,
+			Payload: makeUnknownData(multicodec.Libp2pRelayRsvp),
+		},
+	)
+
+	data, err := json.Marshal(md)
+	require.NoError(t, err)
+
+	var fields []map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	require.Len(t, fields, 5)
+	require.Equal(t, "http", fields[0]["protocol"])
+	require.Equal(t, "/piece/{cid}", fields[0]["pathTemplate"])
+	require.Equal(t, "libp2p-relay-rsvp", fields[1]["protocol"])
+	require.NotEmpty(t, fields[1]["payload"])
+	require.Equal(t, "transport-bitswap", fields[2]["protocol"])
+	require.Equal(t, "transport-graphsync-filecoinv1", fields[3]["protocol"])
+	require.Equal(t, pieceCID.String(), fields[3]["pieceCID"])
+	require.Equal(t, true, fields[3]["verifiedDeal"])
+	require.Equal(t, "transport-ipfs-gateway-http", fields[4]["protocol"])
+
+	decoded := metadata.Default.New()
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.True(t, md.Equal(decoded))
+
+	// Metadata not created from a context uses the default context.
+	var zero metadata.Metadata
+	require.NoError(t, json.Unmarshal(data, &zero))
+	require.True(t, md.Equal(zero))
+
+	err = json.Unmarshal([]byte(`[{"protocol":"transport-bitswap"},{"protocol":"no-such-protocol"}]`), &zero)
+	require.ErrorContains(t, err, "unknown protocol")
+	err = json.Unmarshal([]byte(`[]`), &zero)
+	require.ErrorContains(t, err, "at least one transport")
+}
+
+func TestMetadataJSONCustomProtocol(t *testing.T) {
+	mctx := metadata.Default.WithProtocol(multicodec.Libp2pRelayRsvp, func() metadata.Protocol {
+		return &customProtocol{}
+	})
+	custom := &customProtocol{
+		Unknown: metadata.Unknown{Code: multicodec.Libp2pRelayRsvp},
+		Name:    "custom",
+	}
+	md := mctx.New(&metadata.Bitswap{}, custom)
+
+	data, err := json.Marshal(md)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"protocol":"libp2p-relay-rsvp","name":"custom"},{"protocol":"transport-bitswap"}]`, string(data))
+
+	decoded := mctx.New()
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	p := decoded.Get(multicodec.Libp2pRelayRsvp)
+	require.IsType(t, &customProtocol{}, p)
+	require.Equal(t, "custom", p.(*customProtocol).Name)
+}
+
+// wrappedContext is a MetadataContext implemented outside of this package.
+type wrappedContext struct {
+	metadata.MetadataContext
+}
+
+func TestMetadataJSONReplacedDefault(t *testing.T) {
+	data, err := json.Marshal(metadata.Default.New(&metadata.Bitswap{}))
+	require.NoError(t, err)
+
+	orig := metadata.Default
+	metadata.Default = wrappedContext{orig}
+	defer func() { metadata.Default = orig }()
+
+	var md metadata.Metadata
+	require.NoError(t, json.Unmarshal(data, &md))
+	require.Equal(t, []multicodec.Code{multicodec.TransportBitswap}, md.Protocols())
+}
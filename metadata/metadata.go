@@ -14,6 +14,9 @@ import (
 
 type ErrInvalidMetadata struct {
 	Message string
+	// Diagnostics contains details about the problems that made the metadata
+	// invalid, if available.
+	Diagnostics []Diagnostic
 }
 
 func (e ErrInvalidMetadata) Error() string {
@@ -58,6 +61,10 @@ type MetadataContext interface {
 
 var Default MetadataContext
 
+// builtinContext is the initial value of Default, which knows the protocols
+// supported by this package.
+var builtinContext *metadataContext
+
 func init() {
 	d := metadataContext{
 		protocols: make(map[multicodec.Code]func() Protocol),
@@ -65,7 +72,9 @@ func init() {
 	d.protocols[multicodec.TransportBitswap] = func() Protocol { return &Bitswap{} }
 	d.protocols[multicodec.TransportGraphsyncFilecoinv1] = func() Protocol { return &GraphsyncFilecoinV1{} }
 	d.protocols[multicodec.TransportIpfsGatewayHttp] = func() Protocol { return &IpfsGatewayHttp{} }
-	Default = &d
+	d.protocols[multicodec.Http] = func() Protocol { return &HTTPRetrievalV1{} }
+	builtinContext = &d
+	Default = builtinContext
 }
 
 // WithProtocol dervies a new MetadataContext including the additional protocol mapping.
@@ -157,9 +166,7 @@ func (m *Metadata) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary implements encoding.BinaryUnmarshaler.
 func (m *Metadata) UnmarshalBinary(data []byte) error {
-	var read int64
-	for read < int64(len(data)) {
-		data = data[read:]
+	for len(data) != 0 {
 		v, _, err := varint.FromUvarint(data)
 		if err != nil {
 			return err
@@ -173,7 +180,7 @@ func (m *Metadata) UnmarshalBinary(data []byte) error {
 			return err
 		}
 		m.protocols = append(m.protocols, t)
-		read += tLen
+		data = data[tLen:]
 	}
 	return m.Validate()
 }
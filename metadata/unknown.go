@@ -2,6 +2,7 @@ package metadata
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -68,3 +69,26 @@ func (u *Unknown) ReadFrom(r io.Reader) (int64, error) {
 
 	return int64(readLen), nil
 }
+
+// MarshalJSON implements json.Marshaler. The payload is base64 encoded.
+func (u *Unknown) MarshalJSON() ([]byte, error) {
+	return json.Marshal(payloadJSON{
+		Protocol: protocolName(u.Code),
+		Payload:  u.Payload,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *Unknown) UnmarshalJSON(data []byte) error {
+	var pj payloadJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return err
+	}
+	code, err := parseProtocolName(pj.Protocol)
+	if err != nil {
+		return err
+	}
+	u.Code = code
+	u.Payload = pj.Payload
+	return nil
+}
@@ -2,11 +2,14 @@ package p2pclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
@@ -22,14 +25,53 @@ import (
 // Client is responsible for sending requests and receiving responses to and
 // from libp2p peers. Each instance of Client communicates with a single peer
 // using a single protocolID.
+//
+// Requests are sent over a pool of streams to the peer. Each stream carries
+// one request at a time, and concurrent requests use separate streams, up to
+// the maximum configured by WithMaxStreams.
 type Client struct {
-	host     host.Host
-	ownHost  bool
-	peerID   peer.ID
-	protoID  protocol.ID
-	r        msgio.ReadCloser
-	stream   network.Stream
-	sendLock sync.Mutex
+	host        host.Host
+	ownHost     bool
+	peerID      peer.ID
+	protoID     protocol.ID
+	maxIdleTime time.Duration
+	readTimeout time.Duration
+
+	// slots limits the number of streams that are open at once.
+	slots  chan struct{}
+	lock   sync.Mutex
+	idle   []*clientStream
+	closed bool
+
+	inFlight atomic.Int64
+	open     atomic.Int64
+	requests atomic.Uint64
+	failures atomic.Uint64
+	reopens  atomic.Uint64
+}
+
+// clientStream is a stream to the peer and the reader for its responses.
+type clientStream struct {
+	stream network.Stream
+	r      msgio.ReadCloser
+	// read counts the bytes read from the stream.
+	read *countReader
+	// reused is true if the stream was used by a previous request.
+	reused bool
+	// idleSince is when the stream was returned to the idle pool.
+	idleSince time.Time
+}
+
+// countReader counts the bytes read from a reader.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
 // Response is returned by SendRequest and contains the response to the
@@ -50,24 +92,49 @@ func (r *Response) Close() {
 	}
 }
 
+// Stats contains statistics about a Client's requests and streams.
+type Stats struct {
+	// InFlight is the number of requests currently being sent or waiting for
+	// a response.
+	InFlight int
+	// OpenStreams is the number of streams currently open to the peer,
+	// including idle streams.
+	OpenStreams int
+	// IdleStreams is the number of open streams not in use by a request.
+	IdleStreams int
+	// Requests is the total number of requests and messages sent.
+	Requests uint64
+	// Failures is the total number of requests and messages that failed.
+	Failures uint64
+	// Reopens is the number of times a request was retried on a new stream
+	// because a reused stream was reset or closed by the peer.
+	Reopens uint64
+}
+
 const (
 	// default IPNI port for libp2p client to connect to
 	defaultLibp2pPort = 3003
-	// Timeout to wait for a response after a request is sent
-	readMessageTimeout = 10 * time.Second
 )
 
-// ErrReadTimeout is an error that occurs when no message is read within the
-// timeout period.
-var ErrReadTimeout = fmt.Errorf("timed out reading response")
+var (
+	// ErrReadTimeout is an error that occurs when no message is read within
+	// the timeout period.
+	ErrReadTimeout = fmt.Errorf("timed out reading response")
+	// ErrClosed is returned when using a Client that is closed.
+	ErrClosed = errors.New("client closed")
+)
 
 // New creates a new Client that communicates with a specific peer identified
 // by protocolID. If host is nil, then one is created.
-func New(p2pHost host.Host, peerID peer.ID, protoID protocol.ID) (*Client, error) {
+func New(p2pHost host.Host, peerID peer.ID, protoID protocol.ID, options ...Option) (*Client, error) {
+	opts, err := getOpts(options)
+	if err != nil {
+		return nil, err
+	}
+
 	// If no host was given, create one.
 	var ownHost bool
 	if p2pHost == nil {
-		var err error
 		p2pHost, err = libp2p.New()
 		if err != nil {
 			return nil, err
@@ -77,10 +144,13 @@ func New(p2pHost host.Host, peerID peer.ID, protoID protocol.ID) (*Client, error
 
 	// Start a client
 	return &Client{
-		host:    p2pHost,
-		ownHost: ownHost,
-		peerID:  peerID,
-		protoID: protoID,
+		host:        p2pHost,
+		ownHost:     ownHost,
+		peerID:      peerID,
+		protoID:     protoID,
+		maxIdleTime: opts.maxIdleTime,
+		readTimeout: opts.readTimeout,
+		slots:       make(chan struct{}, opts.maxStreams),
 	}, nil
 }
 
@@ -139,13 +209,21 @@ func (c *Client) Self() peer.ID {
 	return c.host.ID()
 }
 
-// Close resets and closes the network stream if one exists.
+// Close resets and closes all network streams. Streams in use by requests are
+// reset when the request completes.
 func (c *Client) Close() error {
-	c.sendLock.Lock()
-	defer c.sendLock.Unlock()
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil
+	}
+	c.closed = true
+	idle := c.idle
+	c.idle = nil
+	c.lock.Unlock()
 
-	if c.stream != nil {
-		c.closeStream()
+	for _, cs := range idle {
+		c.resetStream(cs)
 	}
 
 	if c.ownHost {
@@ -154,72 +232,231 @@ func (c *Client) Close() error {
 	return nil
 }
 
-// SendRequest sends out a request and reads a response.
+// Stats returns statistics about the client's requests and streams.
+func (c *Client) Stats() Stats {
+	c.lock.Lock()
+	idle := len(c.idle)
+	c.lock.Unlock()
+	return Stats{
+		InFlight:    int(c.inFlight.Load()),
+		OpenStreams: int(c.open.Load()),
+		IdleStreams: idle,
+		Requests:    c.requests.Load(),
+		Failures:    c.failures.Load(),
+		Reopens:     c.reopens.Load(),
+	}
+}
+
+// SendRequest sends out a request and reads a response. The time to wait for
+// the response is limited by the context deadline or, if there is no
+// deadline, the client's read timeout.
+//
+// If the request cannot be written to a reused stream because the peer has
+// reset or closed it, then the request is sent again on a new stream. The
+// request is not sent again if it was written, since the peer may have
+// handled it. Use SendIdempotentRequest for requests that are safe to send
+// twice.
 func (c *Client) SendRequest(ctx context.Context, msg proto.Message) *Response {
-	c.sendLock.Lock()
-	defer c.sendLock.Unlock()
+	return c.sendRequest(ctx, msg, false)
+}
 
-	err := c.sendMessage(ctx, msg)
-	if err != nil {
-		return &Response{
-			Err: fmt.Errorf("cannot sent request: %w", err),
-		}
-	}
+// SendIdempotentRequest is like SendRequest, but is for requests that the
+// peer can safely handle more than once. If the request was written to a
+// reused stream, and the peer reset or closed the stream without sending any
+// part of a response, then the request is sent again on a new stream.
+func (c *Client) SendIdempotentRequest(ctx context.Context, msg proto.Message) *Response {
+	return c.sendRequest(ctx, msg, true)
+}
+
+func (c *Client) sendRequest(ctx context.Context, msg proto.Message, idempotent bool) *Response {
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	c.requests.Add(1)
 
-	rsp := c.readResponse(ctx)
+	rsp := c.withStream(ctx, func(cs *clientStream) (*Response, bool) {
+		if err := writeMsg(cs.stream, msg); err != nil {
+			return &Response{
+				Err: fmt.Errorf("cannot sent request: %w", err),
+			}, true
+		}
+		read := cs.read.n
+		rsp := c.readResponse(ctx, cs)
+		return rsp, idempotent && cs.read.n == read && streamClosed(rsp.Err)
+	})
 	if rsp.Err != nil {
-		c.closeStream()
+		c.failures.Add(1)
 	}
-
 	return rsp
 }
 
 // SendMessage sends out a message.
 func (c *Client) SendMessage(ctx context.Context, msg proto.Message) error {
-	c.sendLock.Lock()
-	defer c.sendLock.Unlock()
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	c.requests.Add(1)
 
-	return c.sendMessage(ctx, msg)
+	rsp := c.withStream(ctx, func(cs *clientStream) (*Response, bool) {
+		return &Response{
+			Err: writeMsg(cs.stream, msg),
+		}, true
+	})
+	if rsp.Err != nil {
+		c.failures.Add(1)
+	}
+	return rsp.Err
 }
 
-func (c *Client) sendMessage(ctx context.Context, msg proto.Message) error {
+// withStream calls fn with a stream, and then returns the stream to the idle
+// pool if fn succeeded. If fn fails on a reused stream and returns true to
+// indicate that the request can be retried, then fn is called again with a
+// new stream.
+func (c *Client) withStream(ctx context.Context, fn func(*clientStream) (*Response, bool)) *Response {
 	if ctx.Err() != nil {
-		return ctx.Err()
+		return &Response{
+			Err: ctx.Err(),
+		}
 	}
-	err := c.prepStreamReader(ctx)
+	cs, err := c.acquireStream(ctx)
 	if err != nil {
-		return err
+		return &Response{
+			Err: err,
+		}
 	}
+	for {
+		rsp, retry := fn(cs)
+		if rsp.Err == nil {
+			c.releaseStream(cs, true)
+			return rsp
+		}
+		if !cs.reused || !retry || ctx.Err() != nil {
+			c.releaseStream(cs, false)
+			return rsp
+		}
+		// The peer reset or closed the idle stream before handling the
+		// request, so retry with a new stream.
+		c.resetStream(cs)
+		c.reopens.Add(1)
+		cs, err = c.newStream(ctx)
+		if err != nil {
+			<-c.slots
+			return &Response{
+				Err: err,
+			}
+		}
+	}
+}
 
-	if err = writeMsg(c.stream, msg); err != nil {
-		c.closeStream()
-		return err
+// acquireStream gets an idle stream, or opens a new stream if there is none.
+// Waits for a stream to become available if the maximum number of streams are
+// in use. Streams that have been idle for longer than the max idle time are
+// reset instead of being reused.
+func (c *Client) acquireStream(ctx context.Context) (*clientStream, error) {
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 
-	return nil
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		<-c.slots
+		return nil, ErrClosed
+	}
+	stale := c.removeStale()
+	var cs *clientStream
+	if n := len(c.idle); n != 0 {
+		cs = c.idle[n-1]
+		c.idle[n-1] = nil
+		c.idle = c.idle[:n-1]
+	}
+	c.lock.Unlock()
+
+	for _, s := range stale {
+		c.resetStream(s)
+	}
+	if cs != nil {
+		cs.reused = true
+		return cs, nil
+	}
+
+	cs, err := c.newStream(ctx)
+	if err != nil {
+		<-c.slots
+		return nil, err
+	}
+	return cs, nil
 }
 
-func (c *Client) prepStreamReader(ctx context.Context) error {
-	if c.stream == nil {
-		nstr, err := c.host.NewStream(ctx, c.peerID, c.protoID)
-		if err != nil {
-			return err
-		}
+// removeStale removes the streams that have been idle for longer than the max
+// idle time from the idle pool, and returns them. Must be called with lock
+// held.
+func (c *Client) removeStale() []*clientStream {
+	if c.maxIdleTime == 0 {
+		return nil
+	}
+	// Streams are added to the end of the idle pool, so the oldest are first.
+	cutoff := time.Now().Add(-c.maxIdleTime)
+	var n int
+	for n < len(c.idle) && c.idle[n].idleSince.Before(cutoff) {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	stale := make([]*clientStream, n)
+	copy(stale, c.idle)
+	m := copy(c.idle, c.idle[n:])
+	for i := m; i < len(c.idle); i++ {
+		c.idle[i] = nil
+	}
+	c.idle = c.idle[:m]
+	return stale
+}
 
-		c.r = msgio.NewVarintReaderSize(nstr, network.MessageSizeMax)
-		c.stream = nstr
+func (c *Client) newStream(ctx context.Context) (*clientStream, error) {
+	nstr, err := c.host.NewStream(ctx, c.peerID, c.protoID)
+	if err != nil {
+		return nil, err
 	}
+	c.open.Add(1)
+	read := &countReader{r: nstr}
+	return &clientStream{
+		stream: nstr,
+		r:      msgio.NewVarintReaderSize(read, network.MessageSizeMax),
+		read:   read,
+	}, nil
+}
 
-	return nil
+// releaseStream returns a stream to the idle pool so that it can be reused,
+// or resets the stream if it cannot be reused.
+func (c *Client) releaseStream(cs *clientStream, reuse bool) {
+	defer func() { <-c.slots }()
+	if reuse {
+		c.lock.Lock()
+		if !c.closed {
+			cs.idleSince = time.Now()
+			c.idle = append(c.idle, cs)
+			c.lock.Unlock()
+			return
+		}
+		c.lock.Unlock()
+	}
+	c.resetStream(cs)
+}
+
+// streamClosed returns true if err is the result of the peer resetting or
+// closing the stream.
+func streamClosed(err error) bool {
+	return errors.Is(err, network.ErrReset) || errors.Is(err, io.EOF)
 }
 
-func (c *Client) closeStream() {
-	_ = c.stream.Reset()
-	c.stream = nil
-	c.r = nil
+func (c *Client) resetStream(cs *clientStream) {
+	_ = cs.stream.Reset()
+	c.open.Add(-1)
 }
 
-func (c *Client) readResponse(ctx context.Context) *Response {
+func (c *Client) readResponse(ctx context.Context, cs *clientStream) *Response {
 	rspCh := make(chan *Response, 1)
 	go func(r msgio.ReadCloser, rsp chan<- *Response) {
 		data, err := r.ReadMsg()
@@ -236,10 +473,14 @@ func (c *Client) readResponse(ctx context.Context) *Response {
 			Data:      data,
 			msgReader: r,
 		}
-	}(c.r, rspCh)
+	}(cs.r, rspCh)
 
-	t := time.NewTimer(readMessageTimeout)
-	defer t.Stop()
+	var timeout <-chan time.Time
+	if _, ok := ctx.Deadline(); !ok && c.readTimeout != 0 {
+		t := time.NewTimer(c.readTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
 
 	select {
 	case response := <-rspCh:
@@ -248,7 +489,7 @@ func (c *Client) readResponse(ctx context.Context) *Response {
 		return &Response{
 			Err: ctx.Err(),
 		}
-	case <-t.C:
+	case <-timeout:
 		return &Response{
 			Err: ErrReadTimeout,
 		}
@@ -0,0 +1,300 @@
+package p2pclient_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipni/go-libipni/p2pclient"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-msgio/pbio"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testProtoID = protocol.ID("/ipni-test/1.0.0")
+
+// newEchoServer starts a host that echoes each request message. A request
+// with the value "slow" is delayed, "hang" is never answered, "reset" resets
+// the stream shortly after responding, and "close" closes the stream after
+// responding. Requests received by the server are counted.
+func newEchoServer(t *testing.T) host.Host {
+	h, _ := newCountingEchoServer(t)
+	return h
+}
+
+func newCountingEchoServer(t *testing.T) (host.Host, *atomic.Int32) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+
+	var received atomic.Int32
+	h.SetStreamHandler(testProtoID, func(s network.Stream) {
+		r := pbio.NewDelimitedReader(s, network.MessageSizeMax)
+		w := pbio.NewDelimitedWriter(s)
+		for {
+			var msg wrapperspb.StringValue
+			if err := r.ReadMsg(&msg); err != nil {
+				_ = s.Reset()
+				return
+			}
+			received.Add(1)
+			switch msg.Value {
+			case "slow":
+				time.Sleep(200 * time.Millisecond)
+			case "hang":
+				continue
+			}
+			if err := w.WriteMsg(&msg); err != nil {
+				_ = s.Reset()
+				return
+			}
+			switch msg.Value {
+			case "reset":
+				// Give the client time to read the response.
+				time.Sleep(50 * time.Millisecond)
+				_ = s.Reset()
+				return
+			case "close":
+				_ = s.Close()
+				return
+			}
+		}
+	})
+	return h, &received
+}
+
+func newClient(t *testing.T, server host.Host, options ...p2pclient.Option) *p2pclient.Client {
+	c, err := p2pclient.New(nil, server.ID(), testProtoID, options...)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	err = c.ConnectAddrs(context.Background(), server.Addrs()...)
+	require.NoError(t, err)
+	return c
+}
+
+func sendString(ctx context.Context, c *p2pclient.Client, value string) (string, error) {
+	return decodeString(c.SendRequest(ctx, wrapperspb.String(value)))
+}
+
+func decodeString(rsp *p2pclient.Response) (string, error) {
+	if rsp.Err != nil {
+		return "", rsp.Err
+	}
+	defer rsp.Close()
+	var msg wrapperspb.StringValue
+	if err := proto.Unmarshal(rsp.Data, &msg); err != nil {
+		return "", err
+	}
+	return msg.Value, nil
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	server := newEchoServer(t)
+	c := newClient(t, server, p2pclient.WithMaxStreams(4))
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := sendString(context.Background(), c, "slow")
+			require.NoError(t, err)
+			require.Equal(t, "slow", value)
+		}()
+	}
+	wg.Wait()
+	// Requests are sent concurrently on separate streams, so they take about
+	// as long as a single request.
+	require.Less(t, time.Since(start), 600*time.Millisecond)
+
+	stats := c.Stats()
+	require.Zero(t, stats.InFlight)
+	require.Equal(t, 4, stats.OpenStreams)
+	require.Equal(t, 4, stats.IdleStreams)
+	require.Equal(t, uint64(4), stats.Requests)
+	require.Zero(t, stats.Failures)
+
+	// Idle streams are reused.
+	value, err := sendString(context.Background(), c, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+	require.Equal(t, 4, c.Stats().OpenStreams)
+
+	require.NoError(t, c.Close())
+	require.Zero(t, c.Stats().OpenStreams)
+	_, err = sendString(context.Background(), c, "hello")
+	require.ErrorIs(t, err, p2pclient.ErrClosed)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := newEchoServer(t)
+	c := newClient(t, server, p2pclient.WithMaxStreams(2), p2pclient.WithReadTimeout(time.Minute))
+
+	// A request that hangs does not block other requests.
+	hangErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		_, err := sendString(ctx, c, "hang")
+		hangErr <- err
+	}()
+	require.Eventually(t, func() bool { return c.Stats().InFlight == 1 }, time.Second, 10*time.Millisecond)
+	value, err := sendString(context.Background(), c, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+
+	// The context deadline is used instead of the read timeout.
+	err = <-hangErr
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	stats := c.Stats()
+	require.Equal(t, uint64(1), stats.Failures)
+	// The stream of the failed request is not reused.
+	require.Equal(t, 1, stats.OpenStreams)
+
+	// The read timeout is used when the context has no deadline.
+	c = newClient(t, server, p2pclient.WithReadTimeout(100*time.Millisecond))
+	_, err = sendString(context.Background(), c, "hang")
+	require.True(t, errors.Is(err, p2pclient.ErrReadTimeout))
+}
+
+func TestReopenResetStream(t *testing.T) {
+	server := newEchoServer(t)
+	c := newClient(t, server)
+
+	value, err := sendString(context.Background(), c, "reset")
+	require.NoError(t, err)
+	require.Equal(t, "reset", value)
+	// Wait for the reset to reach the client.
+	time.Sleep(200 * time.Millisecond)
+
+	// The request is retried on a new stream.
+	value, err = sendString(context.Background(), c, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+	stats := c.Stats()
+	require.Equal(t, uint64(1), stats.Reopens)
+	require.Zero(t, stats.Failures)
+	require.Equal(t, 1, stats.OpenStreams)
+}
+
+func TestNoRetryAfterWrite(t *testing.T) {
+	server, received := newCountingEchoServer(t)
+	c := newClient(t, server)
+
+	_, err := sendString(context.Background(), c, "close")
+	require.NoError(t, err)
+	// Wait for the close to reach the client.
+	time.Sleep(200 * time.Millisecond)
+
+	// The request is written to the closed stream, so it is not sent again
+	// in case the peer handled it.
+	_, err = sendString(context.Background(), c, "hello")
+	require.Error(t, err)
+	stats := c.Stats()
+	require.Zero(t, stats.Reopens)
+	require.Equal(t, uint64(1), stats.Failures)
+	require.Equal(t, int32(1), received.Load())
+
+	// The next request uses a new stream.
+	value, err := sendString(context.Background(), c, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+}
+
+func TestRetryIdempotentRequest(t *testing.T) {
+	server, received := newCountingEchoServer(t)
+	c := newClient(t, server)
+
+	_, err := sendString(context.Background(), c, "close")
+	require.NoError(t, err)
+	// Wait for the close to reach the client.
+	time.Sleep(200 * time.Millisecond)
+
+	// No part of a response was read, so the idempotent request is sent
+	// again on a new stream.
+	value, err := decodeString(c.SendIdempotentRequest(context.Background(), wrapperspb.String("hello")))
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+	stats := c.Stats()
+	require.Equal(t, uint64(1), stats.Reopens)
+	require.Zero(t, stats.Failures)
+	require.Equal(t, int32(2), received.Load())
+}
+
+// newIdleCloseServer starts a host that echoes each request message, and
+// closes a stream when no request is received on it within idleTimeout.
+// Requests received by the server are counted.
+func newIdleCloseServer(t *testing.T, idleTimeout time.Duration) (host.Host, *atomic.Int32) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+
+	var received atomic.Int32
+	h.SetStreamHandler(testProtoID, func(s network.Stream) {
+		r := pbio.NewDelimitedReader(s, network.MessageSizeMax)
+		w := pbio.NewDelimitedWriter(s)
+		for {
+			_ = s.SetReadDeadline(time.Now().Add(idleTimeout))
+			var msg wrapperspb.StringValue
+			if err := r.ReadMsg(&msg); err != nil {
+				_ = s.Close()
+				return
+			}
+			received.Add(1)
+			if err := w.WriteMsg(&msg); err != nil {
+				_ = s.Reset()
+				return
+			}
+		}
+	})
+	return h, &received
+}
+
+func TestMaxIdleTime(t *testing.T) {
+	server, received := newIdleCloseServer(t, 200*time.Millisecond)
+	c := newClient(t, server, p2pclient.WithMaxIdleTime(100*time.Millisecond))
+
+	_, err := sendString(context.Background(), c, "hello")
+	require.NoError(t, err)
+	require.Equal(t, 1, c.Stats().IdleStreams)
+	// Wait for the server to close the idle stream.
+	time.Sleep(400 * time.Millisecond)
+
+	// The stream was idle for longer than the max idle time, so the request
+	// is sent on a new stream instead.
+	value, err := sendString(context.Background(), c, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+	stats := c.Stats()
+	require.Zero(t, stats.Reopens)
+	require.Zero(t, stats.Failures)
+	require.Equal(t, 1, stats.OpenStreams)
+	require.Equal(t, int32(2), received.Load())
+
+	// Without a max idle time, the request is written to the closed stream.
+	c = newClient(t, server, p2pclient.WithMaxIdleTime(0))
+	_, err = sendString(context.Background(), c, "hello")
+	require.NoError(t, err)
+	time.Sleep(400 * time.Millisecond)
+	_, err = sendString(context.Background(), c, "hello")
+	require.Error(t, err)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, err := p2pclient.New(nil, peer.ID("x"), testProtoID, p2pclient.WithMaxStreams(0))
+	require.Error(t, err)
+	_, err = p2pclient.New(nil, peer.ID("x"), testProtoID, p2pclient.WithReadTimeout(-time.Second))
+	require.Error(t, err)
+	_, err = p2pclient.New(nil, peer.ID("x"), testProtoID, p2pclient.WithMaxIdleTime(-time.Second))
+	require.Error(t, err)
+}
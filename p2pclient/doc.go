@@ -3,4 +3,16 @@
 // This package supplies functionality to communicate raw data with libp2p
 // peers. It is useful for building higher-level clients that process data
 // which is sent and received by this package.
+//
+// A Client sends requests over a pool of streams to its peer, with one
+// request at a time on each stream. Use WithMaxStreams to allow concurrent
+// requests, and Client.Stats to see how many requests are in flight.
+//
+// A request that cannot be written to a reused stream, because the peer has
+// reset it, is sent again on a new stream. A request that was written is only
+// sent again if it is sent by SendIdempotentRequest or CallIdempotent and no
+// part of the response was read, since otherwise the peer may have handled it.
+// To avoid sending requests on streams that the peer closed for being idle,
+// streams that are idle for longer than the time set by WithMaxIdleTime are
+// not reused.
 package p2pclient
@@ -0,0 +1,77 @@
+package p2pclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/p2pclient/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrorMessageType is the type of a pb.Message that holds an error response.
+const ErrorMessageType uint32 = 0
+
+// ErrorMessage creates a pb.Message that holds an error response. The error is
+// encoded so that the status, code, retry after, and details of an
+// apierror.Error are preserved.
+func ErrorMessage(err error) *pb.Message {
+	return &pb.Message{
+		Type: ErrorMessageType,
+		Data: apierror.EncodeError(err),
+	}
+}
+
+// MessageError returns the error held by an error response message. Returns
+// nil if the message is not an error response.
+func MessageError(msg *pb.Message) error {
+	if msg.GetType() != ErrorMessageType {
+		return nil
+	}
+	err := apierror.DecodeError(msg.GetData())
+	if err == nil {
+		return errors.New("empty error response")
+	}
+	return err
+}
+
+// Message decodes the response data as a pb.Message. If the message is an
+// error response, then Err is set to the error that the message holds, and
+// that error is returned.
+func (r *Response) Message() (*pb.Message, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var msg pb.Message
+	if err := proto.Unmarshal(r.Data, &msg); err != nil {
+		return nil, fmt.Errorf("cannot decode response message: %w", err)
+	}
+	if err := MessageError(&msg); err != nil {
+		r.Err = err
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Call sends a request message of the given type and returns the response
+// message. If the peer responds with an error message, then the error that
+// the message holds is returned.
+func (c *Client) Call(ctx context.Context, msgType uint32, data []byte) (*pb.Message, error) {
+	return c.call(ctx, msgType, data, false)
+}
+
+// CallIdempotent is like Call, but is for requests that the peer can safely
+// handle more than once, such as lookups. See SendIdempotentRequest.
+func (c *Client) CallIdempotent(ctx context.Context, msgType uint32, data []byte) (*pb.Message, error) {
+	return c.call(ctx, msgType, data, true)
+}
+
+func (c *Client) call(ctx context.Context, msgType uint32, data []byte, idempotent bool) (*pb.Message, error) {
+	rsp := c.sendRequest(ctx, &pb.Message{
+		Type: msgType,
+		Data: data,
+	}, idempotent)
+	defer rsp.Close()
+	return rsp.Message()
+}
@@ -0,0 +1,93 @@
+package p2pclient
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultMaxStreams is the default maximum number of concurrent streams
+	// to the peer.
+	defaultMaxStreams = 1
+	// defaultReadTimeout is the default time to wait for a response when the
+	// request context has no deadline.
+	defaultReadTimeout = 10 * time.Second
+	// defaultMaxIdleTime is the default time that an idle stream is kept for
+	// reuse. This is less than the default time that a p2pserver waits for a
+	// request on an idle stream.
+	defaultMaxIdleTime = 30 * time.Second
+)
+
+type config struct {
+	maxIdleTime time.Duration
+	maxStreams  int
+	readTimeout time.Duration
+}
+
+// Option is a function that sets a value in a config.
+type Option func(*config) error
+
+// getOpts creates a config and applies Options to it.
+func getOpts(opts []Option) (config, error) {
+	cfg := config{
+		maxIdleTime: defaultMaxIdleTime,
+		maxStreams:  defaultMaxStreams,
+		readTimeout: defaultReadTimeout,
+	}
+	for i, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return config{}, fmt.Errorf("option %d failed: %s", i, err)
+		}
+	}
+	return cfg, nil
+}
+
+// WithMaxStreams sets the maximum number of streams that the client opens to
+// the peer. Each stream carries one request at a time, so this is the maximum
+// number of concurrent requests. Streams are kept open and reused by
+// subsequent requests. Requests wait for a stream when all are in use.
+//
+// Default is 1, which sends all requests over a single stream, one at a time.
+func WithMaxStreams(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return errors.New("max streams must be at least 1")
+		}
+		cfg.maxStreams = n
+		return nil
+	}
+}
+
+// WithMaxIdleTime sets the time that a stream is kept for reuse after its
+// last request. A stream that is idle for longer is reset instead of being
+// reused. This must be less than the time that the peer keeps idle streams
+// open, so that requests are not sent on a stream that the peer closed. A
+// value of 0 means that idle streams are reused no matter how long they were
+// idle.
+//
+// Default is 30 seconds.
+func WithMaxIdleTime(d time.Duration) Option {
+	return func(cfg *config) error {
+		if d < 0 {
+			return errors.New("max idle time cannot be negative")
+		}
+		cfg.maxIdleTime = d
+		return nil
+	}
+}
+
+// WithReadTimeout sets the time to wait for a response to a request whose
+// context has no deadline. If the context has a deadline, then the deadline is
+// used instead. A value of 0 means wait until the context is canceled.
+//
+// Default is 10 seconds.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(cfg *config) error {
+		if timeout < 0 {
+			return errors.New("read timeout cannot be negative")
+		}
+		cfg.readTimeout = timeout
+		return nil
+	}
+}
@@ -0,0 +1,162 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        (unknown)
+// source: p2pclient/pb/message.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Message is the envelope for requests and responses sent between a
+// p2pclient.Client and a p2pserver.Server.
+type Message struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Type identifies the kind of request or response. The values are defined
+	// by the protocol that uses the envelope, except for zero, which is an
+	// error response.
+	Type uint32 `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Data is the encoded request or response. It is a protobuf message
+	// defined by the protocol that uses the envelope, such as those in
+	// find/client/p2p/pb and ingest/client/p2p/pb. For an error response,
+	// this is a JSON encoded apierror.ErrorMessage.
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_p2pclient_pb_message_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_p2pclient_pb_message_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_p2pclient_pb_message_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Message) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Message) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_p2pclient_pb_message_proto protoreflect.FileDescriptor
+
+var file_p2pclient_pb_message_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x70, 0x32, 0x70, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2f, 0x70, 0x62, 0x2f, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x69, 0x70,
+	0x6e, 0x69, 0x2e, 0x70, 0x32, 0x70, 0x2e, 0x70, 0x62, 0x22, 0x31, 0x0a, 0x07, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x42, 0x29, 0x5a, 0x27,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x69, 0x70, 0x6e, 0x69, 0x2f,
+	0x67, 0x6f, 0x2d, 0x6c, 0x69, 0x62, 0x69, 0x70, 0x6e, 0x69, 0x2f, 0x70, 0x32, 0x70, 0x63, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_p2pclient_pb_message_proto_rawDescOnce sync.Once
+	file_p2pclient_pb_message_proto_rawDescData = file_p2pclient_pb_message_proto_rawDesc
+)
+
+func file_p2pclient_pb_message_proto_rawDescGZIP() []byte {
+	file_p2pclient_pb_message_proto_rawDescOnce.Do(func() {
+		file_p2pclient_pb_message_proto_rawDescData = protoimpl.X.CompressGZIP(file_p2pclient_pb_message_proto_rawDescData)
+	})
+	return file_p2pclient_pb_message_proto_rawDescData
+}
+
+var file_p2pclient_pb_message_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_p2pclient_pb_message_proto_goTypes = []interface{}{
+	(*Message)(nil), // 0: ipni.p2p.pb.Message
+}
+var file_p2pclient_pb_message_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_p2pclient_pb_message_proto_init() }
+func file_p2pclient_pb_message_proto_init() {
+	if File_p2pclient_pb_message_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_p2pclient_pb_message_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Message); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_p2pclient_pb_message_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_p2pclient_pb_message_proto_goTypes,
+		DependencyIndexes: file_p2pclient_pb_message_proto_depIdxs,
+		MessageInfos:      file_p2pclient_pb_message_proto_msgTypes,
+	}.Build()
+	File_p2pclient_pb_message_proto = out.File
+	file_p2pclient_pb_message_proto_rawDesc = nil
+	file_p2pclient_pb_message_proto_goTypes = nil
+	file_p2pclient_pb_message_proto_depIdxs = nil
+}
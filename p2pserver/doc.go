@@ -0,0 +1,14 @@
+// Package p2pserver provides general libp2p server functionality
+//
+// This package handles requests sent by a p2pclient.Client. Each request is a
+// pb.Message that is dispatched, according to its type, to the Handler that
+// is registered for that type. The response returned by the Handler, or the
+// error response if the Handler fails, is written back to the client on the
+// same stream. Error responses are decoded by Response.Message and
+// Client.Call in the p2pclient package.
+//
+// The server limits the size of request messages and the number of requests
+// from each peer that are handled concurrently. Streams that are idle for
+// longer than the idle timeout are reset. A client should stop reusing idle
+// streams before then, as p2pclient.Client does by default.
+package p2pserver
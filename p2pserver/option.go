@@ -0,0 +1,94 @@
+package p2pserver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+const (
+	// defaultMaxPeerRequests is the default maximum number of requests from
+	// a single peer that are handled concurrently.
+	defaultMaxPeerRequests = 8
+	// defaultIdleTimeout is the default time that a stream can wait for a
+	// request before it is closed.
+	defaultIdleTimeout = time.Minute
+)
+
+type config struct {
+	maxMessageSize  int
+	maxPeerRequests int
+	idleTimeout     time.Duration
+}
+
+// Option is a function that sets a value in a config.
+type Option func(*config) error
+
+// getOpts creates a config and applies Options to it.
+func getOpts(opts []Option) (config, error) {
+	cfg := config{
+		maxMessageSize:  network.MessageSizeMax,
+		maxPeerRequests: defaultMaxPeerRequests,
+		idleTimeout:     defaultIdleTimeout,
+	}
+	for i, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return config{}, fmt.Errorf("option %d failed: %s", i, err)
+		}
+	}
+	return cfg, nil
+}
+
+// WithMaxMessageSize sets the maximum size, in bytes, of a request message.
+// A peer that sends a larger message receives an error response, and its
+// stream is closed.
+//
+// Default is network.MessageSizeMax.
+func WithMaxMessageSize(size int) Option {
+	return func(cfg *config) error {
+		if size < 1 {
+			return errors.New("max message size must be at least 1")
+		}
+		cfg.maxMessageSize = size
+		return nil
+	}
+}
+
+// WithMaxPeerRequests sets the maximum number of requests from a single peer
+// that are handled concurrently. Requests from a peer that already has this
+// many requests in progress receive an error response with the code
+// apierror.CodeRateLimited.
+//
+// Default is 8.
+func WithMaxPeerRequests(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return errors.New("max peer requests must be at least 1")
+		}
+		cfg.maxPeerRequests = n
+		return nil
+	}
+}
+
+// WithIdleTimeout sets the time that a stream can wait for its next request
+// before the server resets it. A value of 0 means streams are never reset for
+// being idle.
+//
+// A p2pclient.Client sends a request again on a new stream if it cannot write
+// the request to a stream that was reset, but not if the request was written
+// before the reset arrived. The client's max idle time, set by
+// p2pclient.WithMaxIdleTime, must be less than this timeout so that the client
+// does not reuse a stream that the server is about to reset.
+//
+// Default is 1 minute.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(cfg *config) error {
+		if timeout < 0 {
+			return errors.New("idle timeout cannot be negative")
+		}
+		cfg.idleTimeout = timeout
+		return nil
+	}
+}
@@ -0,0 +1,342 @@
+package p2pserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/p2pclient"
+	"github.com/ipni/go-libipni/p2pclient/pb"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-msgio"
+	"github.com/libp2p/go-msgio/pbio"
+	"google.golang.org/protobuf/proto"
+)
+
+var log = logging.Logger("p2pserver")
+
+// Handler handles a request message from a peer and returns the response
+// message. If the handler returns an error, then an error response is sent to
+// the peer instead. Return an apierror.Error to set the status and code of the
+// error response. Any other error is sent with status
+// http.StatusInternalServerError.
+//
+// If the handler returns a nil response and no error, then an empty response
+// with the same type as the request is sent.
+//
+// The context is canceled if the peer resets the stream that the request was
+// sent on, such as when the peer stops waiting for the response, or if the
+// server is closed.
+type Handler func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error)
+
+// Server handles requests from libp2p peers on a single protocolID. Each
+// request is a pb.Message that is dispatched to the Handler registered for the
+// message's type, and the Handler's response is written back to the peer on
+// the same stream.
+type Server struct {
+	host            host.Host
+	protoID         protocol.ID
+	maxMessageSize  int
+	maxPeerRequests int
+	idleTimeout     time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	handlersMutex sync.RWMutex
+	handlers      map[uint32]Handler
+
+	lock     sync.Mutex
+	peerReqs map[peer.ID]int
+	streams  map[network.Stream]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// New creates a new Server that handles requests sent to the host using
+// protocolID.
+func New(p2pHost host.Host, protoID protocol.ID, options ...Option) (*Server, error) {
+	if p2pHost == nil {
+		return nil, errors.New("host is nil")
+	}
+	opts, err := getOpts(options)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		host:            p2pHost,
+		protoID:         protoID,
+		maxMessageSize:  opts.maxMessageSize,
+		maxPeerRequests: opts.maxPeerRequests,
+		idleTimeout:     opts.idleTimeout,
+		ctx:             ctx,
+		cancel:          cancel,
+		handlers:        make(map[uint32]Handler),
+		peerReqs:        make(map[peer.ID]int),
+		streams:         make(map[network.Stream]struct{}),
+	}
+	p2pHost.SetStreamHandler(protoID, s.handleStream)
+	return s, nil
+}
+
+// ProtocolID returns the protocol ID that the server handles requests for.
+func (s *Server) ProtocolID() protocol.ID {
+	return s.protoID
+}
+
+// Handle registers the handler for requests of the given message type,
+// replacing any previously registered handler. A nil handler removes the
+// handler for the message type. Requests for a message type that has no
+// handler receive an error response with the code
+// apierror.CodeNotImplemented.
+func (s *Server) Handle(msgType uint32, handler Handler) error {
+	if msgType == p2pclient.ErrorMessageType {
+		return errors.New("cannot handle error message type")
+	}
+	s.handlersMutex.Lock()
+	defer s.handlersMutex.Unlock()
+	if handler == nil {
+		delete(s.handlers, msgType)
+	} else {
+		s.handlers[msgType] = handler
+	}
+	return nil
+}
+
+// Close stops handling requests, resets all streams, and waits for handlers
+// that are in progress to return. The context given to handlers is canceled.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	streams := make([]network.Stream, 0, len(s.streams))
+	for stream := range s.streams {
+		streams = append(streams, stream)
+	}
+	s.lock.Unlock()
+
+	s.host.RemoveStreamHandler(s.protoID)
+	s.cancel()
+	for _, stream := range streams {
+		_ = stream.Reset()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Server) handleStream(stream network.Stream) {
+	if !s.addStream(stream) {
+		_ = stream.Reset()
+		return
+	}
+	defer s.removeStream(stream)
+
+	peerID := stream.Conn().RemotePeer()
+	r := msgio.NewVarintReaderSize(stream, s.maxMessageSize)
+	w := pbio.NewDelimitedWriter(stream)
+
+	// Requests are read by a separate goroutine, so that a reset of the
+	// stream by the peer is seen while a request is being handled. The
+	// context given to handlers is canceled when that happens.
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	reqs := make(chan readResult)
+	go readRequests(ctx, cancel, r, reqs)
+
+	var idle *time.Timer
+	var idleC <-chan time.Time
+	if s.idleTimeout != 0 {
+		idle = time.NewTimer(s.idleTimeout)
+		defer idle.Stop()
+		idleC = idle.C
+	}
+
+	for {
+		var res readResult
+		select {
+		case res = <-reqs:
+		case <-idleC:
+			// Reset, instead of close, the idle stream so that a request the
+			// peer sends on it fails to be written and can be sent again on
+			// a new stream.
+			_ = stream.Reset()
+			return
+		case <-ctx.Done():
+			_ = stream.Reset()
+			return
+		}
+		if idle != nil && !idle.Stop() {
+			select {
+			case <-idle.C:
+			default:
+			}
+		}
+		if res.err != nil {
+			if errors.Is(res.err, msgio.ErrMsgTooLarge) {
+				// The rest of the message cannot be skipped, so the stream
+				// cannot be used for further requests.
+				err := apierror.New(fmt.Errorf("request message exceeds %d bytes", s.maxMessageSize), http.StatusRequestEntityTooLarge).
+					WithCode(apierror.CodeBadRequest)
+				if werr := w.WriteMsg(p2pclient.ErrorMessage(err)); werr != nil {
+					log.Debugw("Cannot write error response", "err", werr, "peer", peerID)
+				}
+			}
+			_ = stream.Close()
+			return
+		}
+
+		var req pb.Message
+		err := proto.Unmarshal(res.data, &req)
+		r.ReleaseMsg(res.data)
+		var rsp *pb.Message
+		if err != nil {
+			err = apierror.New(fmt.Errorf("cannot decode request message: %w", err), http.StatusBadRequest)
+			rsp = p2pclient.ErrorMessage(err)
+		} else {
+			rsp = s.handleRequest(ctx, peerID, &req)
+		}
+
+		if err = w.WriteMsg(rsp); err != nil {
+			log.Debugw("Cannot write response", "err", err, "peer", peerID)
+			_ = stream.Reset()
+			return
+		}
+		if idle != nil {
+			idle.Reset(s.idleTimeout)
+		}
+	}
+}
+
+// readResult is a request message read from a stream, or the error that
+// stopped reading from the stream.
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// readRequests reads request messages from a stream and sends them to reqs,
+// until reading fails or ctx is canceled. If the stream is reset, then cancel
+// is called.
+func readRequests(ctx context.Context, cancel context.CancelFunc, r msgio.ReadCloser, reqs chan<- readResult) {
+	for {
+		data, err := r.ReadMsg()
+		if errors.Is(err, network.ErrReset) {
+			cancel()
+			return
+		}
+		select {
+		case reqs <- readResult{data: data, err: err}:
+		case <-ctx.Done():
+			if data != nil {
+				r.ReleaseMsg(data)
+			}
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleRequest dispatches a request to its handler and returns the response
+// message to send to the peer.
+func (s *Server) handleRequest(ctx context.Context, peerID peer.ID, req *pb.Message) *pb.Message {
+	s.handlersMutex.RLock()
+	handler := s.handlers[req.GetType()]
+	s.handlersMutex.RUnlock()
+	if handler == nil {
+		err := apierror.New(fmt.Errorf("unsupported message type %d", req.GetType()), http.StatusNotImplemented)
+		return p2pclient.ErrorMessage(err)
+	}
+
+	if !s.acquirePeer(peerID) {
+		err := apierror.New(errors.New("too many concurrent requests from peer"), http.StatusTooManyRequests)
+		return p2pclient.ErrorMessage(err)
+	}
+	defer s.releasePeer(peerID)
+
+	rsp, err := s.callHandler(ctx, handler, peerID, req)
+	if err != nil {
+		var apierr *apierror.Error
+		if !errors.As(err, &apierr) {
+			log.Errorw("Request handler failed", "err", err, "peer", peerID, "type", req.GetType())
+			err = apierror.New(err, http.StatusInternalServerError)
+		}
+		return p2pclient.ErrorMessage(err)
+	}
+	if rsp == nil {
+		return &pb.Message{Type: req.GetType()}
+	}
+	if rsp.GetType() == p2pclient.ErrorMessageType {
+		log.Errorw("Request handler returned error message type", "peer", peerID, "type", req.GetType())
+		return p2pclient.ErrorMessage(apierror.New(nil, http.StatusInternalServerError))
+	}
+	return rsp
+}
+
+// callHandler calls the handler, converting a panic into an error.
+func (s *Server) callHandler(ctx context.Context, handler Handler, peerID peer.ID, req *pb.Message) (rsp *pb.Message, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorw("Request handler panicked", "panic", r, "peer", peerID, "type", req.GetType())
+			rsp = nil
+			err = apierror.New(nil, http.StatusInternalServerError)
+		}
+	}()
+	return handler(ctx, peerID, req)
+}
+
+// acquirePeer reserves one of the peer's concurrent requests. Returns false if
+// the peer already has the maximum number of requests in progress.
+func (s *Server) acquirePeer(peerID peer.ID) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.peerReqs[peerID] >= s.maxPeerRequests {
+		return false
+	}
+	s.peerReqs[peerID]++
+	return true
+}
+
+func (s *Server) releasePeer(peerID peer.ID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.peerReqs[peerID] <= 1 {
+		delete(s.peerReqs, peerID)
+	} else {
+		s.peerReqs[peerID]--
+	}
+}
+
+// addStream tracks a stream so that it can be reset when the server is
+// closed. Returns false if the server is already closed.
+func (s *Server) addStream(stream network.Stream) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return false
+	}
+	s.streams[stream] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) removeStream(stream network.Stream) {
+	s.lock.Lock()
+	delete(s.streams, stream)
+	s.lock.Unlock()
+	s.wg.Done()
+}
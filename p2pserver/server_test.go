@@ -0,0 +1,251 @@
+package p2pserver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/p2pclient"
+	"github.com/ipni/go-libipni/p2pclient/pb"
+	"github.com/ipni/go-libipni/p2pserver"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testProtoID = protocol.ID("/ipni-test/1.0.0")
+
+	echoType     uint32 = 1
+	notFoundType uint32 = 2
+	failType     uint32 = 3
+	unknownType  uint32 = 99
+)
+
+func echo(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+	return &pb.Message{
+		Type: msg.Type,
+		Data: bytes.ToUpper(msg.Data),
+	}, nil
+}
+
+func newServer(t *testing.T, options ...p2pserver.Option) (host.Host, *p2pserver.Server) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+	s, err := p2pserver.New(h, testProtoID, options...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	require.NoError(t, s.Handle(echoType, echo))
+	return h, s
+}
+
+func newClient(t *testing.T, server host.Host, options ...p2pclient.Option) *p2pclient.Client {
+	c, err := p2pclient.New(nil, server.ID(), testProtoID, options...)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	err = c.ConnectAddrs(context.Background(), server.Addrs()...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestHandle(t *testing.T) {
+	h, s := newServer(t)
+	require.NoError(t, s.Handle(notFoundType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		return nil, apierror.New(errors.New("no such thing"), http.StatusNotFound).WithDetail("key", "value")
+	}))
+	require.NoError(t, s.Handle(failType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		return nil, errors.New("something broke")
+	}))
+	require.Error(t, s.Handle(p2pclient.ErrorMessageType, echo))
+	require.Equal(t, testProtoID, s.ProtocolID())
+
+	c := newClient(t, h)
+	ctx := context.Background()
+
+	rsp, err := c.Call(ctx, echoType, []byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, echoType, rsp.Type)
+	require.Equal(t, []byte("HELLO"), rsp.Data)
+
+	_, err = c.Call(ctx, notFoundType, nil)
+	var apierr *apierror.Error
+	require.ErrorAs(t, err, &apierr)
+	require.Equal(t, http.StatusNotFound, apierr.Status())
+	require.Equal(t, apierror.CodeNotFound, apierr.Code())
+	require.Equal(t, "value", apierr.Details()["key"])
+	require.Contains(t, err.Error(), "no such thing")
+
+	_, err = c.Call(ctx, failType, nil)
+	require.ErrorAs(t, err, &apierr)
+	require.Equal(t, http.StatusInternalServerError, apierr.Status())
+	require.True(t, apierror.IsCode(err, apierror.CodeInternal))
+
+	_, err = c.Call(ctx, unknownType, nil)
+	require.True(t, apierror.IsCode(err, apierror.CodeNotImplemented))
+
+	// Removing the handler makes the message type unsupported.
+	require.NoError(t, s.Handle(echoType, nil))
+	_, err = c.Call(ctx, echoType, nil)
+	require.True(t, apierror.IsCode(err, apierror.CodeNotImplemented))
+
+	// Error responses are also surfaced by Response.
+	require.NoError(t, s.Handle(echoType, echo))
+	resp := c.SendRequest(ctx, &pb.Message{Type: unknownType})
+	require.NoError(t, resp.Err)
+	_, err = resp.Message()
+	require.Error(t, err)
+	require.Equal(t, err, resp.Err)
+	resp.Close()
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	h, _ := newServer(t, p2pserver.WithMaxMessageSize(64))
+	c := newClient(t, h)
+	ctx := context.Background()
+
+	_, err := c.Call(ctx, echoType, make([]byte, 100))
+	var apierr *apierror.Error
+	require.ErrorAs(t, err, &apierr)
+	require.Equal(t, http.StatusRequestEntityTooLarge, apierr.Status())
+
+	// The server closed the stream, so the next request, which is safe to
+	// send again, uses a new stream.
+	rsp, err := c.CallIdempotent(ctx, echoType, []byte("small"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("SMALL"), rsp.Data)
+}
+
+func TestMaxPeerRequests(t *testing.T) {
+	h, s := newServer(t, p2pserver.WithMaxPeerRequests(1))
+	const blockType uint32 = 4
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	require.NoError(t, s.Handle(blockType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		close(started)
+		<-unblock
+		return nil, nil
+	}))
+
+	c := newClient(t, h, p2pclient.WithMaxStreams(2))
+	ctx := context.Background()
+
+	errCh := make(chan error, 1)
+	go func() {
+		rsp, err := c.Call(ctx, blockType, nil)
+		if err == nil && rsp.Type != blockType {
+			err = errors.New("wrong response type")
+		}
+		errCh <- err
+	}()
+	<-started
+
+	_, err := c.Call(ctx, echoType, []byte("hello"))
+	require.True(t, apierror.IsCode(err, apierror.CodeRateLimited))
+
+	close(unblock)
+	require.NoError(t, <-errCh)
+
+	_, err = c.Call(ctx, echoType, []byte("hello"))
+	require.NoError(t, err)
+}
+
+func TestIdleTimeout(t *testing.T) {
+	h, _ := newServer(t, p2pserver.WithIdleTimeout(100*time.Millisecond))
+	ctx := context.Background()
+
+	// The client reuses streams no matter how long they were idle.
+	c := newClient(t, h, p2pclient.WithMaxIdleTime(0))
+	_, err := c.Call(ctx, echoType, []byte("one"))
+	require.NoError(t, err)
+
+	// Wait for the server to reset the idle stream.
+	time.Sleep(300 * time.Millisecond)
+
+	// The request cannot be written to the reset stream, so it is sent again
+	// on a new stream.
+	rsp, err := c.Call(ctx, echoType, []byte("two"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("TWO"), rsp.Data)
+	require.Equal(t, uint64(1), c.Stats().Reopens)
+
+	// The client does not reuse streams that were idle for longer than its
+	// max idle time, which is less than the server's idle timeout.
+	c = newClient(t, h, p2pclient.WithMaxIdleTime(50*time.Millisecond))
+	_, err = c.Call(ctx, echoType, []byte("one"))
+	require.NoError(t, err)
+	time.Sleep(300 * time.Millisecond)
+	rsp, err = c.Call(ctx, echoType, []byte("two"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("TWO"), rsp.Data)
+	require.Zero(t, c.Stats().Reopens)
+}
+
+func TestHandlerContextCanceled(t *testing.T) {
+	const waitType uint32 = 4
+	h, s := newServer(t)
+	canceled := make(chan error, 1)
+	require.NoError(t, s.Handle(waitType, func(ctx context.Context, peerID peer.ID, msg *pb.Message) (*pb.Message, error) {
+		select {
+		case <-ctx.Done():
+			canceled <- ctx.Err()
+		case <-time.After(5 * time.Second):
+			canceled <- nil
+		}
+		return nil, nil
+	}))
+	c := newClient(t, h)
+
+	// The client resets the stream when it stops waiting for the response,
+	// which cancels the handler's context.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := c.Call(ctx, waitType, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	select {
+	case err = <-canceled:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("handler context not canceled")
+	}
+
+	// Other requests are still handled.
+	rsp, err := c.Call(context.Background(), echoType, []byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("HELLO"), rsp.Data)
+}
+
+func TestClose(t *testing.T) {
+	h, s := newServer(t)
+	c := newClient(t, h)
+	ctx := context.Background()
+
+	_, err := c.Call(ctx, echoType, []byte("hello"))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Close())
+	_, err = c.Call(ctx, echoType, []byte("hello"))
+	require.Error(t, err)
+	require.NoError(t, s.Close())
+}
+
+func TestInvalidOptions(t *testing.T) {
+	h, err := libp2p.New(libp2p.NoListenAddrs)
+	require.NoError(t, err)
+	defer h.Close()
+
+	_, err = p2pserver.New(h, testProtoID, p2pserver.WithMaxMessageSize(0))
+	require.Error(t, err)
+	_, err = p2pserver.New(h, testProtoID, p2pserver.WithMaxPeerRequests(0))
+	require.Error(t, err)
+	_, err = p2pserver.New(h, testProtoID, p2pserver.WithIdleTimeout(-time.Second))
+	require.Error(t, err)
+	_, err = p2pserver.New(nil, testProtoID)
+	require.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package adsource
+
+import (
+	"errors"
+	"fmt"
+)
+
+const defaultMaxDepth = 1000
+
+type config struct {
+	maxDepth int
+}
+
+// Option is a function that sets a value in a config.
+type Option func(*config) error
+
+// getOpts creates a config and applies Options to it.
+func getOpts(opts []Option) (config, error) {
+	cfg := config{
+		maxDepth: defaultMaxDepth,
+	}
+	for i, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return config{}, fmt.Errorf("option %d failed: %s", i, err)
+		}
+	}
+	return cfg, nil
+}
+
+// WithMaxDepth sets the maximum number of advertisements read after a sync.
+// The advertisements read after a sync are held in memory until they are
+// applied in chain order, so this limits the memory used when a long chain is
+// synced. If more advertisements were synced, then only the newest are
+// applied, and any context-level extended providers set by the older
+// advertisements are not seen.
+//
+// Default is 1000.
+func WithMaxDepth(depth int) Option {
+	return func(cfg *config) error {
+		if depth < 1 {
+			return errors.New("max depth must be greater than zero")
+		}
+		cfg.maxDepth = depth
+		return nil
+	}
+}
@@ -0,0 +1,293 @@
+// Package adsource provides a pcache.ProviderSource that derives provider
+// information from advertisements synced by a dagsync Subscriber.
+//
+// This is separate from the pcache package so that pcache users, such as find
+// clients, do not depend on dagsync.
+package adsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipni/go-libipni/dagsync"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/ipni/go-libipni/pcache"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+var log = logging.Logger("adsource")
+
+// SyncNotifier supplies notifications of completed advertisement chain syncs.
+// It is implemented by dagsync.Subscriber.
+type SyncNotifier interface {
+	OnSyncFinished() (<-chan dagsync.SyncFinished, context.CancelFunc)
+}
+
+// Source is a pcache.ProviderSource that derives provider information from
+// advertisements synced by a dagsync Subscriber. This allows provider
+// information to be cached without depending on an indexer's provider
+// listing.
+//
+// When an advertisement chain sync finishes, the newly synced advertisements
+// are read from the link system, from oldest to newest, and used to update the
+// provider's addresses, publisher, and chain-level and context-level extended
+// providers. Advertisements that do not have a valid signature from the
+// provider or the publisher are ignored.
+//
+// Advertisements do not record when they were published, so the
+// LastAdvertisementTime of the provider information is the time that the
+// provider's latest advertisement was read by the Source.
+type Source struct {
+	lsys     ipld.LinkSystem
+	maxDepth int
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	lock      sync.RWMutex
+	providers map[peer.ID]*model.ProviderInfo
+	// heads is the most recently processed advertisement for each publisher.
+	heads map[peer.ID]cid.Cid
+}
+
+// Source must implement pcache.ProviderSource.
+var _ pcache.ProviderSource = (*Source)(nil)
+
+// New creates a Source that reads advertisements from the link system after
+// each sync reported by the SyncNotifier. The link system must be the one that
+// the Subscriber stores synced advertisements in. Call Close to stop receiving
+// sync notifications.
+func New(sub SyncNotifier, lsys ipld.LinkSystem, options ...Option) (*Source, error) {
+	opts, err := getOpts(options)
+	if err != nil {
+		return nil, err
+	}
+	events, cancel := sub.OnSyncFinished()
+	s := &Source{
+		lsys:      lsys,
+		maxDepth:  opts.maxDepth,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		providers: make(map[peer.ID]*model.ProviderInfo),
+		heads:     make(map[peer.ID]cid.Cid),
+	}
+	go s.run(events)
+	return s, nil
+}
+
+// Close stops receiving sync notifications. Provider information that is
+// already collected remains available.
+func (s *Source) Close() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *Source) Fetch(_ context.Context, pid peer.ID) (*model.ProviderInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.providers[pid], nil
+}
+
+func (s *Source) FetchAll(_ context.Context) ([]*model.ProviderInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	pinfos := make([]*model.ProviderInfo, 0, len(s.providers))
+	for _, pinfo := range s.providers {
+		pinfos = append(pinfos, pinfo)
+	}
+	return pinfos, nil
+}
+
+func (s *Source) String() string {
+	return "advertisement source"
+}
+
+func (s *Source) run(events <-chan dagsync.SyncFinished) {
+	defer close(s.done)
+	for event := range events {
+		if event.AsyncErr != nil || event.Cid == cid.Undef {
+			continue
+		}
+		if err := s.processSync(context.Background(), event.PeerID, event.Cid); err != nil {
+			log.Errorw("Cannot get provider info from advertisements", "err", err, "publisher", event.PeerID)
+		}
+	}
+}
+
+// processSync reads the advertisements from the new head back to the
+// previously processed head, and applies them in chain order. No more than
+// maxDepth advertisements are read.
+func (s *Source) processSync(ctx context.Context, publisher peer.ID, head cid.Cid) error {
+	s.lock.RLock()
+	prevHead := s.heads[publisher]
+	s.lock.RUnlock()
+
+	var ads []*schema.Advertisement
+	var adCids []cid.Cid
+	for c := head; c != cid.Undef && c != prevHead; {
+		ad, err := s.loadAd(ctx, c)
+		if err != nil {
+			if len(ads) == 0 {
+				return err
+			}
+			// Rest of chain not synced, so process what was loaded.
+			log.Warnw("Cannot load advertisement", "err", err, "cid", c)
+			break
+		}
+		ads = append(ads, ad)
+		adCids = append(adCids, c)
+		prev, ok := ad.PreviousID.(cidlink.Link)
+		if !ok {
+			break
+		}
+		c = prev.Cid
+		if len(ads) == s.maxDepth && c != prevHead {
+			log.Warnw("Reached max depth, skipping older advertisements", "depth", s.maxDepth, "publisher", publisher)
+			break
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i := len(ads) - 1; i >= 0; i-- {
+		if err := s.applyAd(ads[i], adCids[i], publisher); err != nil {
+			log.Warnw("Ignoring advertisement", "err", err, "cid", adCids[i], "publisher", publisher)
+		}
+	}
+	s.heads[publisher] = head
+	return nil
+}
+
+func (s *Source) loadAd(ctx context.Context, c cid.Cid) (*schema.Advertisement, error) {
+	node, err := s.lsys.Load(ipld.LinkContext{Ctx: ctx}, cidlink.Link{Cid: c}, schema.AdvertisementPrototype)
+	if err != nil {
+		return nil, err
+	}
+	return schema.UnwrapAdvertisement(node)
+}
+
+// applyAd updates the provider information for the advertisement's provider.
+// A new ProviderInfo is created for each update, since previously returned
+// values may be in use by the cache. Must be called with lock held.
+func (s *Source) applyAd(ad *schema.Advertisement, adCid cid.Cid, publisher peer.ID) error {
+	pid, err := peer.Decode(ad.Provider)
+	if err != nil {
+		return fmt.Errorf("bad provider id: %w", err)
+	}
+	signer, err := ad.VerifySignature()
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if signer != pid && signer != publisher {
+		return errors.New("advertisement not signed by provider or publisher")
+	}
+
+	var pinfo model.ProviderInfo
+	if prev, ok := s.providers[pid]; ok {
+		pinfo = *prev
+	} else {
+		pinfo.AddrInfo.ID = pid
+	}
+	if len(ad.Addresses) != 0 {
+		pinfo.AddrInfo.Addrs = stringsToMultiaddrs(ad.Addresses)
+	}
+	pinfo.Publisher = &peer.AddrInfo{ID: publisher}
+	pinfo.LastAdvertisement = adCid
+	pinfo.LastAdvertisementTime = time.Now().UTC().Format(time.RFC3339Nano)
+
+	if ad.IsRm && len(ad.ContextID) != 0 {
+		pinfo.ExtendedProviders = removeContextual(pinfo.ExtendedProviders, string(ad.ContextID))
+	} else if ad.ExtendedProvider != nil {
+		pinfo.ExtendedProviders = updateExtended(pinfo.ExtendedProviders, ad)
+	}
+
+	s.providers[pid] = &pinfo
+	return nil
+}
+
+// updateExtended returns a copy of the extended providers updated with the
+// advertisement's extended providers. Extended providers in an advertisement
+// without a context ID apply to the whole chain.
+func updateExtended(xps *model.ExtendedProviders, ad *schema.Advertisement) *model.ExtendedProviders {
+	var newXPs model.ExtendedProviders
+	if xps != nil {
+		newXPs = *xps
+	}
+
+	providers := make([]peer.AddrInfo, 0, len(ad.ExtendedProvider.Providers))
+	metadatas := make([][]byte, 0, len(ad.ExtendedProvider.Providers))
+	for _, p := range ad.ExtendedProvider.Providers {
+		xpid, err := peer.Decode(p.ID)
+		if err != nil {
+			log.Warnw("Ignoring extended provider with bad id", "err", err, "id", p.ID)
+			continue
+		}
+		providers = append(providers, peer.AddrInfo{
+			ID:    xpid,
+			Addrs: stringsToMultiaddrs(p.Addresses),
+		})
+		metadatas = append(metadatas, p.Metadata)
+	}
+
+	if len(ad.ContextID) == 0 {
+		newXPs.Providers = providers
+		newXPs.Metadatas = metadatas
+		return &newXPs
+	}
+
+	ctxID := string(ad.ContextID)
+	contextual := make([]model.ContextualExtendedProviders, 0, len(newXPs.Contextual)+1)
+	for _, cxp := range newXPs.Contextual {
+		if cxp.ContextID != ctxID {
+			contextual = append(contextual, cxp)
+		}
+	}
+	newXPs.Contextual = append(contextual, model.ContextualExtendedProviders{
+		Override:  ad.ExtendedProvider.Override,
+		ContextID: ctxID,
+		Providers: providers,
+		Metadatas: metadatas,
+	})
+	return &newXPs
+}
+
+// removeContextual returns a copy of the extended providers without the
+// context-level extended providers for the context ID.
+func removeContextual(xps *model.ExtendedProviders, ctxID string) *model.ExtendedProviders {
+	if xps == nil || len(xps.Contextual) == 0 {
+		return xps
+	}
+	newXPs := *xps
+	newXPs.Contextual = make([]model.ContextualExtendedProviders, 0, len(xps.Contextual))
+	for _, cxp := range xps.Contextual {
+		if cxp.ContextID != ctxID {
+			newXPs.Contextual = append(newXPs.Contextual, cxp)
+		}
+	}
+	if len(newXPs.Providers) == 0 && len(newXPs.Contextual) == 0 {
+		return nil
+	}
+	return &newXPs
+}
+
+func stringsToMultiaddrs(addrs []string) []multiaddr.Multiaddr {
+	maddrs := make([]multiaddr.Multiaddr, 0, len(addrs))
+	for _, addr := range addrs {
+		maddr, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			log.Warnw("Ignoring bad multiaddr", "err", err, "addr", addr)
+			continue
+		}
+		maddrs = append(maddrs, maddr)
+	}
+	return maddrs
+}
@@ -0,0 +1,168 @@
+package adsource_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipni/go-libipni/dagsync"
+	dagsynctest "github.com/ipni/go-libipni/dagsync/test"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/ipni/go-libipni/pcache"
+	"github.com/ipni/go-libipni/pcache/adsource"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNotifier struct {
+	events chan dagsync.SyncFinished
+}
+
+func (n *mockNotifier) OnSyncFinished() (<-chan dagsync.SyncFinished, context.CancelFunc) {
+	return n.events, func() { close(n.events) }
+}
+
+func TestSource(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	lsys := dagsynctest.MkLinkSystem(ds)
+
+	provID, provKey, _ := test.RandomIdentity()
+	xpID, xpKey, _ := test.RandomIdentity()
+	pubID, _, _ := test.RandomIdentity()
+	provAddrs := test.RandomAddrs(1)
+	xpAddrs := test.RandomAddrs(1)
+	keyFetcher := func(string) (crypto.PrivKey, error) {
+		return xpKey, nil
+	}
+
+	// First ad has chain-level extended providers.
+	ad := schema.Advertisement{
+		Provider:  provID.String(),
+		Addresses: provAddrs,
+		Entries:   schema.NoEntries,
+		Metadata:  []byte("meta"),
+		ExtendedProvider: &schema.ExtendedProvider{
+			Providers: []schema.Provider{
+				{ID: provID.String(), Addresses: provAddrs},
+				{ID: xpID.String(), Addresses: xpAddrs, Metadata: []byte("xp-meta")},
+			},
+		},
+	}
+	require.NoError(t, ad.SignWithExtendedProviders(provKey, keyFetcher))
+	node, err := ad.ToNode()
+	require.NoError(t, err)
+	lnk1, err := lsys.Store(ipld.LinkContext{}, schema.Linkproto, node)
+	require.NoError(t, err)
+
+	// Second ad has context-level extended providers that override the
+	// chain-level extended providers.
+	ad.PreviousID = lnk1
+	ad.ContextID = []byte("ctx2")
+	ad.ExtendedProvider = &schema.ExtendedProvider{
+		Providers: []schema.Provider{
+			{ID: provID.String(), Addresses: provAddrs},
+		},
+		Override: true,
+	}
+	require.NoError(t, ad.SignWithExtendedProviders(provKey, keyFetcher))
+	node, err = ad.ToNode()
+	require.NoError(t, err)
+	lnk2, err := lsys.Store(ipld.LinkContext{}, schema.Linkproto, node)
+	require.NoError(t, err)
+
+	notifier := &mockNotifier{
+		events: make(chan dagsync.SyncFinished, 1),
+	}
+	src, err := adsource.New(notifier, lsys)
+	require.NoError(t, err)
+	notifier.events <- dagsync.SyncFinished{
+		Cid:    lnk2.(cidlink.Link).Cid,
+		PeerID: pubID,
+		Count:  2,
+	}
+	src.Close()
+
+	pc, err := pcache.New(pcache.WithSource(src))
+	require.NoError(t, err)
+
+	pinfo, err := pc.Get(context.Background(), provID)
+	require.NoError(t, err)
+	require.NotNil(t, pinfo)
+	require.Equal(t, provAddrs[0], pinfo.AddrInfo.Addrs[0].String())
+	require.Equal(t, pubID, pinfo.Publisher.ID)
+	require.Equal(t, lnk2.(cidlink.Link).Cid, pinfo.LastAdvertisement)
+
+	results, err := pc.GetResults(context.Background(), provID, []byte("ctx1"), []byte("meta"))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, xpID, results[1].Provider.ID)
+	require.Equal(t, []byte("xp-meta"), results[1].Metadata)
+
+	results, err = pc.GetResults(context.Background(), provID, []byte("ctx2"), []byte("meta"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+}
+
+func TestMaxDepth(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	lsys := dagsynctest.MkLinkSystem(ds)
+
+	provID, provKey, _ := test.RandomIdentity()
+	pubID, _, _ := test.RandomIdentity()
+	provAddrs := test.RandomAddrs(1)
+
+	// Oldest ad has context-level extended providers.
+	ad := schema.Advertisement{
+		Provider:  provID.String(),
+		Addresses: provAddrs,
+		Entries:   schema.NoEntries,
+		ContextID: []byte("ctx1"),
+		Metadata:  []byte("meta"),
+		ExtendedProvider: &schema.ExtendedProvider{
+			Providers: []schema.Provider{
+				{ID: provID.String(), Addresses: provAddrs},
+			},
+		},
+	}
+	require.NoError(t, ad.SignWithExtendedProviders(provKey, nil))
+	var lnk ipld.Link
+	for i := 0; i < 3; i++ {
+		node, err := ad.ToNode()
+		require.NoError(t, err)
+		lnk, err = lsys.Store(ipld.LinkContext{}, schema.Linkproto, node)
+		require.NoError(t, err)
+
+		ad.PreviousID = lnk
+		ad.ContextID = []byte(fmt.Sprint("ctx", i+2))
+		ad.ExtendedProvider = nil
+		require.NoError(t, ad.Sign(provKey))
+	}
+
+	notifier := &mockNotifier{
+		events: make(chan dagsync.SyncFinished, 1),
+	}
+	src, err := adsource.New(notifier, lsys, adsource.WithMaxDepth(2))
+	require.NoError(t, err)
+	notifier.events <- dagsync.SyncFinished{
+		Cid:    lnk.(cidlink.Link).Cid,
+		PeerID: pubID,
+		Count:  3,
+	}
+	src.Close()
+
+	// The oldest ad is beyond the max depth, so its extended providers are
+	// not seen.
+	pinfo, err := src.Fetch(context.Background(), provID)
+	require.NoError(t, err)
+	require.NotNil(t, pinfo)
+	require.Equal(t, lnk.(cidlink.Link).Cid, pinfo.LastAdvertisement)
+	require.Nil(t, pinfo.ExtendedProviders)
+
+	_, err = adsource.New(notifier, lsys, adsource.WithMaxDepth(0))
+	require.Error(t, err)
+}
@@ -14,6 +14,16 @@
 // multiple data sources, then the information with the most recent timestamp
 // is used.
 //
+// An HTTP data source created with NewHTTPDeltaSource avoids downloading all
+// provider information at each refresh. It uses conditional requests, and an
+// incremental listing of only changed providers when the server supports it.
+// Since unchanged providers are not updated in the cache, refreshing the cache
+// from a delta source does not regenerate the cache's read-only data.
+//
+// The Source in the adsource subpackage derives provider information directly
+// from advertisements synced by a dagsync Subscriber, so that the cache does
+// not depend on an indexer's provider listing.
+//
 // ## All Provider Information Cached
 //
 // The provider cache maintains a unified view of all provider information
@@ -54,4 +64,31 @@
 // time-to-live. The time-to-live countdown begins when the information is no
 // longer seen from any source. Negative cache entries are also evicted after
 // having been in the cache for the time-to-live.
+//
+// The cache size can be bounded by setting a maximum number of entries. When
+// the cache is full, a refresh only updates providers that are already cached,
+// and fetching a provider that is not cached evicts the least recently used or
+// least frequently used provider. Lookups record accesses without locking,
+// since access information is shared with the read-only data. The number of
+// negative cache entries can be bounded separately, in which case the oldest
+// negative entries are evicted first. ProviderCache.Stats reports the number
+// of entries and evictions.
+//
+// ## Snapshots
+//
+// The cache can be configured with a SnapshotStore to persist its provider
+// information, either to a file or to a datastore. When the cache is created,
+// a saved snapshot is loaded so that cached data can be served immediately.
+// Preloading from the data sources is then done in the background, and the
+// snapshot data is reconciled with the data sources as with any refresh.
+// Snapshots are saved after a refresh when the snapshot interval has elapsed,
+// and when the cache is closed.
+//
+// ## Subscriptions
+//
+// Calling ProviderCache.Subscribe returns a channel that receives an Event
+// for each change to cached provider information: providers being added or
+// removed, and changes to a provider's addresses, extended providers, or
+// publisher. This allows dependent components to react to changes without
+// polling the cache.
 package pcache
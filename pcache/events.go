@@ -0,0 +1,234 @@
+package pcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/gammazero/channelqueue"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// EventType identifies the kind of change described by an Event.
+type EventType int
+
+const (
+	// ProviderAdded means that information for a provider was added to the
+	// cache.
+	ProviderAdded EventType = iota + 1
+	// ProviderRemoved means that information for a provider expired and was
+	// removed from the cache.
+	ProviderRemoved
+	// AddrsChanged means that a provider's addresses changed.
+	AddrsChanged
+	// ExtendedProvidersChanged means that a provider's chain-level or
+	// context-level extended providers changed.
+	ExtendedProvidersChanged
+	// PublisherChanged means that a provider's publisher ID or addresses
+	// changed.
+	PublisherChanged
+	// ProviderEvicted means that information for a provider was evicted from
+	// the cache to stay within the maximum number of entries.
+	ProviderEvicted
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case ProviderAdded:
+		return "ProviderAdded"
+	case ProviderRemoved:
+		return "ProviderRemoved"
+	case AddrsChanged:
+		return "AddrsChanged"
+	case ExtendedProvidersChanged:
+		return "ExtendedProvidersChanged"
+	case PublisherChanged:
+		return "PublisherChanged"
+	case ProviderEvicted:
+		return "ProviderEvicted"
+	}
+	return "Unknown"
+}
+
+// Event describes a change to the cached information for a provider.
+type Event struct {
+	// Type is the kind of change.
+	Type EventType
+	// ProviderID identifies the provider whose information changed.
+	ProviderID peer.ID
+	// Provider is the new provider information. This is nil if the provider
+	// was removed or evicted.
+	Provider *model.ProviderInfo
+	// Previous is the previous provider information. This is nil if the
+	// provider was added.
+	Previous *model.ProviderInfo
+}
+
+// Subscribe creates a channel that receives events describing changes to
+// cached provider information. Events are generated when the cache is
+// refreshed and when information for a missing provider is fetched. A single
+// change to a provider's information may generate multiple events, one for
+// each type of change.
+//
+// Calling the returned cancel function removes the event channel from the
+// list of channels to be notified on changes, and it closes the channel to
+// allow any reading goroutines to stop waiting on the channel. The channel is
+// also closed when the cache is closed.
+func (pc *ProviderCache) Subscribe() (<-chan Event, context.CancelFunc) {
+	cq := channelqueue.New[Event](-1)
+	ch := cq.In()
+
+	pc.subsLock.Lock()
+	if pc.closed.Load() {
+		pc.subsLock.Unlock()
+		close(ch)
+		return cq.Out(), func() {}
+	}
+	pc.subs = append(pc.subs, ch)
+	pc.subsLock.Unlock()
+
+	cncl := func() {
+		pc.subsLock.Lock()
+		defer pc.subsLock.Unlock()
+		for i, sub := range pc.subs {
+			if sub == ch {
+				pc.subs[i] = pc.subs[len(pc.subs)-1]
+				pc.subs[len(pc.subs)-1] = nil
+				pc.subs = pc.subs[:len(pc.subs)-1]
+				close(ch)
+				return
+			}
+		}
+	}
+	return cq.Out(), cncl
+}
+
+// hasSubscribers returns true if there are any event subscribers.
+func (pc *ProviderCache) hasSubscribers() bool {
+	pc.subsLock.Lock()
+	defer pc.subsLock.Unlock()
+	return len(pc.subs) != 0
+}
+
+// publish sends events to all subscribers. This does not block since each
+// subscriber channel is the input of an unbounded queue.
+func (pc *ProviderCache) publish(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	pc.subsLock.Lock()
+	defer pc.subsLock.Unlock()
+	for _, sub := range pc.subs {
+		for _, event := range events {
+			sub <- event
+		}
+	}
+}
+
+// closeSubscribers closes all subscriber channels.
+func (pc *ProviderCache) closeSubscribers() {
+	pc.subsLock.Lock()
+	defer pc.subsLock.Unlock()
+	for _, sub := range pc.subs {
+		close(sub)
+	}
+	pc.subs = nil
+}
+
+// changeEvents appends the events that describe the change from the previous
+// to the new provider information.
+func changeEvents(events []Event, pid peer.ID, prev, cur *model.ProviderInfo) []Event {
+	if prev == nil {
+		if cur == nil {
+			return events
+		}
+		return append(events, Event{
+			Type:       ProviderAdded,
+			ProviderID: pid,
+			Provider:   cur,
+		})
+	}
+	if cur == nil {
+		return append(events, Event{
+			Type:       ProviderRemoved,
+			ProviderID: pid,
+			Previous:   prev,
+		})
+	}
+
+	if !addrsEqual(prev.AddrInfo.Addrs, cur.AddrInfo.Addrs) {
+		events = append(events, Event{
+			Type:       AddrsChanged,
+			ProviderID: pid,
+			Provider:   cur,
+			Previous:   prev,
+		})
+	}
+	if !extendedProvidersEqual(prev.ExtendedProviders, cur.ExtendedProviders) {
+		events = append(events, Event{
+			Type:       ExtendedProvidersChanged,
+			ProviderID: pid,
+			Provider:   cur,
+			Previous:   prev,
+		})
+	}
+	if !addrInfoEqual(prev.Publisher, cur.Publisher) {
+		events = append(events, Event{
+			Type:       PublisherChanged,
+			ProviderID: pid,
+			Provider:   cur,
+			Previous:   prev,
+		})
+	}
+	return events
+}
+
+// appendEvicted appends a ProviderEvicted event for an evicted cache entry.
+// Nothing is appended for evicted negative cache entries.
+func appendEvicted(events []Event, v *evicted) []Event {
+	if v.cinfo.provider == nil {
+		return events
+	}
+	return append(events, Event{
+		Type:       ProviderEvicted,
+		ProviderID: v.pid,
+		Previous:   v.cinfo.provider,
+	})
+}
+
+func addrsEqual(a, b []multiaddr.Multiaddr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func addrInfoEqual(a, b *peer.AddrInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ID == b.ID && addrsEqual(a.Addrs, b.Addrs)
+}
+
+func extendedProvidersEqual(a, b *model.ExtendedProviders) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	aData, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bData, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aData, bData)
+}
@@ -0,0 +1,180 @@
+package pcache
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// EvictionPolicy determines which provider information is evicted from a
+// cache that has reached its maximum number of entries.
+type EvictionPolicy int
+
+const (
+	// EvictLRU evicts the least recently used provider information.
+	EvictLRU EvictionPolicy = iota
+	// EvictLFU evicts the least frequently used provider information.
+	EvictLFU
+)
+
+// String returns the name of the eviction policy.
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictLRU:
+		return "LRU"
+	case EvictLFU:
+		return "LFU"
+	}
+	return "Unknown"
+}
+
+// Stats contains cache size and eviction statistics.
+type Stats struct {
+	// Entries is the number of providers with cached information.
+	Entries int
+	// NegativeEntries is the number of negative cache entries.
+	NegativeEntries int
+	// Evictions is the number of providers evicted to stay within the maximum
+	// number of entries.
+	Evictions uint64
+	// NegativeEvictions is the number of negative cache entries evicted to
+	// stay within the maximum number of negative entries.
+	NegativeEvictions uint64
+}
+
+// usage records accesses to cached provider information. It is shared by the
+// writable and read-only cache data so that lookups can record accesses
+// without locking.
+type usage struct {
+	lastAccess atomic.Int64
+	hits       atomic.Uint64
+}
+
+func (u *usage) touch() {
+	u.lastAccess.Store(time.Now().UnixNano())
+	u.hits.Add(1)
+}
+
+// Stats returns the current cache size and eviction statistics.
+func (pc *ProviderCache) Stats() Stats {
+	return Stats{
+		Entries:           int(pc.entries.Load()),
+		NegativeEntries:   int(pc.negEntries.Load()),
+		Evictions:         pc.evictions.Load(),
+		NegativeEvictions: pc.negEvictions.Load(),
+	}
+}
+
+// newUsage returns a new usage record if the cache size is bounded, or nil if
+// it is not, so that unbounded caches do not record accesses.
+func (pc *ProviderCache) newUsage() *usage {
+	if pc.maxEntries == 0 {
+		return nil
+	}
+	u := new(usage)
+	u.touch()
+	return u
+}
+
+// hasRoom returns true if there is room to add another provider to the cache
+// without exceeding the maximum number of entries. Must be called with
+// writeLock held.
+func (pc *ProviderCache) hasRoom() bool {
+	return pc.maxEntries == 0 || len(pc.write)-pc.negCount < pc.maxEntries
+}
+
+// evict removes cache entries, other than the entry for keep, until the
+// numbers of entries and negative entries are within their limits. A nil
+// update is stored for each evicted provider to remove it from the read-only
+// data. Returns the information for the evicted providers. Must be called
+// with writeLock held.
+func (pc *ProviderCache) evict(updates map[peer.ID]*readProviderInfo, keep peer.ID) []*evicted {
+	var victims []*evicted
+
+	if pc.maxNegEntries != 0 && pc.negCount > pc.maxNegEntries {
+		// Evict the oldest negative entries, which expire first.
+		cands := pc.evictCandidates(keep, true)
+		victims = pickVictims(victims, cands, pc.negCount-pc.maxNegEntries, func(c *evicted) int64 {
+			return c.cinfo.expiresAt.UnixNano()
+		})
+	}
+	negVictims := len(victims)
+
+	if n := len(pc.write) - pc.negCount - pc.maxEntries; pc.maxEntries != 0 && n > 0 {
+		cands := pc.evictCandidates(keep, false)
+		if pc.policy == EvictLFU {
+			victims = pickVictims(victims, cands, n, func(c *evicted) int64 {
+				return int64(c.cinfo.use.hits.Load())
+			})
+		} else {
+			victims = pickVictims(victims, cands, n, func(c *evicted) int64 {
+				return c.cinfo.use.lastAccess.Load()
+			})
+		}
+	}
+
+	for i, v := range victims {
+		delete(pc.write, v.pid)
+		updates[v.pid] = nil
+		if i < negVictims {
+			pc.negCount--
+			pc.negEvictions.Add(1)
+		} else {
+			pc.evictions.Add(1)
+		}
+	}
+	if len(victims) != 0 {
+		log.Debugw("Evicted cache entries", "count", len(victims), "negative", negVictims)
+	}
+	return victims
+}
+
+// evicted identifies a cache entry selected for eviction.
+type evicted struct {
+	pid   peer.ID
+	cinfo *cacheInfo
+	rank  int64
+}
+
+// evictCandidates returns the negative or positive cache entries that may be
+// evicted. Must be called with writeLock held.
+func (pc *ProviderCache) evictCandidates(keep peer.ID, negative bool) []*evicted {
+	var cands []*evicted
+	for pid, cinfo := range pc.write {
+		if pid == keep || (cinfo.provider == nil) != negative {
+			continue
+		}
+		if !negative && cinfo.use == nil {
+			cinfo.use = new(usage)
+		}
+		cands = append(cands, &evicted{pid: pid, cinfo: cinfo})
+	}
+	return cands
+}
+
+// pickVictims appends the n candidates with the lowest rank to victims.
+func pickVictims(victims, cands []*evicted, n int, rank func(*evicted) int64) []*evicted {
+	if n >= len(cands) {
+		return append(victims, cands...)
+	}
+	// Rank each candidate once, since usage may change during lookups.
+	for _, c := range cands {
+		c.rank = rank(c)
+	}
+	if n == 1 {
+		// Avoid sorting when making room for a single entry.
+		low := 0
+		for i := 1; i < len(cands); i++ {
+			if cands[i].rank < cands[low].rank {
+				low = i
+			}
+		}
+		return append(victims, cands[low])
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		return cands[i].rank < cands[j].rank
+	})
+	return append(victims, cands[:n]...)
+}
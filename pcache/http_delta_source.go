@@ -0,0 +1,174 @@
+package pcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// defaultFullFetchIn is the default time between full fetches done by a
+	// delta source.
+	defaultFullFetchIn = 30 * time.Minute
+
+	// sinceParam is the query parameter used to request provider information
+	// that changed since a given time.
+	sinceParam = "since"
+)
+
+type httpDeltaSource struct {
+	*httpSource
+	fullFetchIn time.Duration
+
+	lock      sync.Mutex
+	providers map[peer.ID]*model.ProviderInfo
+	list      []*model.ProviderInfo
+	etag      string
+	lastMod   string
+	since     time.Time
+	lastFull  time.Time
+	noSince   bool
+}
+
+// NewHTTPDeltaSource creates a ProviderSource that fetches provider
+// information over HTTP, and only downloads information that changed since the
+// previous fetch when possible.
+//
+// Conditional requests, using ETag and If-Modified-Since, avoid downloading
+// the full provider list if nothing has changed. If the server supports the "since"
+// query parameter, then only information for providers that changed since the
+// previous fetch is downloaded and merged into the previously fetched
+// information. If the server does not support it, then the full provider list
+// is fetched each time.
+//
+// Since an incremental listing does not report providers that are no longer
+// available, the full provider list is fetched at the given interval. If
+// fullFetchIn is 0, then a default of 30 minutes is used.
+func NewHTTPDeltaSource(srcURL string, client *http.Client, fullFetchIn time.Duration) (ProviderSource, error) {
+	src, err := newHTTPSource(srcURL, client)
+	if err != nil {
+		return nil, err
+	}
+	if fullFetchIn == 0 {
+		fullFetchIn = defaultFullFetchIn
+	}
+	return &httpDeltaSource{
+		httpSource:  src,
+		fullFetchIn: fullFetchIn,
+	}, nil
+}
+
+func (s *httpDeltaSource) FetchAll(ctx context.Context) ([]*model.ProviderInfo, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	full := s.providers == nil || s.noSince || time.Since(s.lastFull) >= s.fullFetchIn
+	list, err := s.fetch(ctx, full)
+	if err == nil || full || ctx.Err() != nil {
+		return list, err
+	}
+
+	if apierror.IsCode(err, apierror.CodeBadRequest, apierror.CodeNotImplemented) {
+		// Server does not support incremental listing.
+		s.noSince = true
+	}
+	// Fall back to fetching all provider info.
+	log.Warnw("Cannot fetch changed provider info, fetching all", "err", err, "source", s)
+	return s.fetch(ctx, true)
+}
+
+// fetch gets either all provider information or provider information that
+// changed since the previous fetch. Returns all currently known provider
+// information. Must be called with lock held.
+func (s *httpDeltaSource) fetch(ctx context.Context, full bool) ([]*model.ProviderInfo, error) {
+	u := s.url
+	if !full {
+		q := u.Query()
+		q.Set(sinceParam, s.since.Format(time.RFC3339))
+		u = s.url.JoinPath()
+		u.RawQuery = q.Encode()
+	}
+	req, err := s.newRequest(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	// The validators are from the full provider list, so they are only used
+	// for full fetches.
+	if full && s.providers != nil {
+		if s.etag != "" {
+			req.Header.Set("If-None-Match", s.etag)
+		}
+		if s.lastMod != "" {
+			req.Header.Set("If-Modified-Since", s.lastMod)
+		}
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		// Nothing changed since last fetch.
+		if full {
+			s.lastFull = start
+		}
+		return s.list, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apierror.FromResponse(resp.StatusCode, body)
+	}
+
+	var providers []*model.ProviderInfo
+	if err = json.Unmarshal(body, &providers); err != nil {
+		return nil, fmt.Errorf("cannot decode provider info: %w", err)
+	}
+
+	if full {
+		s.providers = make(map[peer.ID]*model.ProviderInfo, len(providers))
+		s.lastFull = start
+	}
+	for _, pinfo := range providers {
+		if pinfo != nil {
+			s.providers[pinfo.AddrInfo.ID] = pinfo
+		}
+	}
+	if full || len(providers) != 0 {
+		// Generate a new list instead of modifying the list returned by the
+		// previous fetch.
+		list := make([]*model.ProviderInfo, 0, len(s.providers))
+		for _, pinfo := range s.providers {
+			list = append(list, pinfo)
+		}
+		s.list = list
+	}
+
+	if full {
+		s.etag = resp.Header.Get("ETag")
+		s.lastMod = resp.Header.Get("Last-Modified")
+	}
+	// Use the server's time, if available, for the next incremental listing.
+	s.since = start
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		s.since = date
+	}
+	log.Debugw("Fetched provider info", "full", full, "count", len(providers), "source", s)
+
+	return s.list, nil
+}
@@ -22,6 +22,14 @@ type httpSource struct {
 }
 
 func NewHTTPSource(srcURL string, client *http.Client) (ProviderSource, error) {
+	src, err := newHTTPSource(srcURL, client)
+	if err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+func newHTTPSource(srcURL string, client *http.Client) (*httpSource, error) {
 	u, err := url.Parse(srcURL)
 	if err != nil {
 		return nil, err
@@ -50,17 +58,10 @@ func (s *httpSource) AddHeader(key, value string) {
 }
 
 func (s *httpSource) Fetch(ctx context.Context, pid peer.ID) (*model.ProviderInfo, error) {
-	u := s.url.JoinPath(pid.String())
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	req, err := s.newRequest(ctx, s.url.JoinPath(pid.String()))
 	if err != nil {
 		return nil, err
 	}
-	for key, vals := range s.header {
-		for _, val := range vals {
-			req.Header.Add(key, val)
-		}
-	}
-	req.Header.Add("Accept", "application/json")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -90,16 +91,10 @@ func (s *httpSource) Fetch(ctx context.Context, pid peer.ID) (*model.ProviderInf
 }
 
 func (s *httpSource) FetchAll(ctx context.Context) ([]*model.ProviderInfo, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url.String(), nil)
+	req, err := s.newRequest(ctx, s.url)
 	if err != nil {
 		return nil, err
 	}
-	for key, vals := range s.header {
-		for _, val := range vals {
-			req.Header.Add(key, val)
-		}
-	}
-	req.Header.Add("Accept", "application/json")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -126,6 +121,21 @@ func (s *httpSource) FetchAll(ctx context.Context) ([]*model.ProviderInfo, error
 	return providers, nil
 }
 
+// newRequest creates a GET request for the URL with the source's headers.
+func (s *httpSource) newRequest(ctx context.Context, u *url.URL) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, vals := range s.header {
+		for _, val := range vals {
+			req.Header.Add(key, val)
+		}
+	}
+	req.Header.Add("Accept", "application/json")
+	return req, nil
+}
+
 func (s *httpSource) String() string {
 	return s.url.String()
 }
@@ -1,22 +1,29 @@
 package pcache
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 )
 
 const (
-	defaultRefreshIn = 2 * time.Minute
-	defaultTTL       = 10 * time.Minute
+	defaultRefreshIn  = 2 * time.Minute
+	defaultSnapshotIn = 10 * time.Minute
+	defaultTTL        = 10 * time.Minute
 )
 
 type config struct {
-	httpClient *http.Client
-	preload    bool
-	refreshIn  time.Duration
-	sources    []ProviderSource
-	ttl        time.Duration
+	httpClient    *http.Client
+	maxEntries    int
+	maxNegEntries int
+	policy        EvictionPolicy
+	preload       bool
+	refreshIn     time.Duration
+	snapshot      SnapshotStore
+	snapshotIn    time.Duration
+	sources       []ProviderSource
+	ttl           time.Duration
 }
 
 // Option is a function that sets a value in a config.
@@ -28,6 +35,7 @@ func getOpts(opts []Option) (config, error) {
 		httpClient: http.DefaultClient,
 		preload:    true,
 		refreshIn:  defaultRefreshIn,
+		snapshotIn: defaultSnapshotIn,
 		ttl:        defaultTTL,
 	}
 	for i, opt := range opts {
@@ -38,6 +46,56 @@ func getOpts(opts []Option) (config, error) {
 	return cfg, nil
 }
 
+// WithMaxEntries sets the maximum number of providers that the cache holds
+// information for. When the cache is full, a refresh does not add new
+// providers, and fetching information for a provider that is not cached evicts
+// other cached information according to the eviction policy. A value of 0
+// means there is no maximum.
+//
+// Default is 0 (no maximum).
+func WithMaxEntries(n int) Option {
+	return func(cfg *config) error {
+		if n < 0 {
+			return errors.New("max entries cannot be negative")
+		}
+		cfg.maxEntries = n
+		return nil
+	}
+}
+
+// WithMaxNegativeEntries sets the maximum number of negative cache entries.
+// When this is exceeded, the oldest negative entries are evicted. A value of 0
+// means there is no maximum, and negative entries are only removed after the
+// TTL.
+//
+// Default is 0 (no maximum).
+func WithMaxNegativeEntries(n int) Option {
+	return func(cfg *config) error {
+		if n < 0 {
+			return errors.New("max negative entries cannot be negative")
+		}
+		cfg.maxNegEntries = n
+		return nil
+	}
+}
+
+// WithEvictionPolicy sets the policy used to select which provider information
+// to evict when the cache has more than the maximum number of entries. This
+// has no effect unless WithMaxEntries is used.
+//
+// Default is EvictLRU.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(cfg *config) error {
+		switch policy {
+		case EvictLRU, EvictLFU:
+		default:
+			return fmt.Errorf("unknown eviction policy %d", policy)
+		}
+		cfg.policy = policy
+		return nil
+	}
+}
+
 // WithPreload enables or disabled cache preload. Preload performs an initial
 // refresh of the cache to populate it. This results in faster lookup times
 // when information for multiple providers will be needed, even for a few
@@ -75,6 +133,32 @@ func WithRefreshInterval(interval time.Duration) Option {
 	}
 }
 
+// WithSnapshot configures a SnapshotStore that the cache uses to persist its
+// provider information. When the cache is created, it loads any saved snapshot
+// so that cached data is available immediately. If preload is enabled, then
+// the cache is refreshed in the background instead of blocking New. Snapshots
+// are saved after a refresh, if the snapshot interval has elapsed since the
+// last snapshot, and when the cache is closed.
+func WithSnapshot(store SnapshotStore) Option {
+	return func(cfg *config) error {
+		cfg.snapshot = store
+		return nil
+	}
+}
+
+// WithSnapshotInterval sets the minimum time interval between saving
+// snapshots. A snapshot is saved at the next refresh after the interval has
+// elapsed. If set to 0, then a snapshot is saved at every refresh. This has no
+// effect unless WithSnapshot is used.
+//
+// Default is 10 minutes.
+func WithSnapshotInterval(interval time.Duration) Option {
+	return func(cfg *config) error {
+		cfg.snapshotIn = interval
+		return nil
+	}
+}
+
 // WithSource adds one or more new provider information sources for the cache
 // to pull provider information from. If multiple sources provide the
 // information for the same providers, then the provider record with the most
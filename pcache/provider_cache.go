@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -45,6 +45,25 @@ type ProviderCache struct {
 	needsRefresh atomic.Bool
 	refreshIn    time.Duration
 	refreshTimer *time.Timer
+
+	snapshot   SnapshotStore
+	snapshotAt time.Time
+	snapshotIn time.Duration
+
+	maxEntries    int
+	maxNegEntries int
+	policy        EvictionPolicy
+	negCount      int
+
+	entries      atomic.Int64
+	negEntries   atomic.Int64
+	evictions    atomic.Uint64
+	negEvictions atomic.Uint64
+
+	closed atomic.Bool
+
+	subs     []chan<- Event
+	subsLock sync.Mutex
 }
 
 // cacheInfo contains writable cache info.
@@ -54,6 +73,7 @@ type cacheInfo struct {
 	lastUpdate time.Time
 	seq        uint
 	updateSeq  uint
+	use        *usage
 }
 
 // ctxExtendedInfo contains cached read-only contextual extended provider
@@ -68,6 +88,7 @@ type ctxExtendedInfo struct {
 type readProviderInfo struct {
 	provider    *model.ProviderInfo
 	ctxExtended map[string]ctxExtendedInfo
+	use         *usage
 }
 
 // readOnly is an immutable struct stored atomically in the cache read field.
@@ -96,12 +117,29 @@ func New(options ...Option) (*ProviderCache, error) {
 		ttl:       opts.ttl,
 		refreshIn: opts.refreshIn,
 
+		snapshot:   opts.snapshot,
+		snapshotIn: opts.snapshotIn,
+
+		maxEntries:    opts.maxEntries,
+		maxNegEntries: opts.maxNegEntries,
+		policy:        opts.policy,
+
 		write:     make(map[peer.ID]*cacheInfo),
 		writeLock: make(chan struct{}, 1),
 	}
 
+	var warm bool
+	if pc.snapshot != nil {
+		warm = pc.loadSnapshot(context.Background())
+	}
+
 	if opts.preload {
-		_ = pc.Refresh(context.Background())
+		if warm {
+			// Serve snapshot data while reconciling with sources.
+			go pc.Refresh(context.Background())
+		} else {
+			_ = pc.Refresh(context.Background())
+		}
 	}
 
 	if opts.refreshIn != 0 {
@@ -259,13 +297,35 @@ func (pc *ProviderCache) Refresh(ctx context.Context) error {
 		}
 		return ctx.Err()
 	}
+
 	defer func() {
 		<-pc.writeLock
 	}()
 
+	err := pc.refresh(ctx)
+
+	// Save a snapshot if it is time to. This is done while holding writeLock
+	// so that it cannot overwrite the final snapshot saved by Close. A failure
+	// to save the snapshot does not fail the refresh.
+	if err == nil && pc.snapshot != nil && !pc.closed.Load() && time.Since(pc.snapshotAt) >= pc.snapshotIn {
+		pc.snapshotAt = time.Now()
+		if serr := pc.snapshot.Save(ctx, pc.List()); serr != nil {
+			log.Errorw("Cannot save cache snapshot", "err", serr, "snapshot", pc.snapshot)
+		}
+	}
+	return err
+}
+
+// refresh updates provider information from all sources. Must be called with
+// writeLock held.
+func (pc *ProviderCache) refresh(ctx context.Context) error {
 	pc.seq++
 	seq := pc.seq
 
+	// Only compute change events if there is a subscriber to receive them.
+	emit := pc.hasSubscribers()
+	var events []Event
+
 	for _, src := range pc.sources {
 		// Get provider info from each source.
 		fetchedInfos, err := src.FetchAll(ctx)
@@ -282,6 +342,11 @@ func (pc *ProviderCache) Refresh(ctx context.Context) error {
 			pid := fetchedInfo.AddrInfo.ID
 			cinfo, ok := pc.write[pid]
 			if !ok {
+				if !pc.hasRoom() {
+					// Cache is full. Information for this provider is fetched
+					// if the provider is looked up.
+					continue
+				}
 				// Fetched new provider information, add it to cache.
 				lastUpdate, _ := time.Parse(time.RFC3339, fetchedInfo.LastAdvertisementTime)
 				pc.write[pid] = &cacheInfo{
@@ -289,6 +354,10 @@ func (pc *ProviderCache) Refresh(ctx context.Context) error {
 					lastUpdate: lastUpdate,
 					seq:        seq,
 					updateSeq:  seq,
+					use:        pc.newUsage(),
+				}
+				if emit {
+					events = changeEvents(events, pid, nil, fetchedInfo)
 				}
 				continue
 			}
@@ -306,6 +375,14 @@ func (pc *ProviderCache) Refresh(ctx context.Context) error {
 				continue
 			}
 			// Source has later advertisement in chain, so update cache.
+			if emit {
+				events = changeEvents(events, pid, cinfo.provider, fetchedInfo)
+			}
+			if cinfo.provider == nil {
+				// Negative cache entry replaced.
+				pc.negCount--
+				cinfo.use = pc.newUsage()
+			}
 			cinfo.lastUpdate = lastUpdate
 			cinfo.provider = fetchedInfo
 			cinfo.updateSeq = seq // updated provider info
@@ -331,19 +408,34 @@ func (pc *ProviderCache) Refresh(ctx context.Context) error {
 				// Dead provider or negative cache entry expired, remove from
 				// cache.
 				delete(pc.write, pid)
+				if cinfo.provider == nil {
+					pc.negCount--
+				}
 				// Store nil in updates to override anything in main map.
 				updates[pid] = nil
+				if emit {
+					events = changeEvents(events, pid, cinfo.provider, nil)
+				}
 			}
 		} else if cinfo.updateSeq == seq {
 			// Address updated, update read-only data.
-			updates[pid] = apiToCacheInfo(cinfo.provider)
+			updates[pid] = apiToCacheInfo(cinfo.provider, cinfo.use)
 		}
 	}
 
+	// Evict entries if more than the maximum number of entries.
+	for _, v := range pc.evict(updates, "") {
+		if emit {
+			events = appendEvicted(events, v)
+		}
+	}
+	pc.updateCounts()
+
 	// If the update map is small relative to the main map, do not generate a
 	// new main map yet.
 	if !needMerge(len(updates), len(read.m)) {
 		pc.read.Store(&readOnly{m: read.m, u: updates})
+		pc.publish(events)
 		return nil
 	}
 
@@ -359,6 +451,7 @@ func (pc *ProviderCache) Refresh(ctx context.Context) error {
 
 	// Replace old readOnly map with new.
 	pc.read.Store(&readOnly{m: m})
+	pc.publish(events)
 	return nil
 }
 
@@ -368,6 +461,10 @@ func (pc *ProviderCache) Refresh(ctx context.Context) error {
 //
 // Do not modify values in the returned readProviderInfo.
 func (pc *ProviderCache) getReadOnly(ctx context.Context, pid peer.ID) (*readProviderInfo, error) {
+	if pc.closed.Load() {
+		return nil, ErrClosed
+	}
+
 	read := pc.loadReadOnly()
 
 	rpi, ok := read.u[pid]
@@ -382,12 +479,18 @@ func (pc *ProviderCache) getReadOnly(ctx context.Context, pid peer.ID) (*readPro
 			}
 		}
 	}
+	if ok && rpi != nil && rpi.use != nil {
+		// Record access for cache eviction.
+		rpi.use.touch()
+	}
 
 	// If a refresh interval defined, and elapsed, then trigger a refresh.
 	if pc.refreshTimer != nil && pc.needsRefresh.CompareAndSwap(true, false) {
 		go func() {
 			pc.Refresh(context.Background())
-			pc.refreshTimer.Reset(pc.refreshIn)
+			if !pc.closed.Load() {
+				pc.refreshTimer.Reset(pc.refreshIn)
+			}
 		}()
 	}
 
@@ -395,6 +498,68 @@ func (pc *ProviderCache) getReadOnly(ctx context.Context, pid peer.ID) (*readPro
 	return rpi, nil
 }
 
+// Close stops automatic cache refresh and, if the cache is configured with a
+// SnapshotStore, saves a final snapshot. After Close, lookups return
+// ErrClosed.
+func (pc *ProviderCache) Close() error {
+	if !pc.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	if pc.refreshTimer != nil {
+		pc.refreshTimer.Stop()
+	}
+	pc.closeSubscribers()
+	if pc.snapshot == nil {
+		return nil
+	}
+
+	// Wait for any refresh in progress to finish.
+	pc.writeLock <- struct{}{}
+	defer func() {
+		<-pc.writeLock
+	}()
+	return pc.snapshot.Save(context.Background(), pc.List())
+}
+
+// loadSnapshot loads provider information from the snapshot store into the
+// cache. Returns true if any provider information was loaded.
+func (pc *ProviderCache) loadSnapshot(ctx context.Context) bool {
+	pinfos, err := pc.snapshot.Load(ctx)
+	if err != nil {
+		log.Errorw("Cannot load cache snapshot", "err", err, "snapshot", pc.snapshot)
+		return false
+	}
+	if len(pinfos) == 0 {
+		return false
+	}
+
+	m := make(map[peer.ID]*readProviderInfo, len(pinfos))
+	for _, pinfo := range pinfos {
+		if pinfo == nil {
+			continue
+		}
+		if !pc.hasRoom() {
+			break
+		}
+		pid := pinfo.AddrInfo.ID
+		lastUpdate, _ := time.Parse(time.RFC3339, pinfo.LastAdvertisementTime)
+		cinfo := &cacheInfo{
+			provider:   pinfo,
+			lastUpdate: lastUpdate,
+			seq:        pc.seq,
+			updateSeq:  pc.seq,
+			use:        pc.newUsage(),
+		}
+		pc.write[pid] = cinfo
+		m[pid] = apiToCacheInfo(pinfo, cinfo.use)
+	}
+	pc.read.Store(&readOnly{m: m})
+	pc.updateCounts()
+	pc.snapshotAt = time.Now()
+	log.Infow("Loaded cache snapshot", "providers", len(m), "snapshot", pc.snapshot)
+	return true
+}
+
 func (pc *ProviderCache) loadReadOnly() readOnly {
 	if p := pc.read.Load(); p != nil {
 		return *p
@@ -431,6 +596,9 @@ func (pc *ProviderCache) fetchMissing(ctx context.Context, pid peer.ID) (*readPr
 		}
 		// This should never happen. Appropriate to panic here.
 		log.Errorw("Cached data not found in read-only data", "provider", pid)
+		if pc.write[pid].provider == nil {
+			pc.negCount--
+		}
 		delete(pc.write, pid)
 	}
 
@@ -442,8 +610,7 @@ func (pc *ProviderCache) fetchMissing(ctx context.Context, pid peer.ID) (*readPr
 	for _, src := range pc.sources {
 		fetchedInfo, err := src.Fetch(ctx, pid)
 		if err != nil {
-			var apiErr *apierror.Error
-			if errors.As(err, &apiErr) && apiErr.Status() == http.StatusNotFound {
+			if apierror.IsCode(err, apierror.CodeNotFound) {
 				continue
 			}
 			log.Errorw("Cannot fetch provider info", "err", err, "source", src)
@@ -470,7 +637,10 @@ func (pc *ProviderCache) fetchMissing(ctx context.Context, pid peer.ID) (*readPr
 	if cinfo.provider == nil {
 		// No provider info, cache negative entry.
 		cinfo.expiresAt = time.Now().Add(pc.ttl)
+		pc.negCount++
 		log.Infow("Provider info not found at any source", "provider", pid)
+	} else {
+		cinfo.use = pc.newUsage()
 	}
 	pc.write[pid] = cinfo
 
@@ -481,13 +651,18 @@ func (pc *ProviderCache) fetchMissing(ctx context.Context, pid peer.ID) (*readPr
 	for id, rpi := range read.u {
 		updates[id] = rpi
 	}
-	rpinfo := apiToCacheInfo(cinfo.provider)
+	rpinfo := apiToCacheInfo(cinfo.provider, cinfo.use)
 	updates[pid] = rpinfo
 
+	// Evict other entries if more than the maximum number of entries.
+	victims := pc.evict(updates, pid)
+	pc.updateCounts()
+
 	// If the update map is small relative to the main map, do not generate a
 	// new main map yet.
 	if !needMerge(len(updates), len(read.m)) {
 		pc.read.Store(&readOnly{m: read.m, u: updates})
+		pc.publishAdded(pid, cinfo.provider, victims)
 		return rpinfo, nil
 	}
 
@@ -503,11 +678,33 @@ func (pc *ProviderCache) fetchMissing(ctx context.Context, pid peer.ID) (*readPr
 
 	// Replace old readOnly map with new.
 	pc.read.Store(&readOnly{m: m})
+	pc.publishAdded(pid, cinfo.provider, victims)
 
 	return rpinfo, nil
 }
 
-func apiToCacheInfo(provider *model.ProviderInfo) *readProviderInfo {
+// publishAdded publishes a ProviderAdded event if provider information was
+// found for a provider missing from the cache, and a ProviderEvicted event for
+// each provider evicted to make room for it.
+func (pc *ProviderCache) publishAdded(pid peer.ID, pinfo *model.ProviderInfo, victims []*evicted) {
+	if (pinfo == nil && len(victims) == 0) || !pc.hasSubscribers() {
+		return
+	}
+	events := changeEvents(nil, pid, nil, pinfo)
+	for _, v := range victims {
+		events = appendEvicted(events, v)
+	}
+	pc.publish(events)
+}
+
+// updateCounts stores the current number of entries and negative entries for
+// reading without locking. Must be called with writeLock held.
+func (pc *ProviderCache) updateCounts() {
+	pc.entries.Store(int64(len(pc.write) - pc.negCount))
+	pc.negEntries.Store(int64(pc.negCount))
+}
+
+func apiToCacheInfo(provider *model.ProviderInfo, use *usage) *readProviderInfo {
 	// Return nil if this is a negative cache entry.
 	if provider == nil {
 		return nil
@@ -515,6 +712,7 @@ func apiToCacheInfo(provider *model.ProviderInfo) *readProviderInfo {
 
 	rpinfo := &readProviderInfo{
 		provider: provider,
+		use:      use,
 	}
 
 	extProviders := provider.ExtendedProviders
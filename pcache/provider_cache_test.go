@@ -3,16 +3,21 @@ package pcache_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"path"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	"github.com/ipni/go-libipni/find/model"
 	"github.com/ipni/go-libipni/pcache"
+	"github.com/ipni/go-libipni/test"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
@@ -397,3 +402,325 @@ func TestTTL(t *testing.T) {
 	require.Nil(t, pinfo)
 	require.Equal(t, int32(2), src.callFetch.Load())
 }
+
+func TestSnapshot(t *testing.T) {
+	snapFile := filepath.Join(t.TempDir(), "pcache.snapshot")
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	stores := []pcache.SnapshotStore{
+		pcache.NewFileSnapshot(snapFile),
+		pcache.NewDatastoreSnapshot(ds, datastore.NewKey("pcache-snapshot")),
+	}
+
+	for _, store := range stores {
+		src := newMockSource(pid1, pid2)
+		pc, err := pcache.New(pcache.WithSource(src), pcache.WithSnapshot(store),
+			pcache.WithSnapshotInterval(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 2, pc.Len())
+		require.NoError(t, pc.Close())
+
+		_, err = pc.Get(context.Background(), pid1)
+		require.ErrorIs(t, err, pcache.ErrClosed)
+
+		pinfos, err := store.Load(context.Background())
+		require.NoError(t, err)
+		require.Len(t, pinfos, 2)
+
+		// Warm start from snapshot with source that no longer has pid2.
+		src = newMockSource(pid1)
+		pc, err = pcache.New(pcache.WithSource(src), pcache.WithSnapshot(store),
+			pcache.WithPreload(false), pcache.WithTTL(0))
+		require.NoError(t, err)
+		require.Equal(t, 2, pc.Len())
+
+		pinfo, err := pc.Get(context.Background(), pid2)
+		require.NoError(t, err)
+		require.NotNil(t, pinfo)
+		require.Zero(t, src.callFetch.Load())
+		require.Zero(t, src.callFetchAll.Load())
+
+		// Reconcile with source. Provider pid2 expires after TTL.
+		require.NoError(t, pc.Refresh(context.Background()))
+		require.NoError(t, pc.Refresh(context.Background()))
+		require.Equal(t, 1, len(pc.List()))
+		require.NoError(t, pc.Close())
+
+		// Snapshot saved at close.
+		pinfos, err = store.Load(context.Background())
+		require.NoError(t, err)
+		require.Len(t, pinfos, 1)
+	}
+}
+
+type failSnapshot struct {
+	saves atomic.Int32
+}
+
+func (s *failSnapshot) Load(context.Context) ([]*model.ProviderInfo, error) {
+	return nil, nil
+}
+
+func (s *failSnapshot) Save(context.Context, []*model.ProviderInfo) error {
+	s.saves.Add(1)
+	return errors.New("cannot save")
+}
+
+func TestSnapshotSaveFailure(t *testing.T) {
+	store := &failSnapshot{}
+	pc, err := pcache.New(pcache.WithSource(newMockSource(pid1)), pcache.WithSnapshot(store),
+		pcache.WithPreload(false), pcache.WithRefreshInterval(0), pcache.WithSnapshotInterval(0))
+	require.NoError(t, err)
+
+	// Refresh succeeds even though the snapshot cannot be saved.
+	require.NoError(t, pc.Refresh(context.Background()))
+	require.Equal(t, int32(1), store.saves.Load())
+
+	// Close reports the failure to save the final snapshot.
+	require.Error(t, pc.Close())
+	require.Equal(t, int32(2), store.saves.Load())
+}
+
+func TestSubscribe(t *testing.T) {
+	src := newMockSource(pid1)
+	pc, err := pcache.New(pcache.WithSource(src), pcache.WithPreload(false),
+		pcache.WithRefreshInterval(0), pcache.WithTTL(200*time.Millisecond))
+	require.NoError(t, err)
+
+	events, cancel := pc.Subscribe()
+	defer cancel()
+
+	nextEvent := func() pcache.Event {
+		select {
+		case event, open := <-events:
+			require.True(t, open)
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		return pcache.Event{}
+	}
+
+	require.NoError(t, pc.Refresh(context.Background()))
+	event := nextEvent()
+	require.Equal(t, pcache.ProviderAdded, event.Type)
+	require.Equal(t, pid1, event.ProviderID)
+	require.Nil(t, event.Previous)
+
+	// Fetching a missing provider generates an event.
+	src.addInfo(pid2)
+	pinfo, err := pc.Get(context.Background(), pid2)
+	require.NoError(t, err)
+	require.NotNil(t, pinfo)
+	event = nextEvent()
+	require.Equal(t, pcache.ProviderAdded, event.Type)
+	require.Equal(t, pid2, event.ProviderID)
+
+	// Change the addresses of pid1.
+	maddr, err := multiaddr.NewMultiaddr("/ip4/192.168.1.1/tcp/24001")
+	require.NoError(t, err)
+	src.infos[0] = &model.ProviderInfo{
+		AddrInfo: peer.AddrInfo{
+			ID:    pid1,
+			Addrs: []multiaddr.Multiaddr{maddr},
+		},
+		LastAdvertisementTime: time.Now().Add(time.Minute).Format(time.RFC3339),
+	}
+	require.NoError(t, pc.Refresh(context.Background()))
+	event = nextEvent()
+	require.Equal(t, pcache.AddrsChanged, event.Type)
+	require.Equal(t, pid1, event.ProviderID)
+	require.NotNil(t, event.Previous)
+	require.Equal(t, maddr, event.Provider.AddrInfo.Addrs[0])
+
+	// Remove pid2 after TTL.
+	src.infos = src.infos[:1]
+	require.NoError(t, pc.Refresh(context.Background()))
+	time.Sleep(220 * time.Millisecond)
+	require.NoError(t, pc.Refresh(context.Background()))
+	event = nextEvent()
+	require.Equal(t, pcache.ProviderRemoved, event.Type)
+	require.Equal(t, pid2, event.ProviderID)
+	require.Nil(t, event.Provider)
+
+	// Closing the cache closes the subscriber channel.
+	require.NoError(t, pc.Close())
+	select {
+	case _, open := <-events:
+		require.False(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for channel close")
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	pid3, _, _ := test.RandomIdentity()
+	ctx := context.Background()
+
+	for _, policy := range []pcache.EvictionPolicy{pcache.EvictLRU, pcache.EvictLFU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			src := newMockSource(pid1, pid2, pid3)
+			pc, err := pcache.New(pcache.WithSource(src), pcache.WithRefreshInterval(0),
+				pcache.WithMaxEntries(2), pcache.WithMaxNegativeEntries(1),
+				pcache.WithEvictionPolicy(policy))
+			require.NoError(t, err)
+
+			// Preload only fills cache to maximum.
+			stats := pc.Stats()
+			require.Equal(t, 2, stats.Entries)
+			require.Zero(t, stats.Evictions)
+
+			events, cancel := pc.Subscribe()
+			defer cancel()
+
+			// Use pid1 so that pid2 is evicted.
+			for i := 0; i < 3; i++ {
+				_, err = pc.Get(ctx, pid1)
+				require.NoError(t, err)
+			}
+			pinfo, err := pc.Get(ctx, pid3)
+			require.NoError(t, err)
+			require.NotNil(t, pinfo)
+			require.Equal(t, int32(1), src.callFetch.Load())
+
+			stats = pc.Stats()
+			require.Equal(t, 2, stats.Entries)
+			require.Equal(t, uint64(1), stats.Evictions)
+
+			var evicted peer.ID
+			for i := 0; i < 2; i++ {
+				event := <-events
+				if event.Type == pcache.ProviderEvicted {
+					evicted = event.ProviderID
+				}
+			}
+			require.Equal(t, pid2, evicted)
+
+			pids := make([]peer.ID, 0, 2)
+			for _, pinfo := range pc.List() {
+				pids = append(pids, pinfo.AddrInfo.ID)
+			}
+			require.ElementsMatch(t, []peer.ID{pid1, pid3}, pids)
+
+			// Refresh does not add evicted provider back.
+			require.NoError(t, pc.Refresh(ctx))
+			require.Equal(t, 2, len(pc.List()))
+
+			// Negative entries are limited separately.
+			for i := 0; i < 2; i++ {
+				pid, _, _ := test.RandomIdentity()
+				pinfo, err = pc.Get(ctx, pid)
+				require.NoError(t, err)
+				require.Nil(t, pinfo)
+			}
+			stats = pc.Stats()
+			require.Equal(t, 2, stats.Entries)
+			require.Equal(t, 1, stats.NegativeEntries)
+			require.Equal(t, uint64(1), stats.NegativeEvictions)
+			require.NoError(t, pc.Close())
+		})
+	}
+}
+
+func TestHTTPDeltaSource(t *testing.T) {
+	for _, supportSince := range []bool{true, false} {
+		t.Run(fmt.Sprint("since-", supportSince), func(t *testing.T) {
+			testHTTPDeltaSource(t, supportSince)
+		})
+	}
+}
+
+func testHTTPDeltaSource(t *testing.T, supportSince bool) {
+	src := newMockSource(pid1, pid2)
+	modTimes := map[peer.ID]time.Time{
+		pid1: time.Now(),
+		pid2: time.Now(),
+	}
+	var version, fullCount, deltaCount, notModCount int
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		etag := fmt.Sprintf(`"%d"`, version)
+		if req.Header.Get("If-None-Match") == etag {
+			notModCount++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		infos := src.infos
+		if since := req.URL.Query().Get("since"); since != "" {
+			// Validators from the full listing are not sent with an
+			// incremental listing request.
+			require.Empty(t, req.Header.Get("If-None-Match"))
+			require.Empty(t, req.Header.Get("If-Modified-Since"))
+			if !supportSince {
+				http.Error(w, "unknown parameter", http.StatusBadRequest)
+				return
+			}
+			sinceTime, err := time.Parse(time.RFC3339, since)
+			require.NoError(t, err)
+			infos = nil
+			for _, info := range src.infos {
+				if !modTimes[info.AddrInfo.ID].Truncate(time.Second).Before(sinceTime) {
+					infos = append(infos, info)
+				}
+			}
+			deltaCount++
+		} else {
+			fullCount++
+		}
+		data, err := json.Marshal(infos)
+		require.NoError(t, err)
+		w.Header().Set("ETag", etag)
+		w.Write(data)
+	}))
+	defer testServer.Close()
+
+	dsrc, err := pcache.NewHTTPDeltaSource(testServer.URL, nil, time.Hour)
+	require.NoError(t, err)
+	pc, err := pcache.New(pcache.WithSource(dsrc), pcache.WithRefreshInterval(0))
+	require.NoError(t, err)
+	require.Equal(t, 2, pc.Len())
+	require.Equal(t, 1, fullCount)
+
+	require.NoError(t, pc.Refresh(context.Background()))
+	if supportSince {
+		// Nothing changed, so server responds with no providers.
+		require.Equal(t, 1, deltaCount)
+		require.Zero(t, notModCount)
+	} else {
+		// Nothing changed, so server responds with not modified.
+		require.Equal(t, 1, notModCount)
+	}
+	require.Equal(t, 2, len(pc.List()))
+
+	// Update pid1 addresses.
+	maddr, err := multiaddr.NewMultiaddr("/ip4/192.168.1.1/tcp/24001")
+	require.NoError(t, err)
+	src.infos[0] = &model.ProviderInfo{
+		AddrInfo: peer.AddrInfo{
+			ID:    pid1,
+			Addrs: []multiaddr.Multiaddr{maddr},
+		},
+		LastAdvertisementTime: time.Now().Add(time.Minute).Format(time.RFC3339),
+	}
+	modTimes[pid1] = time.Now()
+	version++
+
+	require.NoError(t, pc.Refresh(context.Background()))
+	pinfo, err := pc.Get(context.Background(), pid1)
+	require.NoError(t, err)
+	require.Equal(t, maddr, pinfo.AddrInfo.Addrs[0])
+	require.Equal(t, 2, len(pc.List()))
+
+	if supportSince {
+		require.Equal(t, 1, fullCount)
+		require.Equal(t, 2, deltaCount)
+	} else {
+		// Fell back to full fetch.
+		require.Equal(t, 2, fullCount)
+		require.Zero(t, deltaCount)
+
+		// Does not try incremental listing again.
+		version++
+		require.NoError(t, pc.Refresh(context.Background()))
+		require.Equal(t, 3, fullCount)
+	}
+}
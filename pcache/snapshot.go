@@ -0,0 +1,148 @@
+package pcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipni/go-libipni/find/model"
+)
+
+// snapshotVersion is the version of the snapshot encoding.
+const snapshotVersion = 1
+
+// SnapshotStore saves and loads snapshots of cached provider information. A
+// ProviderCache configured with a SnapshotStore loads a snapshot when it is
+// created, so that it can serve cached data immediately, and saves snapshots
+// periodically and when it is closed.
+type SnapshotStore interface {
+	// Load returns the provider information from the most recently saved
+	// snapshot. Returns no data and no error, (nil, nil), if there is no
+	// saved snapshot.
+	Load(context.Context) ([]*model.ProviderInfo, error)
+	// Save saves a snapshot of provider information, replacing any previous
+	// snapshot.
+	Save(context.Context, []*model.ProviderInfo) error
+}
+
+// snapshot is the serialized form of a cache snapshot.
+type snapshot struct {
+	Version   int
+	Time      time.Time
+	Providers []*model.ProviderInfo
+}
+
+func encodeSnapshot(pinfos []*model.ProviderInfo) ([]byte, error) {
+	return json.Marshal(&snapshot{
+		Version:   snapshotVersion,
+		Time:      time.Now(),
+		Providers: pinfos,
+	})
+}
+
+func decodeSnapshot(data []byte) ([]*model.ProviderInfo, error) {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("cannot decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	return snap.Providers, nil
+}
+
+type fileSnapshot struct {
+	path string
+}
+
+// NewFileSnapshot creates a SnapshotStore that saves snapshots to the file at
+// the given path. The file is replaced atomically on each save.
+func NewFileSnapshot(path string) SnapshotStore {
+	return &fileSnapshot{
+		path: path,
+	}
+}
+
+func (s *fileSnapshot) Load(_ context.Context) ([]*model.ProviderInfo, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeSnapshot(data)
+}
+
+func (s *fileSnapshot) Save(_ context.Context, pinfos []*model.ProviderInfo) error {
+	data, err := encodeSnapshot(pinfos)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *fileSnapshot) String() string {
+	return s.path
+}
+
+type dsSnapshot struct {
+	ds  datastore.Datastore
+	key datastore.Key
+}
+
+// NewDatastoreSnapshot creates a SnapshotStore that saves snapshots in the
+// datastore under the given key.
+func NewDatastoreSnapshot(ds datastore.Datastore, key datastore.Key) SnapshotStore {
+	return &dsSnapshot{
+		ds:  ds,
+		key: key,
+	}
+}
+
+func (s *dsSnapshot) Load(ctx context.Context) ([]*model.ProviderInfo, error) {
+	data, err := s.ds.Get(ctx, s.key)
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeSnapshot(data)
+}
+
+func (s *dsSnapshot) Save(ctx context.Context, pinfos []*model.ProviderInfo) error {
+	data, err := encodeSnapshot(pinfos)
+	if err != nil {
+		return err
+	}
+	if err = s.ds.Put(ctx, s.key, data); err != nil {
+		return err
+	}
+	return s.ds.Sync(ctx, s.key)
+}
+
+func (s *dsSnapshot) String() string {
+	return s.key.String()
+}
@@ -0,0 +1,184 @@
+package rwriter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/multiformats/go-multihash"
+)
+
+// BatchResponseWriter writes the response to a batch find request, which
+// is a POST request with a FindRequest body. When the response is streamed,
+// each result is written as a model.StreamResult tagged with its multihash.
+// Otherwise, results are assembled into a single FindResponse that is written
+// by Close.
+type BatchResponseWriter struct {
+	ResponseWriter
+	count     int
+	mhs       []multihash.Multihash
+	requested map[string]struct{}
+
+	// Assembled results for non-streaming responses.
+	results    []model.MultihashResult
+	resultIdx  map[string]int
+	encResults []model.EncryptedMultihashResult
+	encIdx     map[string]int
+}
+
+// NewBatch reads the FindRequest from the request body, validates each of the
+// requested multihashes, and creates a BatchResponseWriter that writes the
+// response using the media type negotiated from the request's Accept headers.
+func NewBatch(w http.ResponseWriter, r *http.Request, options ...Option) (*BatchResponseWriter, error) {
+	opts, err := getOpts(options)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, err := negotiate(r, opts.preferJson)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apierror.New(fmt.Errorf("cannot read request body: %w", err), http.StatusBadRequest)
+	}
+	req, err := model.UnmarshalFindRequest(body)
+	if err != nil {
+		return nil, apierror.New(fmt.Errorf("cannot decode find request: %w", err), http.StatusBadRequest)
+	}
+	if len(req.Multihashes) == 0 {
+		return nil, apierror.New(errors.New("no multihashes in request"), http.StatusBadRequest)
+	}
+
+	requested := make(map[string]struct{}, len(req.Multihashes))
+	for i, mh := range req.Multihashes {
+		if _, err = multihash.Decode(mh); err != nil {
+			return nil, apierror.New(fmt.Errorf("%w at index %d", multihash.ErrInvalidMultihash, i), http.StatusBadRequest).
+				WithCode(apierror.CodeInvalidMultihash)
+		}
+		requested[string(mh)] = struct{}{}
+	}
+
+	bw := &BatchResponseWriter{
+		ResponseWriter: *newResponseWriter(w, mediaType),
+		mhs:            req.Multihashes,
+		requested:      requested,
+	}
+	if !bw.IsStream() {
+		bw.resultIdx = make(map[string]int)
+		bw.encIdx = make(map[string]int)
+	}
+	return bw, nil
+}
+
+// Multihashes returns the multihashes from the batch find request, in the
+// order they were requested.
+func (bw *BatchResponseWriter) Multihashes() []multihash.Multihash {
+	return bw.mhs
+}
+
+// WriteProviderResult writes a provider result for one of the requested
+// multihashes.
+func (bw *BatchResponseWriter) WriteProviderResult(mh multihash.Multihash, pr model.ProviderResult) error {
+	if err := bw.checkRequested(mh); err != nil {
+		return err
+	}
+	if bw.IsStream() {
+		return bw.writeStreamResult(model.StreamResult{
+			Multihash:      mh,
+			ProviderResult: pr,
+		})
+	}
+	i, ok := bw.resultIdx[string(mh)]
+	if !ok {
+		i = len(bw.results)
+		bw.resultIdx[string(mh)] = i
+		bw.results = append(bw.results, model.MultihashResult{Multihash: mh})
+	}
+	bw.results[i].ProviderResults = append(bw.results[i].ProviderResults, pr)
+	bw.count++
+	return nil
+}
+
+// WriteEncryptedValueKeys writes encrypted value keys for one of the
+// requested multihashes. This is used when responding to double-hashed
+// lookups, where each requested multihash is a second hash of the original
+// multihash.
+func (bw *BatchResponseWriter) WriteEncryptedValueKeys(mh multihash.Multihash, evks [][]byte) error {
+	if len(evks) == 0 {
+		return nil
+	}
+	if err := bw.checkRequested(mh); err != nil {
+		return err
+	}
+	if bw.IsStream() {
+		return bw.writeStreamResult(model.StreamResult{
+			Multihash:          mh,
+			EncryptedValueKeys: evks,
+		})
+	}
+	i, ok := bw.encIdx[string(mh)]
+	if !ok {
+		i = len(bw.encResults)
+		bw.encIdx[string(mh)] = i
+		bw.encResults = append(bw.encResults, model.EncryptedMultihashResult{Multihash: mh})
+	}
+	bw.encResults[i].EncryptedValueKeys = append(bw.encResults[i].EncryptedValueKeys, evks...)
+	bw.count++
+	return nil
+}
+
+// Close completes the response. If no results were written, then an
+// apierror with http.StatusNotFound is returned and nothing is written.
+func (bw *BatchResponseWriter) Close() error {
+	if bw.count == 0 {
+		return apierror.New(nil, http.StatusNotFound)
+	}
+	if bw.IsStream() {
+		return nil
+	}
+	resp := &model.FindResponse{
+		MultihashResults:          bw.results,
+		EncryptedMultihashResults: bw.encResults,
+	}
+	if bw.mediaType == mediaTypeCbor {
+		data, err := model.MarshalFindResponseCBOR(resp)
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(data)
+		return err
+	}
+	return bw.encoder.Encode(resp)
+}
+
+func (bw *BatchResponseWriter) checkRequested(mh multihash.Multihash) error {
+	if _, ok := bw.requested[string(mh)]; !ok {
+		return fmt.Errorf("multihash %s not in request", mh.B58String())
+	}
+	return nil
+}
+
+func (bw *BatchResponseWriter) writeStreamResult(sr model.StreamResult) error {
+	if bw.mediaType == mediaTypeCborStream {
+		data, err := model.MarshalStreamResultCBOR(&sr)
+		if err != nil {
+			return err
+		}
+		if err = bw.writeDelimited(data); err != nil {
+			return err
+		}
+	} else {
+		if err := bw.encoder.Encode(sr); err != nil {
+			return err
+		}
+	}
+	bw.Flush()
+	bw.count++
+	return nil
+}
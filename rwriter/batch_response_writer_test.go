@@ -0,0 +1,123 @@
+package rwriter_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/ipni/go-libipni/rwriter"
+	"github.com/ipni/go-libipni/test"
+	"github.com/libp2p/go-msgio"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchResponseWriter(t *testing.T) {
+	mhs := test.RandomMultihashes(3)
+	pr := model.ProviderResult{ContextID: []byte("ctx1"), Metadata: []byte("md1")}
+	evks := [][]byte{[]byte("evk1"), []byte("evk2")}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bw, err := rwriter.NewBatch(w, r)
+		if err != nil {
+			var apiErr *apierror.Error
+			require.True(t, errors.As(err, &apiErr))
+			http.Error(w, err.Error(), apiErr.Status())
+			return
+		}
+		require.Error(t, bw.WriteProviderResult(test.RandomMultihashes(1)[0], pr))
+		for _, mh := range bw.Multihashes() {
+			// The last multihash has no results.
+			switch {
+			case bytes.Equal(mh, mhs[0]):
+				require.NoError(t, bw.WriteProviderResult(mh, pr))
+				require.NoError(t, bw.WriteProviderResult(mh, pr))
+			case bytes.Equal(mh, mhs[1]):
+				require.NoError(t, bw.WriteEncryptedValueKeys(mh, evks))
+			}
+		}
+		if err = bw.Close(); err != nil {
+			http.Error(w, "", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	post := func(accept string, mhs ...multihash.Multihash) (*http.Response, []byte) {
+		data, err := model.MarshalFindRequest(&model.FindRequest{Multihashes: mhs})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/multihash", bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		require.NoError(t, err)
+		return res, body
+	}
+
+	// JSON assembles a single FindResponse.
+	res, body := post("application/json", mhs...)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	findResp, err := model.UnmarshalFindResponse(body)
+	require.NoError(t, err)
+	require.Len(t, findResp.MultihashResults, 1)
+	require.Equal(t, mhs[0], findResp.MultihashResults[0].Multihash)
+	require.Equal(t, []model.ProviderResult{pr, pr}, findResp.MultihashResults[0].ProviderResults)
+	require.Len(t, findResp.EncryptedMultihashResults, 1)
+	require.Equal(t, mhs[1], findResp.EncryptedMultihashResults[0].Multihash)
+	require.Equal(t, evks, findResp.EncryptedMultihashResults[0].EncryptedValueKeys)
+
+	// NDJSON streams results tagged with their multihash.
+	res, body = post("application/x-ndjson", mhs...)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var srs []model.StreamResult
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var sr model.StreamResult
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &sr))
+		srs = append(srs, sr)
+	}
+	require.Len(t, srs, 3)
+	require.Equal(t, mhs[0], srs[0].Multihash)
+	require.Equal(t, pr, srs[0].ProviderResult)
+	require.Equal(t, mhs[1], srs[2].Multihash)
+	require.Equal(t, evks, srs[2].EncryptedValueKeys)
+
+	// Length-prefixed dag-cbor stream.
+	res, body = post("application/vnd.ipni.cbor-stream", mhs...)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	mr := msgio.NewVarintReader(bytes.NewReader(body))
+	srs = srs[:0]
+	for {
+		msg, err := mr.ReadMsg()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		sr, err := model.UnmarshalStreamResultCBOR(msg)
+		require.NoError(t, err)
+		srs = append(srs, sr)
+	}
+	require.Len(t, srs, 3)
+	require.Equal(t, pr, srs[1].ProviderResult)
+	require.Equal(t, evks, srs[2].EncryptedValueKeys)
+
+	// No results.
+	res, _ = post("application/json", mhs[2])
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// Invalid requests.
+	res, _ = post("application/json")
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res, body = post("application/json", mhs[0], multihash.Multihash("bad"))
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	require.Contains(t, string(body), "index 1")
+}
@@ -23,13 +23,23 @@ func NewProviderResponseWriter(w *ResponseWriter) *ProviderResponseWriter {
 }
 
 func (pw *ProviderResponseWriter) WriteProviderResult(pr model.ProviderResult) error {
-	if pw.nd {
+	switch pw.mediaType {
+	case mediaTypeNDJson:
 		err := pw.encoder.Encode(pr)
 		if err != nil {
 			return err
 		}
 		pw.Flush()
-	} else {
+	case mediaTypeCborStream:
+		data, err := model.MarshalProviderResultCBOR(&pr)
+		if err != nil {
+			return err
+		}
+		if err = pw.writeDelimited(data); err != nil {
+			return err
+		}
+		pw.Flush()
+	default:
 		pw.result.ProviderResults = append(pw.result.ProviderResults, pr)
 	}
 	pw.count++
@@ -40,10 +50,19 @@ func (pw *ProviderResponseWriter) Close() error {
 	if pw.count == 0 {
 		return apierror.New(nil, http.StatusNotFound)
 	}
-	if pw.nd {
+	if pw.IsStream() {
 		return nil
 	}
-	return pw.encoder.Encode(model.FindResponse{
+	resp := &model.FindResponse{
 		MultihashResults: []model.MultihashResult{pw.result},
-	})
+	}
+	if pw.mediaType == mediaTypeCbor {
+		data, err := model.MarshalFindResponseCBOR(resp)
+		if err != nil {
+			return err
+		}
+		_, err = pw.Write(data)
+		return err
+	}
+	return pw.encoder.Encode(resp)
 }
@@ -4,13 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"mime"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipni/go-libipni/apierror"
+	"github.com/libp2p/go-msgio"
 	"github.com/mr-tron/base58"
 	"github.com/multiformats/go-multihash"
 )
@@ -19,18 +22,26 @@ const (
 	mediaTypeNDJson = "application/x-ndjson"
 	mediaTypeJson   = "application/json"
 	mediaTypeAny    = "*/*"
+	// mediaTypeCbor is a complete response encoded as dag-cbor.
+	mediaTypeCbor = "application/cbor"
+	// mediaTypeDagCbor is accepted as an alias for mediaTypeCbor.
+	mediaTypeDagCbor = "application/vnd.ipld.dag-cbor"
+	// mediaTypeCborStream is a stream of dag-cbor encoded results, each
+	// prefixed by its length as an unsigned varint.
+	mediaTypeCborStream = "application/vnd.ipni.cbor-stream"
 )
 
 type ResponseWriter struct {
-	w        http.ResponseWriter
-	f        http.Flusher
-	cid      cid.Cid
-	encoder  *json.Encoder
-	mh       multihash.Multihash
-	mhCode   uint64
-	nd       bool
-	pathType string
-	status   int
+	w         http.ResponseWriter
+	f         http.Flusher
+	cid       cid.Cid
+	encoder   *json.Encoder
+	mediaType string
+	mh        multihash.Multihash
+	mhCode    uint64
+	pathType  string
+	status    int
+	varintW   msgio.WriteCloser
 }
 
 func New(w http.ResponseWriter, r *http.Request, options ...Option) (*ResponseWriter, error) {
@@ -39,39 +50,9 @@ func New(w http.ResponseWriter, r *http.Request, options ...Option) (*ResponseWr
 		return nil, err
 	}
 
-	accepts := r.Header.Values("Accept")
-	var nd, okJson bool
-	for _, accept := range accepts {
-		amts := strings.Split(accept, ",")
-		for _, amt := range amts {
-			mt, _, err := mime.ParseMediaType(amt)
-			if err != nil {
-				return nil, apierror.New(errors.New("invalid Accept header"), http.StatusBadRequest)
-			}
-			switch mt {
-			case mediaTypeNDJson:
-				nd = true
-			case mediaTypeJson:
-				okJson = true
-			case mediaTypeAny:
-				nd = !opts.preferJson
-				okJson = true
-			}
-			if nd && okJson {
-				break
-			}
-		}
-	}
-
-	if len(accepts) == 0 {
-		if !opts.preferJson {
-			// If there is no `Accept` header and JSON is preferred then be
-			// forgiving and fall back onto JSON media type. Otherwise,
-			// strictly require `Accept` header.
-			return nil, apierror.New(errors.New("accept header must be specified"), http.StatusBadRequest)
-		}
-	} else if !okJson && !nd {
-		return nil, apierror.New(fmt.Errorf("media type not supported: %s", accepts), http.StatusBadRequest)
+	mediaType, err := negotiate(r, opts.preferJson)
+	if err != nil {
+		return nil, err
 	}
 
 	var b []byte
@@ -86,7 +67,7 @@ func New(w http.ResponseWriter, r *http.Request, options ...Option) (*ResponseWr
 	case opts.mhPathType:
 		b, err = base58.Decode(strings.TrimSpace(path.Base(r.URL.Path)))
 		if err != nil {
-			return nil, apierror.New(multihash.ErrInvalidMultihash, http.StatusBadRequest)
+			return nil, apierror.New(multihash.ErrInvalidMultihash, http.StatusBadRequest).WithCode(apierror.CodeInvalidMultihash)
 		}
 		mh = multihash.Multihash(b)
 		cidKey = cid.NewCidV1(cid.Raw, mh)
@@ -103,29 +84,111 @@ func New(w http.ResponseWriter, r *http.Request, options ...Option) (*ResponseWr
 
 	dm, err := multihash.Decode(b)
 	if err != nil {
-		return nil, apierror.New(err, http.StatusBadRequest)
+		return nil, apierror.New(err, http.StatusBadRequest).WithCode(apierror.CodeInvalidMultihash)
 	}
 
+	rw := newResponseWriter(w, mediaType)
+	rw.cid = cidKey
+	rw.mh = mh
+	rw.mhCode = dm.Code
+	rw.pathType = pathType
+	return rw, nil
+}
+
+// newResponseWriter creates a ResponseWriter that writes responses using the
+// negotiated media type.
+func newResponseWriter(w http.ResponseWriter, mediaType string) *ResponseWriter {
 	flusher, _ := w.(http.Flusher)
-	if nd {
-		w.Header().Set("Content-Type", mediaTypeNDJson)
+	w.Header().Set("Content-Type", mediaType)
+	if isStream(mediaType) {
 		w.Header().Set("Connection", "Keep-Alive")
 		w.Header().Set("X-Content-Type-Options", "nosniff")
-	} else {
-		w.Header().Set("Content-Type", mediaTypeJson)
 	}
 
 	return &ResponseWriter{
-		w:        w,
-		f:        flusher,
-		cid:      cidKey,
-		encoder:  json.NewEncoder(w),
-		mh:       mh,
-		mhCode:   dm.Code,
-		nd:       nd,
-		pathType: pathType,
-		status:   http.StatusOK,
-	}, nil
+		w:         w,
+		f:         flusher,
+		encoder:   json.NewEncoder(w),
+		mediaType: mediaType,
+		status:    http.StatusOK,
+	}
+}
+
+// negotiate selects the response media type from the request's Accept
+// headers. The media type with the highest quality value (q) is selected, and
+// a media type with q=0 is not acceptable. A media type that is named takes
+// its quality from that entry rather than from */*. If multiple supported
+// media types have the same quality, then streaming media types are selected
+// over non-streaming, and binary over JSON.
+func negotiate(r *http.Request, preferJson bool) (string, error) {
+	accepts := r.Header.Values("Accept")
+	if len(accepts) == 0 {
+		// If there is no `Accept` header and JSON is preferred then be
+		// forgiving and fall back onto JSON media type. Otherwise,
+		// strictly require `Accept` header.
+		if preferJson {
+			return mediaTypeJson, nil
+		}
+		return "", apierror.New(errors.New("accept header must be specified"), http.StatusBadRequest)
+	}
+
+	// Supported media types in order of preference when qualities are equal.
+	candidates := []string{mediaTypeCborStream, mediaTypeNDJson, mediaTypeCbor, mediaTypeJson}
+	// Quality of each candidate given by name, and by */*. A negative quality
+	// means not given.
+	named := map[string]float64{}
+	anyQ := -1.0
+	for _, accept := range accepts {
+		amts := strings.Split(accept, ",")
+		for _, amt := range amts {
+			mt, params, err := mime.ParseMediaType(amt)
+			if err != nil {
+				return "", apierror.New(errors.New("invalid Accept header"), http.StatusBadRequest)
+			}
+			q := 1.0
+			if qs, ok := params["q"]; ok {
+				q, err = strconv.ParseFloat(qs, 64)
+				if err != nil || q < 0 || q > 1 {
+					return "", apierror.New(errors.New("invalid Accept header quality value"), http.StatusBadRequest)
+				}
+			}
+			switch mt {
+			case mediaTypeDagCbor:
+				mt = mediaTypeCbor
+			case mediaTypeAny:
+				anyQ = math.Max(anyQ, q)
+				continue
+			}
+			if prev, ok := named[mt]; !ok || q > prev {
+				named[mt] = q
+			}
+		}
+	}
+
+	var best string
+	var bestQ float64
+	for _, mt := range candidates {
+		q, ok := named[mt]
+		if !ok {
+			// */* selects JSON, or NDJSON if JSON is not preferred. Binary
+			// media types must be asked for by name.
+			if mt != mediaTypeJson && (mt != mediaTypeNDJson || preferJson) {
+				continue
+			}
+			q = anyQ
+		}
+		if q > bestQ {
+			best, bestQ = mt, q
+		}
+	}
+	if best == "" {
+		return "", apierror.New(fmt.Errorf("media type not supported: %s", accepts), http.StatusBadRequest)
+	}
+	return best, nil
+}
+
+func isStream(mediaType string) bool {
+	return mediaType == mediaTypeNDJson || mediaType == mediaTypeCborStream
 }
 
 func (w *ResponseWriter) Multihash() multihash.Multihash {
@@ -137,7 +200,18 @@ func (w *ResponseWriter) MultihashCode() uint64 {
 }
 
 func (w *ResponseWriter) IsND() bool {
-	return w.nd
+	return w.mediaType == mediaTypeNDJson
+}
+
+// IsStream returns true if the response is written as a stream of individual
+// results, either as NDJSON or as length-prefixed dag-cbor.
+func (w *ResponseWriter) IsStream() bool {
+	return isStream(w.mediaType)
+}
+
+// MediaType returns the negotiated media type of the response.
+func (w *ResponseWriter) MediaType() string {
+	return w.mediaType
 }
 
 func (w *ResponseWriter) PathType() string {
@@ -158,6 +232,15 @@ func (w *ResponseWriter) Encoder() *json.Encoder {
 	return w.encoder
 }
 
+// writeDelimited writes a message prefixed by its length as an unsigned
+// varint.
+func (w *ResponseWriter) writeDelimited(msg []byte) error {
+	if w.varintW == nil {
+		w.varintW = msgio.NewVarintWriter(w.w)
+	}
+	return w.varintW.WriteMsg(msg)
+}
+
 func (w *ResponseWriter) Header() http.Header {
 	return w.w.Header()
 }
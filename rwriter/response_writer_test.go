@@ -8,7 +8,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ipni/go-libipni/find/model"
 	"github.com/ipni/go-libipni/rwriter"
+	"github.com/libp2p/go-msgio"
 	"github.com/stretchr/testify/require"
 )
 
@@ -88,3 +90,115 @@ func TestResponseWriter(t *testing.T) {
 	require.Equal(t, http.StatusBadRequest, res.StatusCode)
 	require.Equal(t, "unsupported resource type", strings.TrimSpace(string(body)))
 }
+
+func TestResponseWriterMediaTypes(t *testing.T) {
+	prs := []model.ProviderResult{
+		{ContextID: []byte("ctx1"), Metadata: []byte("md1")},
+		{ContextID: []byte("ctx2"), Metadata: []byte("md2")},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respW, err := rwriter.New(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pw := rwriter.NewProviderResponseWriter(respW)
+		for _, pr := range prs {
+			require.NoError(t, pw.WriteProviderResult(pr))
+		}
+		require.NoError(t, pw.Close())
+	}))
+	defer ts.Close()
+
+	const mhPath = "/multihash/2DrjgbM2tfcpUE5imXMv3HnzryEaxd1FKh8DWMDEgtFkL7MDvT"
+	get := func(accept ...string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+mhPath, nil)
+		require.NoError(t, err)
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		return res, body
+	}
+
+	// Complete dag-cbor response.
+	for _, accept := range []string{"application/cbor", "application/vnd.ipld.dag-cbor"} {
+		res, body := get(accept, "application/json")
+		require.Equal(t, "application/cbor", res.Header.Get("Content-Type"))
+		findResp, err := model.UnmarshalFindResponseCBOR(body)
+		require.NoError(t, err)
+		require.Len(t, findResp.MultihashResults, 1)
+		require.Equal(t, prs, findResp.MultihashResults[0].ProviderResults)
+	}
+
+	// Streaming is selected over a complete response.
+	res, body := get("application/json", "application/cbor", "application/x-ndjson")
+	require.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+	require.Equal(t, len(prs), bytes.Count(body, []byte("\n")))
+
+	// Length-prefixed dag-cbor stream.
+	res, body = get("application/x-ndjson, application/vnd.ipni.cbor-stream")
+	require.Equal(t, "application/vnd.ipni.cbor-stream", res.Header.Get("Content-Type"))
+	r := msgio.NewVarintReader(bytes.NewReader(body))
+	for _, pr := range prs {
+		msg, err := r.ReadMsg()
+		require.NoError(t, err)
+		gotPR, err := model.UnmarshalProviderResultCBOR(msg)
+		require.NoError(t, err)
+		require.Equal(t, pr, gotPR)
+	}
+	_, err := r.ReadMsg()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestResponseWriterQualityValues(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respW, err := rwriter.New(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pw := rwriter.NewProviderResponseWriter(respW)
+		require.NoError(t, pw.WriteProviderResult(model.ProviderResult{ContextID: []byte("ctx1"), Metadata: []byte("md1")}))
+		require.NoError(t, pw.Close())
+	}))
+	defer ts.Close()
+
+	const mhPath = "/multihash/2DrjgbM2tfcpUE5imXMv3HnzryEaxd1FKh8DWMDEgtFkL7MDvT"
+	get := func(accept string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+mhPath, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	for accept, expect := range map[string]string{
+		"application/json, application/cbor;q=0.1":                    "application/json",
+		"application/cbor;q=0.5, application/x-ndjson;q=0.4":          "application/cbor",
+		"application/x-ndjson;q=0, application/json":                  "application/json",
+		"application/json;q=0.5, application/vnd.ipld.dag-cbor;q=0.5": "application/cbor",
+		"*/*;q=0.1, application/cbor;q=0.2":                           "application/cbor",
+		"*/*, application/x-ndjson;q=0":                               "application/json",
+	} {
+		res := get(accept)
+		require.Equal(t, http.StatusOK, res.StatusCode, accept)
+		require.Equal(t, expect, res.Header.Get("Content-Type"), accept)
+	}
+
+	for _, accept := range []string{
+		"application/json;q=0",
+		"application/json;q=2",
+		"application/json;q=fish",
+	} {
+		res := get(accept)
+		require.Equal(t, http.StatusBadRequest, res.StatusCode, accept)
+	}
+}
@@ -0,0 +1,12 @@
+// Package fakeindexer provides an in-memory indexer for testing.
+//
+// The Server implements the find (/multihash, /cid, /providers, /stats),
+// dhstore (encrypted /multihash, /metadata), and ingest (/announce,
+// /ingest/announce, /register, /ingest/content) HTTP endpoints, backed by
+// in-memory data. This allows code that uses the find and ingest clients to be
+// tested end-to-end without a running indexer or dhstore.
+//
+// Content that is indexed, either by calling Server.Put or by an ingest
+// request, is available from both the regular find API and the reader-privacy
+// (double-hashed) find API.
+package fakeindexer
@@ -0,0 +1,534 @@
+package fakeindexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipni/go-libipni/announce/message"
+	"github.com/ipni/go-libipni/apierror"
+	"github.com/ipni/go-libipni/dhash"
+	"github.com/ipni/go-libipni/find/model"
+	ingestmodel "github.com/ipni/go-libipni/ingest/model"
+	"github.com/ipni/go-libipni/rwriter"
+	"github.com/libp2p/go-libp2p/core/peer"
+	b58 "github.com/mr-tron/base58/base58"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multihash"
+)
+
+// Server is an in-memory indexer and dhstore served over HTTP.
+type Server struct {
+	// URL is the base URL of the server, of the form http://ipaddr:port with
+	// no trailing slash.
+	URL string
+
+	ts *httptest.Server
+
+	lock sync.RWMutex
+	// providers maps provider ID to provider information.
+	providers map[peer.ID]*model.ProviderInfo
+	// index maps multihash to value keys.
+	index map[string][]string
+	// metadata maps value key to metadata.
+	metadata map[string][]byte
+	// encIndex maps second multihash to encrypted value keys.
+	encIndex map[string][][]byte
+	// encMetadata maps value key hash to encrypted metadata.
+	encMetadata map[string][]byte
+	announces   []message.Message
+	stats       model.Stats
+}
+
+// New creates and starts a new Server. The caller should call Close when
+// finished.
+func New() *Server {
+	s := &Server{
+		providers:   make(map[peer.ID]*model.ProviderInfo),
+		index:       make(map[string][]string),
+		metadata:    make(map[string][]byte),
+		encIndex:    make(map[string][][]byte),
+		encMetadata: make(map[string][]byte),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/multihash", s.handleFindBatch)
+	mux.HandleFunc("/multihash/", s.handleFind)
+	mux.HandleFunc("/cid/", s.handleFind)
+	mux.HandleFunc("/metadata/", s.handleMetadata)
+	mux.HandleFunc("/providers", s.handleListProviders)
+	mux.HandleFunc("/providers/", s.handleGetProvider)
+	mux.HandleFunc("/stats", s.handleStats)
+	mux.HandleFunc("/announce", s.handleAnnounce)
+	mux.HandleFunc("/ingest/announce", s.handleAnnounce)
+	mux.HandleFunc("/register", s.handleRegister)
+	mux.HandleFunc("/ingest/content", s.handleIndexContent)
+
+	s.ts = httptest.NewServer(mux)
+	s.URL = s.ts.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.ts.Close()
+}
+
+// PutProvider adds or replaces the information for a provider.
+func (s *Server) PutProvider(info *model.ProviderInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.providers[info.AddrInfo.ID] = info
+}
+
+// Put indexes the multihashes as being provided by the provider, with the
+// given context ID and metadata. The data is available from both the regular
+// and the double-hashed find APIs. If the provider is not already known, then
+// it is added with no addresses.
+func (s *Server) Put(providerID peer.ID, contextID, metadata []byte, mhs ...multihash.Multihash) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.put(providerID, contextID, metadata, mhs)
+}
+
+func (s *Server) put(providerID peer.ID, contextID, metadata []byte, mhs []multihash.Multihash) error {
+	vk := dhash.CreateValueKey(providerID, contextID)
+
+	encMetadata, err := dhash.EncryptMetadata(metadata, vk)
+	if err != nil {
+		return err
+	}
+	s.metadata[string(vk)] = metadata
+	s.encMetadata[string(dhash.SHA256(vk, nil))] = encMetadata
+
+	for _, mh := range mhs {
+		if containsString(s.index[string(mh)], string(vk)) {
+			continue
+		}
+		s.index[string(mh)] = append(s.index[string(mh)], string(vk))
+
+		dhmh, err := dhash.SecondMultihash(mh)
+		if err != nil {
+			return err
+		}
+		evk, err := dhash.EncryptValueKey(vk, mh)
+		if err != nil {
+			return err
+		}
+		s.encIndex[string(dhmh)] = append(s.encIndex[string(dhmh)], evk)
+	}
+
+	if _, ok := s.providers[providerID]; !ok {
+		s.providers[providerID] = &model.ProviderInfo{
+			AddrInfo: peer.AddrInfo{ID: providerID},
+		}
+	}
+	return nil
+}
+
+// RemoveContext removes the metadata for the provider and context ID, so that
+// multihashes indexed with that context ID are no longer found.
+func (s *Server) RemoveContext(providerID peer.ID, contextID []byte) {
+	vk := dhash.CreateValueKey(providerID, contextID)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.metadata, string(vk))
+	delete(s.encMetadata, string(dhash.SHA256(vk, nil)))
+}
+
+// SetStats sets the stats returned by the /stats endpoint.
+func (s *Server) SetStats(stats model.Stats) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stats = stats
+}
+
+// Announces returns all the announce messages received by the server.
+func (s *Server) Announces() []message.Message {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	msgs := make([]message.Message, len(s.announces))
+	copy(msgs, s.announces)
+	return msgs
+}
+
+func (s *Server) handleFind(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rw, err := rwriter.New(w, r, rwriter.WithPreferJson(true))
+	if err != nil {
+		apierror.WriteError(w, r, err)
+		return
+	}
+
+	if rw.MultihashCode() == multihash.DBL_SHA2_256 {
+		s.writeEncrypted(rw, r)
+		return
+	}
+
+	pw := rwriter.NewProviderResponseWriter(rw)
+	s.lock.RLock()
+	prs := s.providerResults(rw.Multihash())
+	s.lock.RUnlock()
+	for _, pr := range prs {
+		if err = pw.WriteProviderResult(pr); err != nil {
+			apierror.WriteError(w, r, err)
+			return
+		}
+	}
+	if err = pw.Close(); err != nil {
+		apierror.WriteError(w, r, err)
+	}
+}
+
+// writeEncrypted writes the encrypted value keys for a double-hashed
+// multihash, in the same form as dhstore.
+func (s *Server) writeEncrypted(rw *rwriter.ResponseWriter, r *http.Request) {
+	s.lock.RLock()
+	evks := s.encIndex[string(rw.Multihash())]
+	s.lock.RUnlock()
+
+	if len(evks) == 0 {
+		apierror.WriteError(rw, r, apierror.New(nil, http.StatusNotFound))
+		return
+	}
+
+	if rw.IsND() {
+		for _, evk := range evks {
+			err := rw.Encoder().Encode(struct {
+				EncryptedValueKey []byte
+			}{evk})
+			if err != nil {
+				return
+			}
+			rw.Flush()
+		}
+		return
+	}
+
+	_ = rw.Encoder().Encode(model.FindResponse{
+		EncryptedMultihashResults: []model.EncryptedMultihashResult{{
+			Multihash:          rw.Multihash(),
+			EncryptedValueKeys: evks,
+		}},
+	})
+}
+
+func (s *Server) handleFindBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req, err := model.UnmarshalFindRequest(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp model.FindResponse
+	s.lock.RLock()
+	for _, mh := range req.Multihashes {
+		dm, err := multihash.Decode(mh)
+		if err != nil {
+			s.lock.RUnlock()
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if dm.Code == multihash.DBL_SHA2_256 {
+			if evks := s.encIndex[string(mh)]; len(evks) != 0 {
+				resp.EncryptedMultihashResults = append(resp.EncryptedMultihashResults, model.EncryptedMultihashResult{
+					Multihash:          mh,
+					EncryptedValueKeys: evks,
+				})
+			}
+			continue
+		}
+		if prs := s.providerResults(mh); len(prs) != 0 {
+			resp.MultihashResults = append(resp.MultihashResults, model.MultihashResult{
+				Multihash:       mh,
+				ProviderResults: prs,
+			})
+		}
+	}
+	s.lock.RUnlock()
+
+	if len(resp.MultihashResults) == 0 && len(resp.EncryptedMultihashResults) == 0 {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, &resp)
+}
+
+// providerResults returns the provider results for a multihash. Must be
+// called with read lock held.
+func (s *Server) providerResults(mh multihash.Multihash) []model.ProviderResult {
+	var prs []model.ProviderResult
+	for _, vk := range s.index[string(mh)] {
+		md, ok := s.metadata[vk]
+		if !ok {
+			continue
+		}
+		pid, ctxID, err := dhash.SplitValueKey([]byte(vk))
+		if err != nil {
+			continue
+		}
+		addrInfo := &peer.AddrInfo{ID: pid}
+		if info, ok := s.providers[pid]; ok {
+			ai := info.AddrInfo
+			addrInfo = &ai
+		}
+		prs = append(prs, model.ProviderResult{
+			ContextID: ctxID,
+			Metadata:  md,
+			Provider:  addrInfo,
+		})
+	}
+	return prs
+}
+
+func (s *Server) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+	hvk, err := b58.Decode(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, "cannot decode value key hash", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.RLock()
+	encMetadata, ok := s.encMetadata[string(hvk)]
+	s.lock.RUnlock()
+	if !ok {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, struct {
+		EncryptedMetadata []byte
+	}{encMetadata})
+}
+
+func (s *Server) handleListProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+	s.lock.RLock()
+	infos := make([]*model.ProviderInfo, 0, len(s.providers))
+	for _, info := range s.providers {
+		infoCopy := *info
+		infos = append(infos, &infoCopy)
+	}
+	s.lock.RUnlock()
+	writeJSON(w, infos)
+}
+
+func (s *Server) handleGetProvider(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+	pid, err := peer.Decode(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, "cannot decode provider id", http.StatusBadRequest)
+		return
+	}
+	s.lock.RLock()
+	info, ok := s.providers[pid]
+	var infoCopy model.ProviderInfo
+	if ok {
+		infoCopy = *info
+	}
+	s.lock.RUnlock()
+	if !ok {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, &infoCopy)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+	s.lock.RLock()
+	stats := s.stats
+	s.lock.RUnlock()
+	data, err := model.MarshalStats(&stats)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (s *Server) handleAnnounce(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var msg message.Message
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var err error
+	if mt == "application/json" {
+		err = json.NewDecoder(r.Body).Decode(&msg)
+	} else {
+		err = msg.UnmarshalCBOR(r.Body)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot decode announce message: %s", err), http.StatusBadRequest)
+		return
+	}
+	if !msg.Cid.Defined() {
+		http.Error(w, "announce message missing cid", http.StatusBadRequest)
+		return
+	}
+	addrs, err := msg.GetAddrs()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("bad announce addresses: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	s.announces = append(s.announces, msg)
+	if len(addrs) != 0 {
+		addrInfos, err := peer.AddrInfosFromP2pAddrs(addrs...)
+		if err == nil {
+			for i := range addrInfos {
+				s.updatePublisher(&addrInfos[i], msg.Cid)
+			}
+		}
+	}
+	s.lock.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updatePublisher records the announced advertisement for the publisher.
+// Must be called with the write lock held.
+func (s *Server) updatePublisher(pubInfo *peer.AddrInfo, adCid cid.Cid) {
+	info, ok := s.providers[pubInfo.ID]
+	if !ok {
+		info = &model.ProviderInfo{
+			AddrInfo: peer.AddrInfo{ID: pubInfo.ID},
+		}
+		s.providers[pubInfo.ID] = info
+	}
+	info.Publisher = pubInfo
+	info.LastAdvertisement = adCid
+	info.LastAdvertisementTime = time.Now().Format(time.RFC3339)
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rec, err := ingestmodel.ReadRegisterRequest(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	info, ok := s.providers[rec.PeerID]
+	if !ok {
+		info = &model.ProviderInfo{}
+		s.providers[rec.PeerID] = info
+	}
+	info.AddrInfo = peer.AddrInfo{
+		ID:    rec.PeerID,
+		Addrs: rec.Addrs,
+	}
+	s.lock.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleIndexContent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req, err := ingestmodel.ReadIngestRequest(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err = multihash.Decode(req.Multihash); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maddrs := make([]multiaddr.Multiaddr, 0, len(req.Addrs))
+	for _, addr := range req.Addrs {
+		maddr, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("bad address: %s", err), http.StatusBadRequest)
+			return
+		}
+		maddrs = append(maddrs, maddr)
+	}
+
+	s.lock.Lock()
+	err = s.put(req.ProviderID, req.ContextID, req.Metadata, []multihash.Multihash{req.Multihash})
+	if err == nil && len(maddrs) != 0 {
+		s.providers[req.ProviderID].AddrInfo.Addrs = maddrs
+	}
+	s.lock.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package fakeindexer_test
+
+import (
+	"context"
+	"testing"
+
+	findclient "github.com/ipni/go-libipni/find/client"
+	"github.com/ipni/go-libipni/find/model"
+	ingestclient "github.com/ipni/go-libipni/ingest/client"
+	"github.com/ipni/go-libipni/test"
+	"github.com/ipni/go-libipni/test/fakeindexer"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	s := fakeindexer.New()
+	defer s.Close()
+
+	pid, err := peer.Decode("12D3KooWKRyzVWW6ChFjQjK4miCty85Niy48tpPV95XdKu1BcvMA")
+	require.NoError(t, err)
+	s.PutProvider(&model.ProviderInfo{
+		AddrInfo: peer.AddrInfo{
+			ID:    pid,
+			Addrs: test.RandomMultiaddrs(1),
+		},
+	})
+	mhs := test.RandomMultihashes(3)
+	require.NoError(t, s.Put(pid, []byte("ctx1"), []byte("metadata1"), mhs[:2]...))
+	s.SetStats(model.Stats{EntriesCount: 2})
+
+	ctx := context.Background()
+
+	c, err := findclient.New(s.URL)
+	require.NoError(t, err)
+
+	resp, err := c.Find(ctx, mhs[0])
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 1)
+	require.Len(t, resp.MultihashResults[0].ProviderResults, 1)
+	pr := resp.MultihashResults[0].ProviderResults[0]
+	require.Equal(t, []byte("metadata1"), pr.Metadata)
+	require.Len(t, pr.Provider.Addrs, 1)
+
+	resp, err = c.Find(ctx, mhs[2])
+	require.NoError(t, err)
+	require.Empty(t, resp.MultihashResults)
+
+	resp, err = c.FindBatch(ctx, mhs)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 2)
+
+	resChan := make(chan model.ProviderResult)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- c.FindAsync(ctx, mhs[1], resChan)
+	}()
+	var count int
+	for range resChan {
+		count++
+	}
+	require.NoError(t, <-errChan)
+	require.Equal(t, 1, count)
+
+	pinfo, err := c.GetProvider(ctx, pid)
+	require.NoError(t, err)
+	require.Equal(t, pid, pinfo.AddrInfo.ID)
+
+	pinfos, err := c.ListProviders(ctx)
+	require.NoError(t, err)
+	require.Len(t, pinfos, 1)
+
+	stats, err := c.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stats.EntriesCount)
+
+	dc, err := findclient.NewDHashClient(findclient.WithDHStoreURL(s.URL))
+	require.NoError(t, err)
+
+	resp, err = dc.Find(ctx, mhs[0])
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults[0].ProviderResults, 1)
+	require.True(t, pr.Equal(resp.MultihashResults[0].ProviderResults[0]))
+
+	resp, err = dc.FindBatch(ctx, mhs)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 2)
+
+	s.RemoveContext(pid, []byte("ctx1"))
+	resp, err = c.Find(ctx, mhs[0])
+	require.NoError(t, err)
+	require.Empty(t, resp.MultihashResults)
+}
+
+func TestIngest(t *testing.T) {
+	s := fakeindexer.New()
+	defer s.Close()
+
+	privKey, pubKey, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	pid, err := peer.IDFromPublicKey(pubKey)
+	require.NoError(t, err)
+	addrs := test.RandomAddrs(1)
+
+	ctx := context.Background()
+	ic, err := ingestclient.New(s.URL)
+	require.NoError(t, err)
+
+	require.NoError(t, ic.Register(ctx, pid, privKey, addrs))
+
+	mh := test.RandomMultihashes(1)[0]
+	require.NoError(t, ic.IndexContent(ctx, pid, privKey, mh, []byte("ctx1"), []byte("metadata1"), addrs))
+
+	adCid := test.RandomCids(1)[0]
+	require.NoError(t, ic.Announce(ctx, &peer.AddrInfo{ID: pid, Addrs: test.RandomMultiaddrs(1)}, adCid))
+	announces := s.Announces()
+	require.Len(t, announces, 1)
+	require.Equal(t, adCid, announces[0].Cid)
+
+	c, err := findclient.New(s.URL)
+	require.NoError(t, err)
+	resp, err := c.Find(ctx, mh)
+	require.NoError(t, err)
+	require.Len(t, resp.MultihashResults, 1)
+	pr := resp.MultihashResults[0].ProviderResults[0]
+	require.Equal(t, pid, pr.Provider.ID)
+	require.Equal(t, addrs[0], pr.Provider.Addrs[0].String())
+
+	pinfo, err := c.GetProvider(ctx, pid)
+	require.NoError(t, err)
+	require.NotNil(t, pinfo.Publisher)
+	require.Equal(t, adCid, pinfo.LastAdvertisement)
+}